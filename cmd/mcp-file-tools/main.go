@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/dimitar-grigorov/mcp-file-tools/filetoolsserver"
 	"github.com/dimitar-grigorov/mcp-file-tools/internal/security"
@@ -37,14 +39,16 @@ func main() {
 		}
 	}
 
-	// Create MCP server with allowed directories (can be empty, directories can be added dynamically)
-	// Pass nil for logger to disable logging middleware (recovery still active)
-	// Pass nil for config to load from environment variables (MCP_DEFAULT_ENCODING, MCP_MEMORY_THRESHOLD)
-	server := filetoolsserver.NewServer(normalized, nil, nil)
+	// Stop on interrupt as well as when the client disconnects, so the directory
+	// watcher is shut down either way
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	// Run server on stdio transport
-	ctx := context.Background()
-	if err := server.Run(ctx, &mcp.StdioTransport{}); err != nil {
+	// Run MCP server with allowed directories on stdio transport (can be empty, directories can be added dynamically)
+	// Pass nil for logger to disable logging middleware (recovery still active)
+	// Pass nil for config to load from environment variables (MCP_DEFAULT_ENCODING, MCP_MEMORY_THRESHOLD, MCP_CUSTOM_ENCODINGS, MCP_CONTENT_INDEX, MCP_INDEX_DIR, MCP_WATCH)
+	err = filetoolsserver.Run(ctx, &mcp.StdioTransport{}, normalized, nil, nil)
+	if err != nil && ctx.Err() == nil {
 		fmt.Fprintf(os.Stderr, "Server error: %v\n", err)
 		os.Exit(1)
 	}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -17,8 +18,8 @@ func (h *Handler) HandleChangeLineEndings(ctx context.Context, req *mcp.CallTool
 	}
 
 	style := strings.ToLower(input.Style)
-	if style != LineEndingLF && style != LineEndingCRLF {
-		return errorResult("style must be \"lf\" or \"crlf\""), ChangeLineEndingsOutput{}, nil
+	if style != LineEndingLF && style != LineEndingCRLF && style != LineEndingCR {
+		return errorResult("style must be \"lf\", \"crlf\" or \"cr\""), ChangeLineEndingsOutput{}, nil
 	}
 
 	data, err := os.ReadFile(v.Path)
@@ -26,12 +27,29 @@ func (h *Handler) HandleChangeLineEndings(ctx context.Context, req *mcp.CallTool
 		return errorResult(fmt.Sprintf("failed to read file: %v", err)), ChangeLineEndingsOutput{}, nil
 	}
 
-	// Detect current line endings
+	// UTF-16 is converted as text, so its two-byte line endings and BOM survive
+	charset, enc, err := detectWideEncoding(data)
+	if err != nil {
+		return errorResult(err.Error()), ChangeLineEndingsOutput{}, nil
+	}
+	if enc != nil {
+		if data, err = enc.NewDecoder().Bytes(data); err != nil {
+			return errorResult(fmt.Sprintf("failed to decode %s content: %v", charset, err)), ChangeLineEndingsOutput{}, nil
+		}
+	}
+
+	// Detect current line endings. Unicode separators are converted too, but
+	// only in Unicode text: in legacy encodings their bytes are other characters.
 	info := DetectLineEndings(data)
 	originalStyle := info.Style
+	content := string(data)
+	separators := 0
+	if enc != nil || utf8.Valid(data) {
+		separators = countUnicodeSeparators(content)
+	}
 
 	// Already in target style — no-op
-	if originalStyle == style || originalStyle == LineEndingNone {
+	if separators == 0 && (originalStyle == style || originalStyle == LineEndingNone) {
 		return &mcp.CallToolResult{}, ChangeLineEndingsOutput{
 			Message:       fmt.Sprintf("File already uses %s line endings, no changes needed", style),
 			OriginalStyle: originalStyle,
@@ -41,26 +59,37 @@ func (h *Handler) HandleChangeLineEndings(ctx context.Context, req *mcp.CallTool
 	}
 
 	// Count lines that will change
-	var linesChanged int
-	if style == LineEndingLF {
-		linesChanged = info.CRLFCount
-	} else {
-		linesChanged = info.LFCount
+	linesChanged := info.CRLFCount + info.LFCount + info.CRCount + separators
+	switch style {
+	case LineEndingLF:
+		linesChanged -= info.LFCount
+	case LineEndingCRLF:
+		linesChanged -= info.CRLFCount
+	case LineEndingCR:
+		linesChanged -= info.CRCount
 	}
 
 	// Convert
-	content := string(data)
-	converted := ConvertLineEndings(content, style)
+	if separators > 0 {
+		content = replaceUnicodeSeparators(content)
+	}
+	converted := []byte(ConvertLineEndings(content, style))
+	if enc != nil {
+		if converted, err = enc.NewEncoder().Bytes(converted); err != nil {
+			return errorResult(fmt.Sprintf("failed to encode %s content: %v", charset, err)), ChangeLineEndingsOutput{}, nil
+		}
+	}
 
 	mode := getFileMode(v.Path)
-	if err := atomicWriteFile(v.Path, []byte(converted), mode); err != nil {
+	if err := atomicWriteFile(v.Path, converted, mode); err != nil {
 		return errorResult(fmt.Sprintf("failed to write file: %v", err)), ChangeLineEndingsOutput{}, nil
 	}
 
 	return &mcp.CallToolResult{}, ChangeLineEndingsOutput{
-		Message:       fmt.Sprintf("Converted %s from %s to %s (%d lines changed)", input.Path, originalStyle, style, linesChanged),
-		OriginalStyle: originalStyle,
-		NewStyle:      style,
-		LinesChanged:  linesChanged,
+		Message:           fmt.Sprintf("Converted %s from %s to %s (%d lines changed)", input.Path, originalStyle, style, linesChanged),
+		OriginalStyle:     originalStyle,
+		NewStyle:          style,
+		LinesChanged:      linesChanged,
+		UnicodeSeparators: separators,
 	}, nil
 }
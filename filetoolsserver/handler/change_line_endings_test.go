@@ -4,7 +4,10 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/dimitar-grigorov/mcp-file-tools/internal/encoding"
 )
 
 func TestHandleChangeLineEndings_CRLFtoLF(t *testing.T) {
@@ -172,3 +175,78 @@ func TestHandleChangeLineEndings_EmptyFile(t *testing.T) {
 		t.Errorf("expected linesChanged=0 for file with no line endings, got %d", output.LinesChanged)
 	}
 }
+
+// encodeUTF16 returns text with a BOM in the given UTF-16 encoding.
+func encodeUTF16(t *testing.T, charset, text string) []byte {
+	t.Helper()
+	enc, _ := encoding.Get(charset)
+	data, err := enc.NewEncoder().Bytes([]byte("\ufeff" + text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestHandleChangeLineEndings_UTF16(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+	text := "Windows Registry Editor Version 5.00\r\n\r\n[HKEY_CURRENT_USER\\Software\\Тест]\r\n"
+
+	for _, charset := range []string{"utf-16-le", "utf-16-be"} {
+		t.Run(charset, func(t *testing.T) {
+			testFile := filepath.Join(tempDir, charset+".reg")
+			os.WriteFile(testFile, encodeUTF16(t, charset, text), 0644)
+
+			result, output, _ := h.HandleChangeLineEndings(context.Background(), nil, ChangeLineEndingsInput{Path: testFile, Style: "lf"})
+			if result.IsError {
+				t.Fatalf("unexpected error: %s", extractTextFromResult(result.Content))
+			}
+			if output.OriginalStyle != "crlf" || output.LinesChanged != 3 {
+				t.Errorf("expected 3 CRLF lines changed, got %+v", output)
+			}
+			data, _ := os.ReadFile(testFile)
+			if want := encodeUTF16(t, charset, strings.ReplaceAll(text, "\r\n", "\n")); string(data) != string(want) {
+				t.Errorf("unexpected content % x", data)
+			}
+
+			// And back again
+			result, output, _ = h.HandleChangeLineEndings(context.Background(), nil, ChangeLineEndingsInput{Path: testFile, Style: "crlf"})
+			if result.IsError || output.OriginalStyle != "lf" {
+				t.Fatalf("unexpected result %+v", output)
+			}
+			data, _ = os.ReadFile(testFile)
+			if want := encodeUTF16(t, charset, text); string(data) != string(want) {
+				t.Errorf("unexpected content % x", data)
+			}
+		})
+	}
+}
+
+func TestHandleChangeLineEndings_CR(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+	testFile := filepath.Join(tempDir, "export.txt")
+	os.WriteFile(testFile, []byte("line1\rline2\r\nline3\u2028line4\n"), 0644)
+
+	result, output, _ := h.HandleChangeLineEndings(context.Background(), nil, ChangeLineEndingsInput{Path: testFile, Style: "cr"})
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", extractTextFromResult(result.Content))
+	}
+	if output.OriginalStyle != LineEndingMixed || output.LinesChanged != 3 || output.UnicodeSeparators != 1 {
+		t.Errorf("unexpected output %+v", output)
+	}
+	data, _ := os.ReadFile(testFile)
+	if string(data) != "line1\rline2\rline3\rline4\r" {
+		t.Errorf("unexpected content %q", data)
+	}
+
+	// Only Unicode separators left to convert
+	os.WriteFile(testFile, []byte("a\u0085b\n"), 0644)
+	_, output, _ = h.HandleChangeLineEndings(context.Background(), nil, ChangeLineEndingsInput{Path: testFile, Style: "lf"})
+	if output.LinesChanged != 1 {
+		t.Errorf("expected the separator to be converted, got %+v", output)
+	}
+	if data, _ := os.ReadFile(testFile); string(data) != "a\nb\n" {
+		t.Errorf("unexpected content %q", data)
+	}
+}
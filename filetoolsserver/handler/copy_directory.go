@@ -0,0 +1,347 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dimitar-grigorov/mcp-file-tools/internal/encoding"
+	"github.com/dimitar-grigorov/mcp-file-tools/internal/security"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// copyPlan is everything copy_directory will create, listed before copying.
+type copyPlan struct {
+	dirs    []copyEntry // parents first
+	files   []copyEntry
+	skipped []CopySkippedFile
+}
+
+// copyEntry is a directory or file to create in the destination, with the mode
+// and modification time of its source.
+type copyEntry struct {
+	src, dst string
+	backup   string // overwrite "backup-existing": the existing file is moved here
+	mode     fs.FileMode
+	modTime  time.Time
+	size     int64
+}
+
+// copyCandidate is a source file found by the walk, before the overwrite policy
+// has been applied to it.
+type copyCandidate struct {
+	rel     string
+	entry   copyEntry
+	info    fs.FileInfo
+	dstInfo fs.FileInfo // nil if the destination does not exist
+}
+
+// HandleCopyDirectory copies a directory tree, keeping file modes and
+// modification times. Conflicts with existing files are checked before anything
+// is copied. With targetEncoding, text files are transcoded on the way.
+func (h *Handler) HandleCopyDirectory(ctx context.Context, req *mcp.CallToolRequest, input CopyDirectoryInput) (*mcp.CallToolResult, CopyDirectoryOutput, error) {
+	src, dst := h.ValidateSourceDest(input.Source, input.Destination)
+	if !src.Ok() {
+		return src.Result, CopyDirectoryOutput{}, nil
+	}
+	if !dst.Ok() {
+		return dst.Result, CopyDirectoryOutput{}, nil
+	}
+
+	srcInfo, err := os.Stat(src.Path)
+	if os.IsNotExist(err) {
+		return errorResult(fmt.Sprintf("source does not exist: %s", input.Source)), CopyDirectoryOutput{}, nil
+	}
+	if err != nil {
+		return errorResult(fmt.Sprintf("failed to access source: %v", err)), CopyDirectoryOutput{}, nil
+	}
+	if !srcInfo.IsDir() {
+		return errorResult("source is not a directory, use copy_file to copy files"), CopyDirectoryOutput{}, nil
+	}
+	if info, err := os.Stat(dst.Path); err == nil && !info.IsDir() {
+		return errorResult(fmt.Sprintf("destination exists and is not a directory: %s", input.Destination)), CopyDirectoryOutput{}, nil
+	}
+	if security.IsPathWithinAllowedDirectories(dst.Path, []string{src.Path}) {
+		return errorResult("destination is inside the source directory"), CopyDirectoryOutput{}, nil
+	}
+
+	overwrite, err := parseOverwritePolicy(input.Overwrite)
+	if err != nil {
+		return errorResult(err.Error()), CopyDirectoryOutput{}, nil
+	}
+	var targetEncoding string
+	if input.TargetEncoding != "" {
+		name, ok := encoding.CanonicalName(strings.ToLower(input.TargetEncoding))
+		if !ok {
+			return errorResult(fmt.Sprintf("%v: %s. Use list_encodings to see available encodings", ErrEncodingUnsupported, input.TargetEncoding)), CopyDirectoryOutput{}, nil
+		}
+		targetEncoding = name
+	}
+	if input.From != "" {
+		if _, ok := encoding.Get(strings.ToLower(input.From)); !ok {
+			return errorResult(fmt.Sprintf("%v: %s. Use list_encodings to see available encodings", ErrEncodingUnsupported, input.From)), CopyDirectoryOutput{}, nil
+		}
+	}
+	filter, err := newFileFilter(input.Include, input.Exclude)
+	if err != nil {
+		return errorResult(err.Error()), CopyDirectoryOutput{}, nil
+	}
+	filter.respectIgnoreFiles = input.RespectIgnoreFiles == nil || *input.RespectIgnoreFiles // default: true
+
+	plan, err := h.planCopy(ctx, src.Path, dst.Path, filter, overwrite)
+	if err != nil {
+		return errorResult(err.Error()), CopyDirectoryOutput{}, nil
+	}
+	output := CopyDirectoryOutput{
+		FilesCopied:        len(plan.files),
+		DirectoriesCreated: len(plan.dirs),
+		Skipped:            plan.skipped,
+	}
+	if input.DryRun {
+		for _, file := range plan.files {
+			output.Paths = append(output.Paths, file.dst)
+		}
+		output.Message = fmt.Sprintf("Would copy %d files to %s, creating %d directories", len(plan.files), input.Destination, len(plan.dirs))
+		return &mcp.CallToolResult{}, output, nil
+	}
+
+	// Directories stay writable until their files are in; their own mode and
+	// time are set last, as adding files changes both
+	for _, dir := range plan.dirs {
+		if err := os.MkdirAll(dir.dst, dir.mode|0o700); err != nil {
+			return errorResult(fmt.Sprintf("failed to create directory: %v", err)), CopyDirectoryOutput{}, nil
+		}
+	}
+	for i, file := range plan.files {
+		if ctx.Err() != nil {
+			return errorResult(fmt.Sprintf("copy cancelled after %d of %d files", i, len(plan.files))), CopyDirectoryOutput{}, nil
+		}
+		var converted bool
+		var reason string
+		err := destination{path: file.dst, backup: file.backup}.write(func() (err error) {
+			converted, reason, err = h.copyTreeFile(file, input.From, targetEncoding)
+			return err
+		})
+		if err != nil {
+			return errorResult(fmt.Sprintf("failed to copy %s after %d of %d files: %v", file.src, i, len(plan.files), err)), CopyDirectoryOutput{}, nil
+		}
+		if file.backup != "" {
+			output.Backups = append(output.Backups, file.backup)
+		}
+		if converted {
+			output.FilesConverted++
+		}
+		if reason != "" {
+			output.NotConverted = append(output.NotConverted, CopySkippedFile{Path: file.src, Reason: reason})
+		}
+	}
+	for i := len(plan.dirs) - 1; i >= 0; i-- {
+		dir := plan.dirs[i]
+		if err := os.Chmod(dir.dst, dir.mode); err != nil {
+			return errorResult(fmt.Sprintf("failed to set directory mode: %v", err)), CopyDirectoryOutput{}, nil
+		}
+		if err := os.Chtimes(dir.dst, dir.modTime, dir.modTime); err != nil {
+			return errorResult(fmt.Sprintf("failed to set directory times: %v", err)), CopyDirectoryOutput{}, nil
+		}
+	}
+
+	output.Applied = true
+	output.Message = fmt.Sprintf("Successfully copied %d files to %s", len(plan.files), input.Destination)
+	if targetEncoding != "" {
+		output.Message += fmt.Sprintf(", %d converted to %s", output.FilesConverted, targetEncoding)
+	}
+	return &mcp.CallToolResult{}, output, nil
+}
+
+// planCopy walks the source and lists the directories and files to create.
+// Without include patterns every directory is copied, even an empty one; with
+// them only the directories that receive files are.
+func (h *Handler) planCopy(ctx context.Context, srcRoot, dstRoot string, filter fileFilter, overwrite string) (copyPlan, error) {
+	var plan copyPlan
+	planned := make(map[string]bool)
+	var addDir func(rel string) error
+	addDir = func(rel string) error {
+		if planned[rel] {
+			return nil
+		}
+		if rel != "." {
+			if err := addDir(path.Dir(rel)); err != nil {
+				return err
+			}
+		}
+		planned[rel] = true
+		srcDir := filepath.Join(srcRoot, filepath.FromSlash(rel))
+		dstDir := filepath.Join(dstRoot, filepath.FromSlash(rel))
+		// Lstat, as writing through a link could leave the allowed directories;
+		// the destination root itself was resolved by validation
+		if info, err := os.Lstat(dstDir); err == nil {
+			if info.Mode()&fs.ModeSymlink != 0 {
+				return fmt.Errorf("cannot copy directory %s: %s is a symbolic link", srcDir, dstDir)
+			}
+			if !info.IsDir() {
+				return fmt.Errorf("cannot copy directory %s: %s exists and is not a directory", srcDir, dstDir)
+			}
+			return nil // copied into the existing directory
+		}
+		info, err := os.Stat(srcDir)
+		if err != nil {
+			return err
+		}
+		plan.dirs = append(plan.dirs, copyEntry{src: srcDir, dst: dstDir, mode: info.Mode().Perm(), modTime: info.ModTime()})
+		return nil
+	}
+
+	ignores := h.ignoreMatcher(srcRoot, filter.respectIgnoreFiles)
+	var candidates []copyCandidate
+	var conflicts []string
+	err := filepath.WalkDir(srcRoot, func(p string, d fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(srcRoot, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if p != srcRoot && (filter.excludeDir(rel) || ignores.Ignored(p, true)) {
+				return filepath.SkipDir
+			}
+			if filter.include.Empty() {
+				return addDir(rel)
+			}
+			return nil
+		}
+		if !filter.includeFile(rel) || ignores.Ignored(p, false) {
+			return nil
+		}
+		if d.Type()&fs.ModeSymlink != 0 {
+			plan.skipped = append(plan.skipped, CopySkippedFile{Path: p, Reason: "symbolic link"})
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			plan.skipped = append(plan.skipped, CopySkippedFile{Path: p, Reason: "not a regular file"})
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		dstPath := filepath.Join(dstRoot, filepath.FromSlash(rel))
+		candidate := copyCandidate{
+			rel:   rel,
+			entry: copyEntry{src: p, dst: dstPath, mode: info.Mode().Perm(), modTime: info.ModTime(), size: info.Size()},
+			info:  info,
+		}
+		if dstInfo, err := os.Lstat(dstPath); err == nil {
+			candidate.dstInfo = dstInfo
+		}
+		candidates = append(candidates, candidate)
+		return nil
+	})
+	if err != nil {
+		return plan, err
+	}
+
+	// Renamed files and backups must not take the name of another copied file
+	taken := make(map[string]bool, len(candidates))
+	for _, c := range candidates {
+		taken[pathKey(c.entry.dst)] = true
+	}
+	isTaken := func(p string) bool { return taken[pathKey(p)] }
+	for _, c := range candidates {
+		if c.dstInfo != nil {
+			switch {
+			case c.dstInfo.Mode()&fs.ModeSymlink != 0:
+				plan.skipped = append(plan.skipped, CopySkippedFile{Path: c.entry.src, Reason: "destination is a symbolic link"})
+				continue
+			case c.dstInfo.IsDir():
+				plan.skipped = append(plan.skipped, CopySkippedFile{Path: c.entry.src, Reason: "destination is a directory"})
+				continue
+			case overwrite == overwriteFail:
+				conflicts = append(conflicts, c.entry.dst)
+				continue
+			}
+			dest, err := applyOverwritePolicy(c.info, c.entry.dst, c.dstInfo, overwrite, isTaken)
+			if err != nil {
+				return plan, err
+			}
+			if dest.skip != "" {
+				plan.skipped = append(plan.skipped, CopySkippedFile{Path: c.entry.src, Reason: dest.skip})
+				continue
+			}
+			c.entry.dst, c.entry.backup = dest.path, dest.backup
+			taken[pathKey(dest.path)] = true
+			if dest.backup != "" {
+				taken[pathKey(dest.backup)] = true
+			}
+		}
+		if err := addDir(path.Dir(c.rel)); err != nil {
+			return plan, err
+		}
+		plan.files = append(plan.files, c.entry)
+	}
+	if len(conflicts) > 0 {
+		return plan, fmt.Errorf("%d files already exist in the destination, e.g. %s. Set overwrite to %s", len(conflicts), conflicts[0], overwritePolicyNames)
+	}
+	return plan, nil
+}
+
+// copyTreeFile copies one file, transcoding it when targetEncoding is set.
+// Returns whether it was converted, or why a text file was copied as is.
+func (h *Handler) copyTreeFile(file copyEntry, from, targetEncoding string) (bool, string, error) {
+	if targetEncoding == "" {
+		return false, "", copyFile(file.src, file.dst, file.mode, file.modTime)
+	}
+	if file.size > h.config.MemoryThreshold {
+		return false, "file too large to convert", copyFile(file.src, file.dst, file.mode, file.modTime)
+	}
+	original, err := os.ReadFile(file.src)
+	if err != nil {
+		return false, "", err
+	}
+	data, reason := h.transcodeForCopy(original, from, targetEncoding, file.src)
+	if data == nil {
+		return false, reason, copyFile(file.src, file.dst, file.mode, file.modTime)
+	}
+	if err := atomicWriteFile(file.dst, data, file.mode); err != nil {
+		return false, "", err
+	}
+	return true, "", os.Chtimes(file.dst, file.modTime, file.modTime)
+}
+
+// transcodeForCopy returns the content in the target encoding, or nil when the
+// file is to be copied as is: binary files, files whose bytes do not change and
+// files that cannot be converted, with the reason for the latter.
+func (h *Handler) transcodeForCopy(original []byte, from, targetEncoding, path string) ([]byte, string) {
+	// UTF-16 text is full of NUL bytes, but its BOM tells it apart from binary
+	if _, bom := encoding.DetectBOM(original); !bom && isBinaryFile(original) {
+		return nil, ""
+	}
+	content, _, err := h.decodeForRewrite(original, from, path)
+	if err != nil {
+		return nil, err.Error()
+	}
+	// A BOM is kept when the target encoding has one
+	hadBOM := strings.HasPrefix(content, "\ufeff")
+	data, err := encodeContent(strings.TrimPrefix(content, "\ufeff"), targetEncoding)
+	if err != nil {
+		return nil, err.Error()
+	}
+	if hadBOM && hasBOM(targetEncoding) {
+		data = append(encoding.BOMBytesFor(targetEncoding), data...)
+	}
+	if bytes.Equal(data, original) {
+		return nil, ""
+	}
+	return data, ""
+}
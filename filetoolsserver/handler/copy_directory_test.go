@@ -0,0 +1,275 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+func TestHandleCopyDirectory(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+	src := filepath.Join(tempDir, "src")
+	dst := filepath.Join(tempDir, "backup", "src")
+	os.MkdirAll(filepath.Join(src, "forms"), 0755)
+	os.MkdirAll(filepath.Join(src, "empty"), 0755)
+	os.MkdirAll(filepath.Join(src, "__history"), 0755)
+	os.WriteFile(filepath.Join(src, "main.pas"), []byte("unit Main;\r\n"), 0644)
+	os.WriteFile(filepath.Join(src, "forms", "about.dfm"), []byte("object About"), 0600)
+	os.WriteFile(filepath.Join(src, "__history", "main.pas.~1~"), []byte("old"), 0644)
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	os.Chtimes(filepath.Join(src, "forms", "about.dfm"), modTime, modTime)
+	os.Chtimes(filepath.Join(src, "forms"), modTime, modTime)
+
+	input := CopyDirectoryInput{Source: src, Destination: dst, Exclude: GlobList{"__history"}, DryRun: true}
+	result, output, _ := h.HandleCopyDirectory(context.Background(), nil, input)
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", extractTextFromResult(result.Content))
+	}
+	if output.Applied || output.FilesCopied != 2 || output.DirectoriesCreated != 3 || len(output.Paths) != 2 {
+		t.Fatalf("unexpected dry run output: %+v", output)
+	}
+	if _, err := os.Stat(dst); !os.IsNotExist(err) {
+		t.Fatal("dry run created the destination")
+	}
+
+	input.DryRun = false
+	result, output, _ = h.HandleCopyDirectory(context.Background(), nil, input)
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", extractTextFromResult(result.Content))
+	}
+	if !output.Applied || output.FilesCopied != 2 {
+		t.Fatalf("unexpected output: %+v", output)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dst, "main.pas")); string(data) != "unit Main;\r\n" {
+		t.Errorf("unexpected content %q", data)
+	}
+	info, err := os.Stat(filepath.Join(dst, "forms", "about.dfm"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.ModTime().Equal(modTime) {
+		t.Errorf("modification time not preserved: %v", info.ModTime())
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Errorf("mode not preserved: %v", info.Mode().Perm())
+	}
+	if info, _ := os.Stat(filepath.Join(dst, "forms")); !info.ModTime().Equal(modTime) {
+		t.Errorf("directory modification time not preserved: %v", info.ModTime())
+	}
+	if info, err := os.Stat(filepath.Join(dst, "empty")); err != nil || !info.IsDir() {
+		t.Error("empty directory not copied")
+	}
+	if _, err := os.Stat(filepath.Join(dst, "__history")); !os.IsNotExist(err) {
+		t.Error("excluded directory was copied")
+	}
+}
+
+func TestHandleCopyDirectory_Overwrite(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+	src := filepath.Join(tempDir, "src")
+	dst := filepath.Join(tempDir, "dst")
+	os.MkdirAll(src, 0755)
+	os.MkdirAll(dst, 0755)
+	os.WriteFile(filepath.Join(src, "new.txt"), []byte("new"), 0644)
+	os.WriteFile(filepath.Join(src, "changed.txt"), []byte("source"), 0644)
+	os.WriteFile(filepath.Join(dst, "changed.txt"), []byte("destination"), 0644)
+	older, newer := time.Now().Add(-time.Hour), time.Now()
+
+	// Nothing is copied when a file already exists
+	result, _, _ := h.HandleCopyDirectory(context.Background(), nil, CopyDirectoryInput{Source: src, Destination: dst})
+	if !result.IsError {
+		t.Fatal("expected conflict error")
+	}
+	if _, err := os.Stat(filepath.Join(dst, "new.txt")); !os.IsNotExist(err) {
+		t.Fatal("files were copied despite the conflict")
+	}
+
+	tests := []struct {
+		overwrite string
+		srcTime   time.Time
+		want      string
+	}{
+		{"skip", newer, "destination"},
+		{"overwrite-if-newer", older, "destination"},
+		{"overwrite-if-newer", newer, "source"},
+		{"overwrite", older, "source"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.overwrite, func(t *testing.T) {
+			os.WriteFile(filepath.Join(dst, "changed.txt"), []byte("destination"), 0644)
+			os.Chtimes(filepath.Join(dst, "changed.txt"), older.Add(time.Minute), older.Add(time.Minute))
+			os.Chtimes(filepath.Join(src, "changed.txt"), tt.srcTime, tt.srcTime)
+			result, output, _ := h.HandleCopyDirectory(context.Background(), nil, CopyDirectoryInput{Source: src, Destination: dst, Overwrite: tt.overwrite})
+			if result.IsError {
+				t.Fatalf("unexpected error: %s", extractTextFromResult(result.Content))
+			}
+			if data, _ := os.ReadFile(filepath.Join(dst, "changed.txt")); string(data) != tt.want {
+				t.Errorf("expected %q, got %q (%+v)", tt.want, data, output)
+			}
+		})
+	}
+}
+
+func TestHandleCopyDirectory_RenameAndBackup(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+	src := filepath.Join(tempDir, "src")
+	dst := filepath.Join(tempDir, "dst")
+	os.MkdirAll(src, 0755)
+	os.MkdirAll(dst, 0755)
+	// a_1.txt is also copied, so a renamed a.txt must not take its name
+	os.WriteFile(filepath.Join(src, "a.txt"), []byte("new a"), 0644)
+	os.WriteFile(filepath.Join(src, "a_1.txt"), []byte("new a_1"), 0644)
+	os.WriteFile(filepath.Join(dst, "a.txt"), []byte("old a"), 0644)
+
+	result, output, _ := h.HandleCopyDirectory(context.Background(), nil, CopyDirectoryInput{Source: src, Destination: dst, Overwrite: "rename-with-suffix"})
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", extractTextFromResult(result.Content))
+	}
+	for name, want := range map[string]string{"a.txt": "old a", "a_1.txt": "new a_1", "a_2.txt": "new a"} {
+		if data, _ := os.ReadFile(filepath.Join(dst, name)); string(data) != want {
+			t.Errorf("%s: expected %q, got %q (%+v)", name, want, data, output)
+		}
+	}
+
+	os.WriteFile(filepath.Join(src, "a.txt"), []byte("newest a"), 0644)
+	result, output, _ = h.HandleCopyDirectory(context.Background(), nil, CopyDirectoryInput{Source: src, Destination: dst, Overwrite: "backup-existing"})
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", extractTextFromResult(result.Content))
+	}
+	if data, _ := os.ReadFile(filepath.Join(dst, "a.txt")); string(data) != "newest a" {
+		t.Errorf("unexpected content %q", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dst, "a.txt.bak")); string(data) != "old a" || len(output.Backups) != 2 {
+		t.Errorf("existing files not backed up: %q, %+v", data, output)
+	}
+}
+
+func TestHandleCopyDirectory_TargetEncoding(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+	src := filepath.Join(tempDir, "legacy")
+	dst := filepath.Join(tempDir, "mirror")
+	os.MkdirAll(src, 0755)
+	legacy, _ := charmap.Windows1251.NewEncoder().String("// Главная форма\r\nunit Main;\r\n")
+	os.WriteFile(filepath.Join(src, "main.pas"), []byte(legacy), 0644)
+	os.WriteFile(filepath.Join(src, "ascii.pas"), []byte("unit Ascii;\n"), 0644)
+	os.WriteFile(filepath.Join(src, "logo.bmp"), []byte{'B', 'M', 0, 0, 0xff, 0xfe}, 0644)
+
+	result, output, _ := h.HandleCopyDirectory(context.Background(), nil, CopyDirectoryInput{
+		Source:         src,
+		Destination:    dst,
+		TargetEncoding: "UTF8",
+		From:           "cp1251",
+	})
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", extractTextFromResult(result.Content))
+	}
+	if output.FilesCopied != 3 || output.FilesConverted != 1 || len(output.NotConverted) != 0 {
+		t.Fatalf("unexpected output: %+v", output)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dst, "main.pas")); string(data) != "// Главная форма\r\nunit Main;\r\n" {
+		t.Errorf("unexpected content %q", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dst, "logo.bmp")); string(data) != "BM\x00\x00\xff\xfe" {
+		t.Errorf("binary file was changed: % x", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(src, "main.pas")); string(data) != legacy {
+		t.Error("source was modified")
+	}
+}
+
+func TestHandleCopyDirectory_NotConverted(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+	src := filepath.Join(tempDir, "src")
+	os.MkdirAll(src, 0755)
+	os.WriteFile(filepath.Join(src, "ja.txt"), []byte("日本語\n"), 0644)
+
+	// Text the target encoding cannot hold is copied as is and reported
+	_, output, _ := h.HandleCopyDirectory(context.Background(), nil, CopyDirectoryInput{
+		Source:         src,
+		Destination:    filepath.Join(tempDir, "dst"),
+		TargetEncoding: "cp1251",
+	})
+	if output.FilesCopied != 1 || len(output.NotConverted) != 1 {
+		t.Fatalf("expected the file reported as not converted, got %+v", output)
+	}
+	if data, _ := os.ReadFile(filepath.Join(tempDir, "dst", "ja.txt")); string(data) != "日本語\n" {
+		t.Errorf("unexpected content %q", data)
+	}
+}
+
+func TestHandleCopyDirectory_Invalid(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+	src := filepath.Join(tempDir, "src")
+	os.MkdirAll(src, 0755)
+	os.WriteFile(filepath.Join(src, "a.txt"), []byte("a"), 0644)
+
+	tests := []struct {
+		name  string
+		input CopyDirectoryInput
+	}{
+		{"source is a file", CopyDirectoryInput{Source: filepath.Join(src, "a.txt"), Destination: filepath.Join(tempDir, "dst")}},
+		{"destination inside source", CopyDirectoryInput{Source: src, Destination: filepath.Join(src, "copy")}},
+		{"destination is a file", CopyDirectoryInput{Source: src, Destination: filepath.Join(src, "a.txt")}},
+		{"unknown policy", CopyDirectoryInput{Source: src, Destination: filepath.Join(tempDir, "dst"), Overwrite: "always"}},
+		{"unknown encoding", CopyDirectoryInput{Source: src, Destination: filepath.Join(tempDir, "dst"), TargetEncoding: "nope"}},
+		{"outside allowed directories", CopyDirectoryInput{Source: src, Destination: t.TempDir()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _, _ := h.HandleCopyDirectory(context.Background(), nil, tt.input)
+			if !result.IsError {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestHandleCopyDirectory_DestinationSymlinks(t *testing.T) {
+	tempDir := t.TempDir()
+	outside := t.TempDir()
+	h := NewHandler([]string{tempDir})
+	src := filepath.Join(tempDir, "src")
+	dst := filepath.Join(tempDir, "dst")
+	os.MkdirAll(filepath.Join(src, "sub"), 0755)
+	os.MkdirAll(dst, 0755)
+	os.WriteFile(filepath.Join(src, "a.txt"), []byte("a"), 0644)
+	os.WriteFile(filepath.Join(src, "sub", "b.txt"), []byte("b"), 0644)
+	os.WriteFile(filepath.Join(outside, "a.txt"), []byte("outside"), 0644)
+	if err := os.Symlink(outside, filepath.Join(dst, "sub")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	// A linked directory in the destination is refused
+	result, _, _ := h.HandleCopyDirectory(context.Background(), nil, CopyDirectoryInput{Source: src, Destination: dst})
+	if !result.IsError {
+		t.Fatal("expected a linked destination directory to be refused")
+	}
+	if _, err := os.Stat(filepath.Join(outside, "b.txt")); !os.IsNotExist(err) {
+		t.Fatal("file was written outside the allowed directories")
+	}
+
+	// A linked file in the destination is skipped, whatever the policy
+	os.Remove(filepath.Join(dst, "sub"))
+	os.Symlink(filepath.Join(outside, "a.txt"), filepath.Join(dst, "a.txt"))
+	result, output, _ := h.HandleCopyDirectory(context.Background(), nil, CopyDirectoryInput{Source: src, Destination: dst, Overwrite: "overwrite"})
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", extractTextFromResult(result.Content))
+	}
+	if len(output.Skipped) != 1 || output.Skipped[0].Reason != "destination is a symbolic link" {
+		t.Errorf("expected the linked file skipped, got %+v", output)
+	}
+	if data, _ := os.ReadFile(filepath.Join(outside, "a.txt")); string(data) != "outside" {
+		t.Errorf("file outside the allowed directories was changed: %q", data)
+	}
+}
@@ -10,7 +10,8 @@ import (
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
-// HandleCopyFile copies a file to a new location.
+// HandleCopyFile copies a file to a new location. An existing destination is
+// handled by the overwrite policy.
 func (h *Handler) HandleCopyFile(ctx context.Context, req *mcp.CallToolRequest, input CopyFileInput) (*mcp.CallToolResult, CopyFileOutput, error) {
 	src, dst := h.ValidateSourceDest(input.Source, input.Destination)
 	if !src.Ok() {
@@ -19,6 +20,10 @@ func (h *Handler) HandleCopyFile(ctx context.Context, req *mcp.CallToolRequest,
 	if !dst.Ok() {
 		return dst.Result, CopyFileOutput{}, nil
 	}
+	policy, err := parseOverwritePolicy(input.Overwrite)
+	if err != nil {
+		return errorResult(err.Error()), CopyFileOutput{}, nil
+	}
 
 	srcInfo, err := os.Stat(src.Path)
 	if os.IsNotExist(err) {
@@ -29,20 +34,32 @@ func (h *Handler) HandleCopyFile(ctx context.Context, req *mcp.CallToolRequest,
 	}
 
 	if srcInfo.IsDir() {
-		return errorResult("source is a directory, only files can be copied"), CopyFileOutput{}, nil
+		return errorResult("source is a directory, use copy_directory to copy directories"), CopyFileOutput{}, nil
 	}
 
-	if _, err := os.Stat(dst.Path); err == nil {
-		return errorResult(fmt.Sprintf("destination already exists: %s", input.Destination)), CopyFileOutput{}, nil
+	dest, err := resolveDestination(src.Path, srcInfo, dst.Path, policy)
+	if err != nil {
+		return errorResult(err.Error()), CopyFileOutput{}, nil
+	}
+	if dest.skip != "" {
+		message := fmt.Sprintf("Nothing copied: %s (%s)", dest.skip, dest.path)
+		return &mcp.CallToolResult{}, CopyFileOutput{Message: message, Destination: dest.path, Skipped: true}, nil
 	}
 
-	// Copy file with source permissions and timestamps preserved
-	if err := copyFile(src.Path, dst.Path, srcInfo.Mode().Perm(), srcInfo.ModTime()); err != nil {
+	// Copy file with source permissions and timestamps preserved. The copy is
+	// renamed into place, so an overwritten file is replaced atomically
+	err = dest.write(func() error {
+		return copyFile(src.Path, dest.path, srcInfo.Mode().Perm(), srcInfo.ModTime())
+	})
+	if err != nil {
 		return errorResult(fmt.Sprintf("failed to copy file: %v", err)), CopyFileOutput{}, nil
 	}
 
-	message := fmt.Sprintf("Successfully copied %s to %s", input.Source, input.Destination)
-	return &mcp.CallToolResult{}, CopyFileOutput{Message: message}, nil
+	message := fmt.Sprintf("Successfully copied %s to %s", input.Source, dest.path)
+	if dest.backup != "" {
+		message += fmt.Sprintf(", existing file backed up to %s", dest.backup)
+	}
+	return &mcp.CallToolResult{}, CopyFileOutput{Message: message, Destination: dest.path, BackupPath: dest.backup}, nil
 }
 
 func copyFile(src, dst string, mode os.FileMode, modTime time.Time) (err error) {
@@ -115,3 +115,85 @@ func TestHandleCopyFile_OutsideAllowed(t *testing.T) {
 		t.Error("expected error for path outside allowed directories")
 	}
 }
+
+func TestHandleCopyFile_OverwritePolicies(t *testing.T) {
+	older, newer := time.Now().Add(-time.Hour), time.Now()
+	tests := []struct {
+		overwrite  string
+		srcTime    time.Time
+		wantDest   string // relative to the temp dir
+		wantBackup bool
+		wantSkip   bool
+	}{
+		{"overwrite", older, "dest.txt", false, false},
+		{"skip", newer, "dest.txt", false, true},
+		{"overwrite-if-newer", newer, "dest.txt", false, false},
+		{"overwrite-if-newer", older, "dest.txt", false, true},
+		{"rename-with-suffix", newer, "dest_2.txt", false, false},
+		{"backup-existing", newer, "dest.txt", true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.overwrite, func(t *testing.T) {
+			tempDir := t.TempDir()
+			h := NewHandler([]string{tempDir})
+			src := filepath.Join(tempDir, "source.txt")
+			dst := filepath.Join(tempDir, "dest.txt")
+			os.WriteFile(src, []byte("source"), 0644)
+			os.WriteFile(dst, []byte("existing"), 0644)
+			os.WriteFile(filepath.Join(tempDir, "dest_1.txt"), []byte("taken"), 0644)
+			os.Chtimes(dst, older.Add(time.Minute), older.Add(time.Minute))
+			os.Chtimes(src, tt.srcTime, tt.srcTime)
+
+			result, output, _ := h.HandleCopyFile(context.Background(), nil, CopyFileInput{Source: src, Destination: dst, Overwrite: tt.overwrite})
+			if result.IsError {
+				t.Fatalf("unexpected error: %s", extractTextFromResult(result.Content))
+			}
+			if output.Skipped != tt.wantSkip {
+				t.Errorf("expected skipped=%v, got %+v", tt.wantSkip, output)
+			}
+			wantDest := filepath.Join(tempDir, tt.wantDest)
+			if output.Destination != wantDest {
+				t.Errorf("expected destination %s, got %s", wantDest, output.Destination)
+			}
+			want := "source"
+			if tt.wantSkip {
+				want = "existing"
+			}
+			if data, _ := os.ReadFile(wantDest); string(data) != want {
+				t.Errorf("expected %q, got %q", want, data)
+			}
+			if tt.wantBackup {
+				if data, _ := os.ReadFile(dst + ".bak"); string(data) != "existing" || output.BackupPath != dst+".bak" {
+					t.Errorf("existing file not backed up: %q, %+v", data, output)
+				}
+			}
+		})
+	}
+}
+
+func TestHandleCopyFile_IntoDirectory(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+	src := filepath.Join(tempDir, "source.txt")
+	dir := filepath.Join(tempDir, "backup")
+	os.WriteFile(src, []byte("content"), 0644)
+	os.Mkdir(dir, 0755)
+
+	result, output, _ := h.HandleCopyFile(context.Background(), nil, CopyFileInput{Source: src, Destination: dir})
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", extractTextFromResult(result.Content))
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "source.txt")); string(data) != "content" || output.Destination != filepath.Join(dir, "source.txt") {
+		t.Errorf("file not copied into the directory: %q, %+v", data, output)
+	}
+
+	// The file inside the directory is now the one that exists
+	result, _, _ = h.HandleCopyFile(context.Background(), nil, CopyFileInput{Source: src, Destination: dir})
+	if !result.IsError {
+		t.Error("expected error when the file exists in the directory")
+	}
+	result, _, _ = h.HandleCopyFile(context.Background(), nil, CopyFileInput{Source: src, Destination: dir, Overwrite: "always"})
+	if !result.IsError {
+		t.Error("expected error for unknown policy")
+	}
+}
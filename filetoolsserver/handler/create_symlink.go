@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/dimitar-grigorov/mcp-file-tools/internal/security"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// HandleCreateSymlink creates a symbolic link. The target must exist and
+// resolve inside allowed directories, so a link never leads outside them.
+func (h *Handler) HandleCreateSymlink(ctx context.Context, req *mcp.CallToolRequest, input CreateSymlinkInput) (*mcp.CallToolResult, CreateSymlinkOutput, error) {
+	link := h.ValidateLinkPath(input.Path)
+	if !link.Ok() {
+		return link.Result, CreateSymlinkOutput{}, nil
+	}
+	if input.Target == "" {
+		return errorResult("target is required and must be a non-empty string"), CreateSymlinkOutput{}, nil
+	}
+	if _, err := os.Lstat(link.Path); err == nil {
+		return errorResult(fmt.Sprintf("path already exists: %s", input.Path)), CreateSymlinkOutput{}, nil
+	}
+
+	// A relative target is stored as is and resolved from the link's directory.
+	// It is resolved the way the kernel will, following links before "..", so
+	// it is not cleaned first: with sub -> ., sub/../x is ../x, not x.
+	target := security.ExpandHome(input.Target)
+	resolvable := target
+	if !filepath.IsAbs(resolvable) {
+		resolvable = filepath.Dir(link.Path) + string(filepath.Separator) + resolvable
+	}
+	resolved, err := filepath.EvalSymlinks(resolvable)
+	if err != nil {
+		return errorResult(fmt.Sprintf("target does not exist: %s", input.Target)), CreateSymlinkOutput{}, nil
+	}
+	t := h.ValidatePath(resolved)
+	if !t.Ok() {
+		return t.Result, CreateSymlinkOutput{}, nil
+	}
+
+	if err := os.Symlink(target, link.Path); err != nil {
+		return errorResult(fmt.Sprintf("failed to create symbolic link: %v", err)), CreateSymlinkOutput{}, nil
+	}
+
+	message := fmt.Sprintf("Successfully created symbolic link %s -> %s", input.Path, target)
+	return &mcp.CallToolResult{}, CreateSymlinkOutput{Message: message, ResolvedTarget: t.Path}, nil
+}
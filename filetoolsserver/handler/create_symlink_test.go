@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHandleCreateSymlink(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+	os.MkdirAll(filepath.Join(tempDir, "lib"), 0755)
+	os.WriteFile(filepath.Join(tempDir, "lib", "units.pas"), []byte("unit Units;"), 0644)
+	link := filepath.Join(tempDir, "units.pas")
+
+	// A relative target is kept relative in the link
+	result, output, _ := h.HandleCreateSymlink(context.Background(), nil, CreateSymlinkInput{Path: link, Target: "lib/units.pas"})
+	if result.IsError {
+		text := extractTextFromResult(result.Content)
+		if _, err := os.Lstat(link); os.IsNotExist(err) {
+			t.Skipf("symlinks not supported: %s", text)
+		}
+		t.Fatalf("unexpected error: %s", text)
+	}
+	if got, _ := os.Readlink(link); got != "lib/units.pas" {
+		t.Errorf("expected relative target, got %q", got)
+	}
+	if data, _ := os.ReadFile(link); string(data) != "unit Units;" {
+		t.Errorf("link does not resolve to the target: %q", data)
+	}
+	if want, _ := filepath.EvalSymlinks(filepath.Join(tempDir, "lib", "units.pas")); output.ResolvedTarget != want {
+		t.Errorf("expected resolved target %s, got %s", want, output.ResolvedTarget)
+	}
+
+	result, _, _ = h.HandleCreateSymlink(context.Background(), nil, CreateSymlinkInput{Path: link, Target: "lib/units.pas"})
+	if !result.IsError {
+		t.Error("expected error when the link exists")
+	}
+}
+
+func TestHandleCreateSymlink_TargetOutsideAllowed(t *testing.T) {
+	tempDir := t.TempDir()
+	outside := t.TempDir()
+	h := NewHandler([]string{tempDir})
+	os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0644)
+
+	tests := []struct {
+		name   string
+		target string
+	}{
+		{"absolute", filepath.Join(outside, "secret.txt")},
+		{"relative", filepath.Join("..", filepath.Base(outside), "secret.txt")},
+		{"missing", "missing.txt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			link := filepath.Join(tempDir, tt.name)
+			result, _, _ := h.HandleCreateSymlink(context.Background(), nil, CreateSymlinkInput{Path: link, Target: tt.target})
+			if !result.IsError {
+				t.Error("expected error")
+			}
+			if _, err := os.Lstat(link); !os.IsNotExist(err) {
+				t.Error("link was created")
+			}
+		})
+	}
+}
+
+func TestHandleCreateSymlink_DotDotAfterLink(t *testing.T) {
+	tempDir := t.TempDir()
+	outside := t.TempDir()
+	h := NewHandler([]string{tempDir})
+	os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0644)
+	// The lexically cleaned target exists inside, the real one is outside
+	os.MkdirAll(filepath.Join(tempDir, filepath.Base(outside)), 0755)
+	os.WriteFile(filepath.Join(tempDir, filepath.Base(outside), "secret.txt"), []byte("decoy"), 0644)
+	if err := os.Symlink(tempDir, filepath.Join(tempDir, "sub")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+
+	// Not filepath.Join, which would clean the ".." away
+	sep := string(filepath.Separator)
+	target := "sub" + sep + ".." + sep + filepath.Base(outside) + sep + "secret.txt"
+	result, _, _ := h.HandleCreateSymlink(context.Background(), nil, CreateSymlinkInput{Path: filepath.Join(tempDir, "link"), Target: target})
+	if !result.IsError {
+		t.Error("expected a target leaving the allowed directories through a link to be refused")
+	}
+}
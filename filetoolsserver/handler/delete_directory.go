@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/dimitar-grigorov/mcp-file-tools/internal/security"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const defaultMaxDeleteFiles = 1000
+
+// HandleDeleteDirectory deletes a directory. Deleting a non-empty directory needs
+// recursive=true and is refused when it holds more than maxFiles files. An
+// allowed directory, or one containing an allowed directory, is never deleted.
+func (h *Handler) HandleDeleteDirectory(ctx context.Context, req *mcp.CallToolRequest, input DeleteDirectoryInput) (*mcp.CallToolResult, DeleteDirectoryOutput, error) {
+	// Symbolic links are resolved by validation, which would delete the target.
+	// The link path is cleaned, as Lstat follows a link given with a trailing slash.
+	if link := h.ValidateLinkPath(input.Path); link.Ok() {
+		if info, err := os.Lstat(link.Path); err == nil && info.Mode()&fs.ModeSymlink != 0 {
+			return errorResult("path is a symbolic link; deleting it would delete the directory it points to"), DeleteDirectoryOutput{}, nil
+		}
+	}
+	v := h.ValidatePath(input.Path)
+	if !v.Ok() {
+		return v.Result, DeleteDirectoryOutput{}, nil
+	}
+
+	info, err := os.Stat(v.Path)
+	if os.IsNotExist(err) {
+		return errorResult(fmt.Sprintf("directory does not exist: %s", input.Path)), DeleteDirectoryOutput{}, nil
+	}
+	if err != nil {
+		return errorResult(fmt.Sprintf("failed to access directory: %v", err)), DeleteDirectoryOutput{}, nil
+	}
+	if !info.IsDir() {
+		return errorResult("path is not a directory, use delete_file to delete files"), DeleteDirectoryOutput{}, nil
+	}
+	for _, dir := range h.ResolvedAllowedDirs() {
+		if security.IsPathWithinAllowedDirectories(dir, []string{v.Path}) {
+			return errorResult(fmt.Sprintf("refusing to delete %s: it is or contains the allowed directory %s", input.Path, dir)), DeleteDirectoryOutput{}, nil
+		}
+	}
+
+	// List everything first, so nothing is deleted when a guard fails
+	maxFiles := input.MaxFiles
+	if maxFiles <= 0 {
+		maxFiles = defaultMaxDeleteFiles
+	}
+	var paths []string
+	var files, dirs int
+	err = filepath.WalkDir(v.Path, func(p string, d fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			return err
+		}
+		if p == v.Path {
+			return nil
+		}
+		if !input.Recursive {
+			return fmt.Errorf("directory is not empty; set recursive=true to delete it with its contents")
+		}
+		// Links are deleted themselves; WalkDir does not follow them
+		if d.IsDir() {
+			dirs++
+		} else if files++; files > maxFiles {
+			return fmt.Errorf("directory contains more than %d files; raise maxFiles to delete it", maxFiles)
+		}
+		paths = append(paths, p)
+		return nil
+	})
+	if err != nil {
+		return errorResult(err.Error()), DeleteDirectoryOutput{}, nil
+	}
+
+	output := DeleteDirectoryOutput{FilesDeleted: files, DirectoriesDeleted: dirs + 1}
+	if input.DryRun {
+		output.Paths = append(paths, v.Path)
+		output.Message = fmt.Sprintf("Would delete %s with %d files and %d subdirectories", input.Path, files, dirs)
+		return &mcp.CallToolResult{}, output, nil
+	}
+
+	if err := os.RemoveAll(v.Path); err != nil {
+		return errorResult(fmt.Sprintf("failed to delete directory: %v", err)), DeleteDirectoryOutput{}, nil
+	}
+	output.Applied = true
+	output.Message = fmt.Sprintf("Successfully deleted %s with %d files and %d subdirectories", input.Path, files, dirs)
+	return &mcp.CallToolResult{}, output, nil
+}
@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHandleDeleteDirectory_Empty(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+	dir := filepath.Join(tempDir, "empty")
+	os.Mkdir(dir, 0755)
+
+	result, output, _ := h.HandleDeleteDirectory(context.Background(), nil, DeleteDirectoryInput{Path: dir})
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", extractTextFromResult(result.Content))
+	}
+	if !output.Applied || output.DirectoriesDeleted != 1 || output.FilesDeleted != 0 {
+		t.Errorf("unexpected output %+v", output)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Error("directory should be deleted")
+	}
+}
+
+func TestHandleDeleteDirectory_Recursive(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+	dir := filepath.Join(tempDir, "build")
+	for _, f := range []string{"a.dcu", "b.dcu", "sub/c.dcu"} {
+		os.MkdirAll(filepath.Dir(filepath.Join(dir, f)), 0755)
+		os.WriteFile(filepath.Join(dir, f), []byte("x"), 0644)
+	}
+
+	// Non-empty directories need recursive
+	result, _, _ := h.HandleDeleteDirectory(context.Background(), nil, DeleteDirectoryInput{Path: dir})
+	if !result.IsError {
+		t.Fatal("expected error without recursive")
+	}
+
+	result, _, _ = h.HandleDeleteDirectory(context.Background(), nil, DeleteDirectoryInput{Path: dir, Recursive: true, MaxFiles: 2})
+	if !result.IsError {
+		t.Fatal("expected error above maxFiles")
+	}
+
+	result, output, _ := h.HandleDeleteDirectory(context.Background(), nil, DeleteDirectoryInput{Path: dir, Recursive: true, DryRun: true})
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", extractTextFromResult(result.Content))
+	}
+	if output.Applied || output.FilesDeleted != 3 || output.DirectoriesDeleted != 2 || len(output.Paths) != 5 {
+		t.Errorf("unexpected dry run output %+v", output)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "sub", "c.dcu")); err != nil {
+		t.Fatal("dry run deleted files")
+	}
+
+	result, output, _ = h.HandleDeleteDirectory(context.Background(), nil, DeleteDirectoryInput{Path: dir, Recursive: true})
+	if result.IsError || !output.Applied {
+		t.Fatalf("expected directory to be deleted, got %+v", output)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Error("directory should be deleted")
+	}
+}
+
+func TestHandleDeleteDirectory_Refused(t *testing.T) {
+	tempDir := t.TempDir()
+	nested := filepath.Join(tempDir, "projects", "app")
+	os.MkdirAll(nested, 0755)
+	file := filepath.Join(tempDir, "file.txt")
+	os.WriteFile(file, []byte("x"), 0644)
+	h := NewHandler([]string{tempDir, nested})
+
+	for _, path := range []string{tempDir, nested, filepath.Join(tempDir, "projects"), file, filepath.Join(tempDir, "missing"), t.TempDir()} {
+		result, _, _ := h.HandleDeleteDirectory(context.Background(), nil, DeleteDirectoryInput{Path: path, Recursive: true})
+		if !result.IsError {
+			t.Errorf("expected %s to be refused", path)
+		}
+	}
+	if _, err := os.Stat(nested); err != nil {
+		t.Error("allowed directory was deleted")
+	}
+}
+
+func TestHandleDeleteDirectory_SymlinkNotFollowed(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+	target := filepath.Join(tempDir, "target")
+	os.Mkdir(target, 0755)
+	os.WriteFile(filepath.Join(target, "keep.txt"), []byte("x"), 0644)
+	link := filepath.Join(tempDir, "link")
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	dir := filepath.Join(tempDir, "dir")
+	os.Mkdir(dir, 0755)
+	if err := os.Symlink(target, filepath.Join(dir, "inner")); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{link, link + string(filepath.Separator), link + "/"} {
+		result, _, _ := h.HandleDeleteDirectory(context.Background(), nil, DeleteDirectoryInput{Path: path, Recursive: true})
+		if !result.IsError {
+			t.Errorf("expected symbolic link %q to be refused", path)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(target, "keep.txt")); err != nil {
+		t.Fatal("link target was deleted")
+	}
+	// A link inside the directory is deleted, not what it points to
+	result, _, _ := h.HandleDeleteDirectory(context.Background(), nil, DeleteDirectoryInput{Path: dir, Recursive: true})
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", extractTextFromResult(result.Content))
+	}
+	if _, err := os.Stat(filepath.Join(target, "keep.txt")); err != nil {
+		t.Error("link target was deleted")
+	}
+}
@@ -24,7 +24,7 @@ func (h *Handler) HandleDeleteFile(ctx context.Context, req *mcp.CallToolRequest
 	}
 
 	if info.IsDir() {
-		return errorResult("path is a directory, use a different tool to delete directories"), DeleteFileOutput{}, nil
+		return errorResult("path is a directory, use delete_directory to delete directories"), DeleteFileOutput{}, nil
 	}
 
 	if err := os.Remove(v.Path); err != nil {
@@ -2,6 +2,9 @@ package handler
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
+	"os"
 
 	"github.com/dimitar-grigorov/mcp-file-tools/internal/encoding"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
@@ -18,6 +21,9 @@ func (h *Handler) HandleDetectEncoding(ctx context.Context, req *mcp.CallToolReq
 	if mode == "" {
 		mode = "sample"
 	}
+	if mode == "regions" {
+		return h.detectEncodingRegions(v.Path)
+	}
 
 	result, err := encoding.DetectFromFile(v.Path, mode)
 	if err != nil {
@@ -34,3 +40,59 @@ func (h *Handler) HandleDetectEncoding(ctx context.Context, req *mcp.CallToolReq
 		HasBOM:     result.HasBOM,
 	}, nil
 }
+
+// detectEncodingRegions reports per-region encodings. The dominant region
+// (most bytes) is returned as the file encoding.
+func (h *Handler) detectEncodingRegions(path string) (*mcp.CallToolResult, DetectEncodingOutput, error) {
+	if loadToMemory, size := h.shouldLoadEntireFile(path); !loadToMemory {
+		slog.Warn("loading large file into memory", "path", path, "size", size, "threshold", h.config.MemoryThreshold)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return errorResult(fmt.Sprintf("failed to read file: %v", err)), DetectEncodingOutput{}, nil
+	}
+
+	regions := h.detectRegions(data, "")
+	output := DetectEncodingOutput{Regions: toEncodingRegions(regions)}
+	var dominant int64 = -1
+	for _, r := range regions {
+		if size := r.EndByte - r.StartByte; size > dominant {
+			dominant = size
+			output.Encoding = r.Charset
+			output.Confidence = r.Confidence
+		}
+	}
+	_, output.HasBOM = encoding.DetectBOM(data)
+	return &mcp.CallToolResult{}, output, nil
+}
+
+// detectRegions splits data into encoding regions. A non-empty override replaces
+// the detected charset of every non-UTF-8 region; it is ignored for files with a BOM.
+func (h *Handler) detectRegions(data []byte, override string) []encoding.Region {
+	regions := encoding.DetectRegions(data, h.config.DefaultEncoding)
+	if _, hasBOM := encoding.DetectBOM(data); override == "" || hasBOM {
+		return regions
+	}
+	for i := range regions {
+		if !encoding.IsUTF8(regions[i].Charset) {
+			regions[i].Charset = override
+			regions[i].Confidence = 100
+		}
+	}
+	return regions
+}
+
+func toEncodingRegions(regions []encoding.Region) []EncodingRegion {
+	out := make([]EncodingRegion, len(regions))
+	for i, r := range regions {
+		out[i] = EncodingRegion{
+			Encoding:   r.Charset,
+			Confidence: r.Confidence,
+			StartByte:  r.StartByte,
+			EndByte:    r.EndByte,
+			StartLine:  r.StartLine,
+			EndLine:    r.EndLine,
+		}
+	}
+	return out
+}
@@ -222,3 +222,36 @@ func TestHandleDetectEncoding_InvalidMode(t *testing.T) {
 		t.Errorf("expected 'invalid mode' message, got %q", text)
 	}
 }
+
+func TestHandleDetectEncoding_Regions(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+	testFile := filepath.Join(tempDir, "mixed.txt")
+
+	// Line 1-2 UTF-8, line 3 cp1251 ("Текст")
+	data := []byte("header\nПривет, мир\n\xd2\xe5\xea\xf1\xf2\n")
+	if err := os.WriteFile(testFile, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, output, err := h.HandleDetectEncoding(context.Background(), nil, DetectEncodingInput{Path: testFile, Mode: "regions"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.IsError {
+		t.Fatalf("expected success, got error: %s", extractTextFromResult(result.Content))
+	}
+	if len(output.Regions) != 2 {
+		t.Fatalf("expected 2 regions, got %+v", output.Regions)
+	}
+	first, second := output.Regions[0], output.Regions[1]
+	if first.Encoding != "utf-8" || first.StartLine != 1 || first.EndLine != 2 || first.EndByte != 28 {
+		t.Errorf("unexpected first region: %+v", first)
+	}
+	if second.Encoding != "windows-1251" || second.StartByte != 28 || second.EndByte != int64(len(data)) || second.StartLine != 3 {
+		t.Errorf("unexpected second region: %+v", second)
+	}
+	if output.Encoding != "utf-8" {
+		t.Errorf("expected dominant encoding utf-8, got %q", output.Encoding)
+	}
+}
@@ -4,8 +4,8 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"path/filepath"
 
+	"github.com/dimitar-grigorov/mcp-file-tools/internal/glob"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
@@ -20,6 +20,10 @@ func (h *Handler) HandleListDirectory(ctx context.Context, req *mcp.CallToolRequ
 	if input.Pattern != "" {
 		pattern = input.Pattern
 	}
+	matcher, err := glob.Compile(pattern)
+	if err != nil {
+		return errorResult(err.Error()), ListDirectoryOutput{}, nil
+	}
 
 	entries, err := os.ReadDir(v.Path)
 	if err != nil {
@@ -28,11 +32,7 @@ func (h *Handler) HandleListDirectory(ctx context.Context, req *mcp.CallToolRequ
 
 	files := make([]string, 0, len(entries))
 	for _, entry := range entries {
-		matched, err := filepath.Match(pattern, entry.Name())
-		if err != nil {
-			return errorResult(fmt.Sprintf("invalid pattern: %v", err)), ListDirectoryOutput{}, nil
-		}
-		if matched {
+		if matcher.Match(entry.Name()) {
 			prefix := ""
 			if entry.IsDir() {
 				prefix = "[DIR] "
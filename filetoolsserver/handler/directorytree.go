@@ -5,9 +5,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
+	"github.com/dimitar-grigorov/mcp-file-tools/internal/glob"
+	"github.com/dimitar-grigorov/mcp-file-tools/internal/ignore"
 	"github.com/dimitar-grigorov/mcp-file-tools/internal/security"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -25,8 +28,14 @@ func (h *Handler) HandleDirectoryTree(ctx context.Context, req *mcp.CallToolRequ
 	if !stat.IsDir() {
 		return errorResult(ErrPathMustBeDirectory.Error()), DirectoryTreeOutput{}, nil
 	}
+	exclude, err := newTreeExclude(input.ExcludePatterns)
+	if err != nil {
+		return errorResult(fmt.Sprintf("invalid excludePatterns: %v", err)), DirectoryTreeOutput{}, nil
+	}
+	respectIgnoreFiles := input.RespectIgnoreFiles == nil || *input.RespectIgnoreFiles // default: true
+	exclude.ignore = h.ignoreMatcher(v.Path, respectIgnoreFiles)
 	resolvedDirs := h.ResolvedAllowedDirs()
-	tree, err := buildTree(ctx, v.Path, input.ExcludePatterns, resolvedDirs)
+	tree, err := buildTree(ctx, v.Path, "", exclude, resolvedDirs)
 	if err != nil {
 		if err == context.Canceled || err == context.DeadlineExceeded {
 			return errorResult("operation cancelled"), DirectoryTreeOutput{}, nil
@@ -41,8 +50,9 @@ func (h *Handler) HandleDirectoryTree(ctx context.Context, req *mcp.CallToolRequ
 	return &mcp.CallToolResult{}, output, nil
 }
 
-// buildTree recursively builds a tree of directory entries
-func buildTree(ctx context.Context, dirPath string, excludePatterns []string, allowedDirs []string) ([]TreeEntry, error) {
+// buildTree recursively builds a tree of directory entries. relDir is dirPath
+// relative to the tree root, used for exclude matching.
+func buildTree(ctx context.Context, dirPath, relDir string, exclude treeExclude, allowedDirs []string) ([]TreeEntry, error) {
 	select {
 	case <-ctx.Done():
 		return nil, ctx.Err()
@@ -55,7 +65,8 @@ func buildTree(ctx context.Context, dirPath string, excludePatterns []string, al
 	var result []TreeEntry
 	for _, entry := range entries {
 		name := entry.Name()
-		if shouldExclude(name, excludePatterns) {
+		relPath := path.Join(relDir, name)
+		if exclude.matches(filepath.Join(dirPath, name), relPath, entry.IsDir()) {
 			continue
 		}
 		treeEntry := TreeEntry{Name: name}
@@ -65,7 +76,7 @@ func buildTree(ctx context.Context, dirPath string, excludePatterns []string, al
 			if !security.IsPathSafeResolved(childPath, allowedDirs) {
 				continue
 			}
-			children, err := buildTree(ctx, childPath, excludePatterns, allowedDirs)
+			children, err := buildTree(ctx, childPath, relPath, exclude, allowedDirs)
 			if err != nil {
 				if err == context.Canceled || err == context.DeadlineExceeded {
 					return nil, err
@@ -84,32 +95,47 @@ func buildTree(ctx context.Context, dirPath string, excludePatterns []string, al
 	return result, nil
 }
 
-// shouldExclude checks if a name matches any of the exclude patterns
-func shouldExclude(name string, patterns []string) bool {
+// treeExclude holds the compiled exclude patterns of directory_tree.
+type treeExclude struct {
+	set    *glob.Set
+	plain  []string // patterns without glob characters, also matched as substrings
+	ignore *ignore.Matcher
+}
+
+func newTreeExclude(patterns []string) (treeExclude, error) {
+	set, err := glob.CompileSet(patterns)
+	if err != nil {
+		return treeExclude{}, err
+	}
+	e := treeExclude{set: set}
 	for _, pattern := range patterns {
-		// Try exact match first
-		if name == pattern {
-			return true
+		if pattern != "" && !containsGlobChars(pattern) {
+			e.plain = append(e.plain, pattern)
 		}
+	}
+	return e, nil
+}
 
-		// Try glob pattern match
-		matched, err := filepath.Match(pattern, name)
-		if err == nil && matched {
+// matches checks the entry against the exclude patterns and ignore files
+func (e treeExclude) matches(fullPath, relPath string, isDir bool) bool {
+	if isDir && e.set.MatchDir(relPath) || !isDir && e.set.Match(relPath) {
+		return true
+	}
+	if e.ignore.Ignored(fullPath, isDir) {
+		return true
+	}
+	name := path.Base(relPath)
+	// For patterns without wildcards, also try as substring/prefix
+	// This mimics the JS behavior for patterns like "node_modules"
+	for _, pattern := range e.plain {
+		if strings.Contains(name, pattern) {
 			return true
 		}
-
-		// For patterns without wildcards, also try as substring/prefix
-		// This mimics the JS behavior for patterns like "node_modules"
-		if !containsGlobChars(pattern) {
-			if strings.Contains(name, pattern) {
-				return true
-			}
-		}
 	}
 	return false
 }
 
 // containsGlobChars checks if pattern contains glob metacharacters
 func containsGlobChars(pattern string) bool {
-	return strings.ContainsAny(pattern, "*?[")
+	return strings.ContainsAny(pattern, "*?[{!\\")
 }
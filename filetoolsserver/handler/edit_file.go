@@ -44,46 +44,21 @@ func (h *Handler) HandleEditFile(ctx context.Context, req *mcp.CallToolRequest,
 		}
 	}
 
-	data, err := os.ReadFile(v.Path)
-	if err != nil {
-		return errorResult(fmt.Sprintf("failed to read file: %v", err)), EditFileOutput{}, nil
-	}
-
-	// TODO: Use DetectLineEndingsFromFile for streaming when file > MemoryThreshold
-	lineEndings := DetectLineEndings(data)
-	if lineEndings.Style == LineEndingMixed {
-		slog.Warn("file has mixed line endings", "path", input.Path, "crlf", lineEndings.CRLFCount, "lf", lineEndings.LFCount)
-	}
-
-	encodingName, err := h.resolveEncodingFromData(input.Encoding, data, input.Path)
+	file, err := h.readTextForEdit(v.Path, input.Encoding)
 	if err != nil {
 		return errorResult(err.Error()), EditFileOutput{}, nil
 	}
+	file.mode = originalMode
 
-	var content string
-	if encoding.IsUTF8(encodingName) {
-		content = string(data)
-	} else {
-		enc, _ := encoding.Get(encodingName) // Already validated by resolveEncodingFromData
-		decoder := enc.NewDecoder()
-		decoded, err := decoder.Bytes(data)
-		if err != nil {
-			return errorResult(fmt.Sprintf("failed to decode file with %s: %v", encodingName, err)), EditFileOutput{}, nil
-		}
-		content = string(decoded)
-		slog.Debug("edit_file: decoded content", "path", input.Path, "encoding", encodingName, "originalSize", len(data), "decodedSize", len(decoded))
-	}
-
-	content = ConvertLineEndings(content, LineEndingLF)
-	modifiedContent, err := applyEdits(content, input.Edits)
+	modifiedContent, err := applyEdits(file.content, input.Edits)
 	if err != nil {
 		return errorResult(err.Error()), EditFileOutput{}, nil
 	}
 
-	diff := createUnifiedDiff(content, modifiedContent, input.Path)
+	diff := createUnifiedDiff(file.content, modifiedContent, input.Path)
 
 	if !input.DryRun {
-		if err := atomicWriteFileWithEncoding(v.Path, modifiedContent, encodingName, lineEndings.Style, originalMode); err != nil {
+		if err := file.write(v.Path, modifiedContent); err != nil {
 			return errorResult(fmt.Sprintf("failed to write file: %v", err)), EditFileOutput{}, nil
 		}
 	}
@@ -231,30 +206,94 @@ func formatDiffOutput(diff string) string {
 	return fmt.Sprintf("%sdiff\n%s%s\n\n", fence, diff, fence)
 }
 
-// atomicWriteFileWithEncoding encodes UTF-8 content to the target encoding and writes atomically.
-func atomicWriteFileWithEncoding(path, content, encodingName, lineEndingStyle string, mode os.FileMode) error {
-	content = ConvertLineEndings(content, lineEndingStyle)
+// textFile is a text file decoded to UTF-8 with LF line endings, plus the
+// format details needed to write modified content back the same way.
+type textFile struct {
+	content     string
+	encoding    string
+	lineEndings LineEndingInfo
+	mode        os.FileMode
+}
 
-	var dataToWrite []byte
-	if encoding.IsUTF8(encodingName) {
-		dataToWrite = []byte(content)
+// write encodes content in the file's original encoding and line ending style and writes atomically.
+func (f textFile) write(path, content string) error {
+	return atomicWriteFileWithEncoding(path, content, f.encoding, f.lineEndings.Style, f.mode)
+}
+
+// encode converts content to the file's original encoding and line ending style.
+func (f textFile) encode(content string) ([]byte, error) {
+	return encodeText(content, f.encoding, f.lineEndings.Style)
+}
+
+// readTextForEdit reads and decodes a file for in-place modification.
+// Encoding is explicit or auto-detected; line endings are normalized to LF.
+func (h *Handler) readTextForEdit(path, inputEncoding string) (textFile, error) {
+	file := textFile{mode: getFileMode(path)}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return file, fmt.Errorf("failed to read file: %w", err)
+	}
+
+	file.encoding, err = h.resolveEncodingFromData(inputEncoding, data, path)
+	if err != nil {
+		return file, err
+	}
+
+	if encoding.IsUTF8(file.encoding) {
+		file.content = string(data)
 	} else {
-		enc, ok := encoding.Get(encodingName)
-		if !ok {
-			return fmt.Errorf("unsupported encoding: %s", encodingName)
-		}
-		encoder := enc.NewEncoder()
-		encoded, err := encoder.Bytes([]byte(content))
+		enc, _ := encoding.Get(file.encoding) // Already validated by resolveEncodingFromData
+		decoded, err := enc.NewDecoder().Bytes(data)
 		if err != nil {
-			return fmt.Errorf("failed to encode content to %s: %w", encodingName, err)
+			return file, fmt.Errorf("failed to decode file with %s: %w", file.encoding, err)
 		}
-		dataToWrite = encoded
-		slog.Debug("edit_file: encoded content for write", "encoding", encodingName, "utf8Size", len(content), "encodedSize", len(encoded))
+		file.content = string(decoded)
+		slog.Debug("decoded content for edit", "path", path, "encoding", file.encoding, "originalSize", len(data), "decodedSize", len(decoded))
+	}
+
+	// Detected on the decoded text, where line endings of UTF-16 files are single bytes
+	// TODO: Use DetectLineEndingsFromFile for streaming when file > MemoryThreshold
+	file.lineEndings = DetectLineEndings([]byte(file.content))
+	if file.lineEndings.Style == LineEndingMixed {
+		slog.Warn("file has mixed line endings", "path", path, "crlf", file.lineEndings.CRLFCount, "lf", file.lineEndings.LFCount)
 	}
 
+	file.content = ConvertLineEndings(file.content, LineEndingLF)
+	return file, nil
+}
+
+// atomicWriteFileWithEncoding encodes UTF-8 content to the target encoding and writes atomically.
+func atomicWriteFileWithEncoding(path, content, encodingName, lineEndingStyle string, mode os.FileMode) error {
+	dataToWrite, err := encodeText(content, encodingName, lineEndingStyle)
+	if err != nil {
+		return err
+	}
 	return atomicWriteFile(path, dataToWrite, mode)
 }
 
+// encodeText converts UTF-8 content with LF line endings to the target line ending style and encoding.
+func encodeText(content, encodingName, lineEndingStyle string) ([]byte, error) {
+	return encodeContent(ConvertLineEndings(content, lineEndingStyle), encodingName)
+}
+
+// encodeContent converts UTF-8 content to the target encoding as is.
+func encodeContent(content, encodingName string) ([]byte, error) {
+	if encoding.IsUTF8(encodingName) {
+		return []byte(content), nil
+	}
+	enc, ok := encoding.Get(encodingName)
+	if !ok {
+		return nil, fmt.Errorf("unsupported encoding: %s", encodingName)
+	}
+	encoded, err := enc.NewEncoder().Bytes([]byte(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode content to %s: %w", encodingName, err)
+	}
+	slog.Debug("edit_file: encoded content for write", "encoding", encodingName, "utf8Size", len(content), "encodedSize", len(encoded))
+	return encoded, nil
+}
+
 func isReadOnly(mode os.FileMode) bool {
 	return mode&0200 == 0
 }
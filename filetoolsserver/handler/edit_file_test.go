@@ -365,3 +365,40 @@ func TestEditFile_CP1251Encoding(t *testing.T) {
 		t.Errorf("file content mismatch.\ngot bytes: %v\nwant bytes: %v", modifiedData, expectedCP1251)
 	}
 }
+
+func TestEditFile_UTF16PreservesCRLF(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+	testFile := filepath.Join(tempDir, "setup.inf")
+	os.WriteFile(testFile, encodeUTF16(t, "utf-16-le", "[Version]\r\nSignature=\"$Windows NT$\"\r\n"), 0644)
+
+	result, _, _ := h.HandleEditFile(context.Background(), nil, EditFileInput{
+		Path:  testFile,
+		Edits: []EditOperation{{OldText: "[Version]\nSignature", NewText: "[Version]\nClass=Net\nSignature"}},
+	})
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", extractTextFromResult(result.Content))
+	}
+	data, _ := os.ReadFile(testFile)
+	if want := encodeUTF16(t, "utf-16-le", "[Version]\r\nClass=Net\r\nSignature=\"$Windows NT$\"\r\n"); string(data) != string(want) {
+		t.Errorf("unexpected content % x", data)
+	}
+}
+
+func TestEditFile_PreservesCR(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+	testFile := filepath.Join(tempDir, "classic.txt")
+	os.WriteFile(testFile, []byte("first\rsecond\rthird\r"), 0644)
+
+	result, _, _ := h.HandleEditFile(context.Background(), nil, EditFileInput{
+		Path:  testFile,
+		Edits: []EditOperation{{OldText: "second\nthird", NewText: "second\ninserted\nthird"}},
+	})
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", extractTextFromResult(result.Content))
+	}
+	if data, _ := os.ReadFile(testFile); string(data) != "first\rsecond\rinserted\rthird\r" {
+		t.Errorf("unexpected content %q", data)
+	}
+}
@@ -25,6 +25,10 @@ var (
 	// ErrEncodingUnsupported is returned when an unsupported encoding is specified.
 	// Wrap this error to include the encoding name: fmt.Errorf("%w: %s", ErrEncodingUnsupported, name)
 	ErrEncodingUnsupported = errors.New("unsupported encoding")
+
+	// ErrInvalidSequences is returned in strict mode when content has bytes that are
+	// invalid in its encoding.
+	ErrInvalidSequences = errors.New("invalid byte sequences")
 )
 
 // Edit operation errors
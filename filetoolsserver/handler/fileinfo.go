@@ -3,12 +3,39 @@ package handler
 import (
 	"context"
 	"fmt"
+	"io/fs"
+	"mime"
+	"net/http"
 	"os"
+	"os/user"
+	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/dimitar-grigorov/mcp-file-tools/internal/encoding"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
+// Link types reported by get_file_info
+const (
+	linkTypeSymlink  = "symlink"
+	linkTypeHardlink = "hardlink"
+)
+
+// mimeSniffSize is the most data http.DetectContentType considers.
+const mimeSniffSize = 512
+
+// sysFileInfo is the platform-specific part of a file's metadata. Zero times
+// are unknown.
+type sysFileInfo struct {
+	created, accessed, changed time.Time
+	uid, gid                   int
+	hasOwner                   bool
+	inode, device              uint64
+	links                      int
+}
+
 // HandleGetFileInfo retrieves detailed metadata about a file or directory
 func (h *Handler) HandleGetFileInfo(ctx context.Context, req *mcp.CallToolRequest, input GetFileInfoInput) (*mcp.CallToolResult, GetFileInfoOutput, error) {
 	v := h.ValidatePath(input.Path)
@@ -21,22 +48,125 @@ func (h *Handler) HandleGetFileInfo(ctx context.Context, req *mcp.CallToolReques
 		return errorResult(fmt.Sprintf("failed to get file info: %v", err)), GetFileInfoOutput{}, nil
 	}
 
-	created, accessed, modified := getFileTimes(stat)
-	permissions := fmt.Sprintf("%03o", stat.Mode().Perm())
-
+	sys := getSysFileInfo(v.Path, stat)
 	output := GetFileInfoOutput{
 		Size:        stat.Size(),
-		Created:     formatTime(created),
-		Modified:    formatTime(modified),
-		Accessed:    formatTime(accessed),
+		Created:     formatTime(sys.created),
+		Modified:    formatTime(stat.ModTime()),
+		Accessed:    formatTime(sys.accessed),
+		Changed:     formatTime(sys.changed),
 		IsDirectory: stat.IsDir(),
 		IsFile:      stat.Mode().IsRegular(),
-		Permissions: permissions,
+		Permissions: fmt.Sprintf("%03o", stat.Mode().Perm()),
+		Inode:       sys.inode,
+		Device:      sys.device,
+	}
+	if sys.hasOwner {
+		output.UID, output.GID = &sys.uid, &sys.gid
+		if u, err := user.LookupId(strconv.Itoa(sys.uid)); err == nil {
+			output.Owner = u.Username
+		}
+		if g, err := user.LookupGroupId(strconv.Itoa(sys.gid)); err == nil {
+			output.Group = g.Name
+		}
+	}
+
+	// Validation followed a symbolic link to its target; Lstat the path itself
+	if link := h.ValidateLinkPath(input.Path); link.Ok() {
+		if info, err := os.Lstat(link.Path); err == nil && info.Mode()&fs.ModeSymlink != 0 {
+			output.LinkType = linkTypeSymlink
+			output.LinkTarget, _ = os.Readlink(link.Path)
+			output.ResolvedPath = v.Path
+		}
+	}
+	if !stat.Mode().IsRegular() {
+		return &mcp.CallToolResult{}, output, nil
+	}
+	output.HardLinks = sys.links
+	if output.LinkType == "" && output.HardLinks > 1 {
+		output.LinkType = linkTypeHardlink
+	}
+
+	head, err := readFileHead(v.Path, mimeSniffSize)
+	if err == nil && len(head) > 0 {
+		output.MimeType, _, _ = mime.ParseMediaType(http.DetectContentType(head))
+	}
+	if input.TextStats {
+		output.Text = h.fileTextStats(v.Path, stat.Size())
 	}
 
 	return &mcp.CallToolResult{}, output, nil
 }
 
+// fileTextStats detects the encoding, BOM, line endings and line count of a
+// file. It returns nil for binary files.
+func (h *Handler) fileTextStats(path string, size int64) *FileTextStats {
+	if size > h.config.MemoryThreshold {
+		head, err := readFileHead(path, encoding.ChunkSize)
+		if err != nil {
+			return nil
+		}
+		if _, ok := encoding.DetectBOM(head); !ok && isBinaryFile(head) {
+			return nil
+		}
+		detected, err := encoding.DetectFromFile(path, "sample")
+		if err != nil || detected.Confidence < encoding.MinConfidenceThreshold {
+			return &FileTextStats{}
+		}
+		return &FileTextStats{Encoding: detected.Charset, HasBOM: detected.HasBOM}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	detected, hasBOM := encoding.DetectBOM(data)
+	switch {
+	case hasBOM:
+	case isBinaryFile(data):
+		return nil
+	case utf8.Valid(data): // chardet misdetects short UTF-8 text
+		detected = encoding.DetectionResult{Charset: "utf-8", Confidence: 100}
+	default:
+		detected = encoding.Detect(data)
+		if detected.Confidence < encoding.MinConfidenceThreshold {
+			detected.Charset = ""
+		}
+	}
+	stats := &FileTextStats{Encoding: detected.Charset, HasBOM: hasBOM}
+
+	text := string(data)
+	enc, err := wideEncoding(detected.Charset)
+	if err != nil {
+		return stats // UTF-32: encoding only
+	}
+	if enc != nil {
+		if text, err = enc.NewDecoder().String(text); err != nil {
+			return stats
+		}
+	}
+	stats.LineEndings = DetectLineEndings([]byte(text)).Style
+	stats.LineCount = countTextLines(text)
+	return stats
+}
+
+// countTextLines counts lines the way editors do: a final line ending does not
+// start another line, and empty text has no lines.
+func countTextLines(text string) int {
+	if text == "" {
+		return 0
+	}
+	n := countLines(text)
+	if strings.HasSuffix(text, "\n") || strings.HasSuffix(text, "\r") {
+		n--
+	}
+	return n
+}
+
+// formatTime formats t as RFC 3339, or returns "" for an unknown (zero) time.
 func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
 	return t.Format(time.RFC3339)
 }
@@ -8,19 +8,18 @@ import (
 	"time"
 )
 
-// getFileTimes returns created, accessed, and modified times for a file on macOS
-func getFileTimes(stat os.FileInfo) (created, accessed, modified time.Time) {
-	modified = stat.ModTime()
+// getSysFileInfo returns platform metadata for a file on macOS
+func getSysFileInfo(path string, stat os.FileInfo) sysFileInfo {
+	info := sysFileInfo{accessed: stat.ModTime(), links: 1}
 
-	sys := stat.Sys().(*syscall.Stat_t)
-	if sys != nil {
-		accessed = time.Unix(sys.Atimespec.Sec, sys.Atimespec.Nsec)
-		// macOS has birthtime but it's not in the standard Stat_t, use ctime as fallback
-		created = time.Unix(sys.Ctimespec.Sec, sys.Ctimespec.Nsec)
-	} else {
-		created = modified
-		accessed = modified
+	if sys, ok := stat.Sys().(*syscall.Stat_t); ok {
+		info.created = time.Unix(sys.Birthtimespec.Sec, sys.Birthtimespec.Nsec)
+		info.accessed = time.Unix(sys.Atimespec.Sec, sys.Atimespec.Nsec)
+		info.changed = time.Unix(sys.Ctimespec.Sec, sys.Ctimespec.Nsec)
+		info.uid, info.gid, info.hasOwner = int(sys.Uid), int(sys.Gid), true
+		info.inode, info.device = sys.Ino, uint64(sys.Dev)
+		info.links = int(sys.Nlink)
 	}
 
-	return created, accessed, modified
+	return info
 }
@@ -6,22 +6,28 @@ import (
 	"os"
 	"syscall"
 	"time"
+
+	"golang.org/x/sys/unix"
 )
 
-// getFileTimes returns created, accessed, and modified times for a file on Linux
-// Note: Linux doesn't have a true creation time, so we use ctime (status change time) as a fallback
-func getFileTimes(stat os.FileInfo) (created, accessed, modified time.Time) {
-	modified = stat.ModTime()
+// getSysFileInfo returns platform metadata for a file on Linux. The birth time
+// comes from statx and is left zero where the kernel or file system lacks it;
+// ctime is the last status change, not the creation time.
+func getSysFileInfo(path string, stat os.FileInfo) sysFileInfo {
+	info := sysFileInfo{accessed: stat.ModTime(), links: 1}
+
+	if sys, ok := stat.Sys().(*syscall.Stat_t); ok {
+		info.accessed = time.Unix(sys.Atim.Sec, sys.Atim.Nsec)
+		info.changed = time.Unix(sys.Ctim.Sec, sys.Ctim.Nsec)
+		info.uid, info.gid, info.hasOwner = int(sys.Uid), int(sys.Gid), true
+		info.inode, info.device = sys.Ino, sys.Dev
+		info.links = int(sys.Nlink)
+	}
 
-	sys := stat.Sys().(*syscall.Stat_t)
-	if sys != nil {
-		accessed = time.Unix(sys.Atim.Sec, sys.Atim.Nsec)
-		// Linux doesn't have birthtime, use ctime (status change time) as fallback
-		created = time.Unix(sys.Ctim.Sec, sys.Ctim.Nsec)
-	} else {
-		created = modified
-		accessed = modified
+	var stx unix.Statx_t
+	if err := unix.Statx(unix.AT_FDCWD, path, unix.AT_STATX_SYNC_AS_STAT, unix.STATX_BTIME, &stx); err == nil && stx.Mask&unix.STATX_BTIME != 0 {
+		info.created = time.Unix(stx.Btime.Sec, int64(stx.Btime.Nsec))
 	}
 
-	return created, accessed, modified
+	return info
 }
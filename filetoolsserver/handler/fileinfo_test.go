@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
 )
@@ -43,7 +44,8 @@ func TestHandleGetFileInfo_File(t *testing.T) {
 		t.Errorf("expected IsDirectory to be false")
 	}
 
-	if output.Created == "" {
+	// Linux reports a birth time only where statx and the file system support it
+	if output.Created == "" && runtime.GOOS != "linux" {
 		t.Errorf("expected Created to be set")
 	}
 
@@ -159,3 +161,90 @@ func TestHandleGetFileInfo_NonExistent(t *testing.T) {
 		t.Errorf("expected 'failed to get file info' message, got %q", text)
 	}
 }
+
+func TestHandleGetFileInfo_Links(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+	target := filepath.Join(tempDir, "target.txt")
+	os.WriteFile(target, []byte("content"), 0644)
+
+	_, output, _ := h.HandleGetFileInfo(context.Background(), nil, GetFileInfoInput{Path: target})
+	if output.LinkType != "" || output.HardLinks != 1 {
+		t.Errorf("expected a plain file, got %+v", output)
+	}
+
+	if err := os.Link(target, filepath.Join(tempDir, "hard.txt")); err == nil {
+		_, output, _ = h.HandleGetFileInfo(context.Background(), nil, GetFileInfoInput{Path: target})
+		if output.LinkType != "hardlink" || output.HardLinks != 2 {
+			t.Errorf("expected a hard link, got %+v", output)
+		}
+	}
+
+	link := filepath.Join(tempDir, "link.txt")
+	if err := os.Symlink("target.txt", link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	result, output, _ := h.HandleGetFileInfo(context.Background(), nil, GetFileInfoInput{Path: link})
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", extractTextFromResult(result.Content))
+	}
+	resolved, _ := filepath.EvalSymlinks(target)
+	if output.LinkType != "symlink" || output.LinkTarget != "target.txt" || output.ResolvedPath != resolved || output.Size != 7 {
+		t.Errorf("unexpected symlink info %+v", output)
+	}
+}
+
+func TestHandleGetFileInfo_SystemInfo(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+	testFile := filepath.Join(tempDir, "page.html")
+	os.WriteFile(testFile, []byte("<!DOCTYPE html><html></html>"), 0644)
+
+	_, output, _ := h.HandleGetFileInfo(context.Background(), nil, GetFileInfoInput{Path: testFile})
+	if output.MimeType != "text/html" {
+		t.Errorf("expected text/html, got %q", output.MimeType)
+	}
+	if output.Inode == 0 {
+		t.Error("expected Inode to be set")
+	}
+	if output.Text != nil {
+		t.Error("text stats reported without being requested")
+	}
+	if runtime.GOOS == "windows" {
+		return
+	}
+	if output.UID == nil || *output.UID != os.Getuid() || output.GID == nil {
+		t.Errorf("unexpected ownership %+v", output)
+	}
+	if output.Changed == "" {
+		t.Error("expected Changed to be set")
+	}
+}
+
+func TestHandleGetFileInfo_TextStats(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+
+	tests := []struct {
+		name    string
+		content []byte
+		want    *FileTextStats
+	}{
+		{"utf-8 lf", []byte("Привет\nмир\n"), &FileTextStats{Encoding: "utf-8", LineEndings: "lf", LineCount: 2}},
+		{"no final newline", []byte("a\r\nb\r\nc"), &FileTextStats{Encoding: "utf-8", LineEndings: "crlf", LineCount: 3}},
+		{"utf-8 bom", []byte("\xef\xbb\xbfa\r\nb\n"), &FileTextStats{Encoding: "utf-8", HasBOM: true, LineEndings: "mixed", LineCount: 2}},
+		{"utf-16", encodeUTF16(t, "utf-16-le", "a\r\nb\r\n"), &FileTextStats{Encoding: "utf-16-le", HasBOM: true, LineEndings: "crlf", LineCount: 2}},
+		{"empty", []byte{}, &FileTextStats{Encoding: "utf-8", LineEndings: "none"}},
+		{"binary", []byte{'B', 'M', 0, 0, 1}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(tempDir, tt.name)
+			os.WriteFile(path, tt.content, 0644)
+			_, output, _ := h.HandleGetFileInfo(context.Background(), nil, GetFileInfoInput{Path: path, TextStats: true})
+			if (output.Text == nil) != (tt.want == nil) || output.Text != nil && *output.Text != *tt.want {
+				t.Errorf("expected %+v, got %+v", tt.want, output.Text)
+			}
+		})
+	}
+}
@@ -8,19 +8,34 @@ import (
 	"time"
 )
 
-// getFileTimes returns created, accessed, and modified times for a file on Windows
-func getFileTimes(stat os.FileInfo) (created, accessed, modified time.Time) {
-	modified = stat.ModTime()
+// getSysFileInfo returns platform metadata for a file on Windows. The file
+// index and volume serial number stand in for inode and device; link count and
+// index are not part of what os.Stat returns, so the file is opened for them.
+func getSysFileInfo(path string, stat os.FileInfo) sysFileInfo {
+	info := sysFileInfo{accessed: stat.ModTime(), links: 1}
 
-	sys := stat.Sys().(*syscall.Win32FileAttributeData)
-	if sys != nil {
-		created = time.Unix(0, sys.CreationTime.Nanoseconds())
-		accessed = time.Unix(0, sys.LastAccessTime.Nanoseconds())
-	} else {
-		// Fallback if syscall data is not available
-		created = modified
-		accessed = modified
+	if sys, ok := stat.Sys().(*syscall.Win32FileAttributeData); ok {
+		info.created = time.Unix(0, sys.CreationTime.Nanoseconds())
+		info.accessed = time.Unix(0, sys.LastAccessTime.Nanoseconds())
 	}
 
-	return created, accessed, modified
+	name, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return info
+	}
+	// FILE_FLAG_BACKUP_SEMANTICS is needed to open directories
+	handle, err := syscall.CreateFile(name, 0, syscall.FILE_SHARE_READ|syscall.FILE_SHARE_WRITE|syscall.FILE_SHARE_DELETE,
+		nil, syscall.OPEN_EXISTING, syscall.FILE_FLAG_BACKUP_SEMANTICS, 0)
+	if err != nil {
+		return info
+	}
+	defer syscall.CloseHandle(handle)
+	var byHandle syscall.ByHandleFileInformation
+	if err := syscall.GetFileInformationByHandle(handle, &byHandle); err == nil {
+		info.links = int(byHandle.NumberOfLinks)
+		info.inode = uint64(byHandle.FileIndexHigh)<<32 | uint64(byHandle.FileIndexLow)
+		info.device = uint64(byHandle.VolumeSerialNumber)
+	}
+
+	return info
 }
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/dimitar-grigorov/mcp-file-tools/internal/encoding"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// HandleFixMojibake detects double-encoded text (e.g. UTF-8 decoded as cp1251 and
+// re-saved) and repairs it, preserving the file's encoding and line endings.
+func (h *Handler) HandleFixMojibake(ctx context.Context, req *mcp.CallToolRequest, input FixMojibakeInput) (*mcp.CallToolResult, FixMojibakeOutput, error) {
+	v := h.ValidatePath(input.Path)
+	if !v.Ok() {
+		return v.Result, FixMojibakeOutput{}, nil
+	}
+
+	if loadToMemory, size := h.shouldLoadEntireFile(v.Path); !loadToMemory {
+		return errorResult(fmt.Sprintf("file too large for mojibake repair (%d bytes, threshold %d)", size, h.config.MemoryThreshold)), FixMojibakeOutput{}, nil
+	}
+
+	file, err := h.readTextForEdit(v.Path, input.Encoding)
+	if err != nil {
+		return errorResult(err.Error()), FixMojibakeOutput{}, nil
+	}
+
+	repair, found := encoding.RepairMojibake(file.content)
+	if !found {
+		return &mcp.CallToolResult{}, FixMojibakeOutput{
+			Message:  fmt.Sprintf("No mojibake detected in %s", input.Path),
+			Detected: false,
+		}, nil
+	}
+
+	patterns := make([]string, len(repair.Patterns))
+	for i, p := range repair.Patterns {
+		patterns[i] = p.String()
+	}
+
+	output := FixMojibakeOutput{
+		Detected:      true,
+		Patterns:      patterns,
+		SegmentsFixed: repair.Segments,
+		LinesChanged:  countChangedLines(file.content, repair.Text),
+		Diff:          createUnifiedDiff(file.content, repair.Text, input.Path),
+	}
+
+	dryRun := input.DryRun == nil || *input.DryRun // default: true
+	if dryRun {
+		output.Message = fmt.Sprintf("Found mojibake (%s) in %d lines. Review the diff and call again with dryRun=false to apply.",
+			strings.Join(patterns, ", then "), output.LinesChanged)
+		return &mcp.CallToolResult{}, output, nil
+	}
+
+	if err := file.write(v.Path, repair.Text); err != nil {
+		return errorResult(fmt.Sprintf("failed to write file: %v (the repaired text may not be representable in %s; pass encoding to read the file differently)", err, file.encoding)), FixMojibakeOutput{}, nil
+	}
+	output.Applied = true
+	output.Message = fmt.Sprintf("Repaired mojibake (%s) in %d lines of %s", strings.Join(patterns, ", then "), output.LinesChanged, input.Path)
+	return &mcp.CallToolResult{}, output, nil
+}
+
+// countChangedLines returns how many lines differ between two texts, aligning them
+// with a line diff so an inserted or removed line does not shift every later one.
+func countChangedLines(before, after string) int {
+	a := strings.Split(before, "\n")
+	b := strings.Split(after, "\n")
+	changed := 0
+	for _, op := range difflib.NewMatcherWithJunk(a, b, false, nil).GetOpCodes() {
+		if op.Tag != 'e' {
+			changed += max(op.I2-op.I1, op.J2-op.J1)
+		}
+	}
+	return changed
+}
@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+func TestHandleFixMojibake_DryRunAndApply(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+
+	// "Проверка" saved as UTF-8, then opened as cp1252 and re-saved as UTF-8
+	garbled, _ := charmap.Windows1252.NewDecoder().String("Проверка")
+	content := "[Main]\r\nTitle=" + garbled + "\r\nName=Test\r\n"
+	testFile := filepath.Join(tempDir, "config.ini")
+	os.WriteFile(testFile, []byte(content), 0644)
+
+	result, output, err := h.HandleFixMojibake(context.Background(), nil, FixMojibakeInput{
+		Path:     testFile,
+		Encoding: "utf-8",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", extractTextFromResult(result.Content))
+	}
+	if !output.Detected {
+		t.Fatal("expected mojibake to be detected")
+	}
+	if output.Applied {
+		t.Error("dry run should not apply changes")
+	}
+	if output.LinesChanged != 1 {
+		t.Errorf("expected 1 line changed, got %d", output.LinesChanged)
+	}
+	if len(output.Patterns) != 1 || output.Patterns[0] != "utf-8 misread as windows-1252" {
+		t.Errorf("unexpected patterns: %v", output.Patterns)
+	}
+	if !strings.Contains(output.Diff, "+Title=Проверка") {
+		t.Errorf("diff missing repaired line:\n%s", output.Diff)
+	}
+	if data, _ := os.ReadFile(testFile); string(data) != content {
+		t.Error("dry run modified the file")
+	}
+
+	dryRun := false
+	result, output, err = h.HandleFixMojibake(context.Background(), nil, FixMojibakeInput{
+		Path:     testFile,
+		Encoding: "utf-8",
+		DryRun:   &dryRun,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.IsError || !output.Applied {
+		t.Fatalf("expected repair to be applied: %s", extractTextFromResult(result.Content))
+	}
+	data, _ := os.ReadFile(testFile)
+	want := "[Main]\r\nTitle=Проверка\r\nName=Test\r\n"
+	if string(data) != want {
+		t.Errorf("file content = %q, want %q", data, want)
+	}
+}
+
+func TestHandleFixMojibake_PreservesEncoding(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+
+	// cp1251 file with a line of UTF-8 text that was misread as cp1251 ("РџСЂРѕРІРµСЂРєР°")
+	garbled, _ := charmap.Windows1251.NewDecoder().String("Проверка")
+	encoded, _ := charmap.Windows1251.NewEncoder().String("Заголовок\nCaption = '" + garbled + "'\n")
+	testFile := filepath.Join(tempDir, "form.dfm")
+	os.WriteFile(testFile, []byte(encoded), 0644)
+
+	dryRun := false
+	result, output, err := h.HandleFixMojibake(context.Background(), nil, FixMojibakeInput{
+		Path:     testFile,
+		Encoding: "cp1251",
+		DryRun:   &dryRun,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", extractTextFromResult(result.Content))
+	}
+	if !output.Applied {
+		t.Fatal("expected repair to be applied")
+	}
+	if output.Patterns[0] != "utf-8 misread as windows-1251" {
+		t.Errorf("unexpected pattern: %v", output.Patterns)
+	}
+	want, _ := charmap.Windows1251.NewEncoder().String("Заголовок\nCaption = 'Проверка'\n")
+	if data, _ := os.ReadFile(testFile); string(data) != want {
+		t.Errorf("file not written back in cp1251: %q", data)
+	}
+}
+
+func TestHandleFixMojibake_NoMojibake(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+
+	testFile := filepath.Join(tempDir, "clean.txt")
+	os.WriteFile(testFile, []byte("Привет, мир\nHello\n"), 0644)
+
+	result, output, err := h.HandleFixMojibake(context.Background(), nil, FixMojibakeInput{Path: testFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", extractTextFromResult(result.Content))
+	}
+	if output.Detected {
+		t.Errorf("expected no mojibake, got patterns %v", output.Patterns)
+	}
+}
+
+func TestHandleFixMojibake_PathOutsideAllowed(t *testing.T) {
+	h := NewHandler([]string{t.TempDir()})
+
+	result, _, err := h.HandleFixMojibake(context.Background(), nil, FixMojibakeInput{Path: "/etc/passwd"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.IsError {
+		t.Error("expected error for path outside allowed directories")
+	}
+}
+
+func TestCountChangedLines(t *testing.T) {
+	tests := []struct {
+		before, after string
+		want          int
+	}{
+		{"a\nb\nc", "a\nb\nc", 0},
+		{"a\nb\nc", "a\nB\nc", 1},
+		{"a\nb\nc", "a\nX\nb\nc", 1},
+		{"a\nX\nY\nc", "a\nZ\nc", 2},
+	}
+	for _, tt := range tests {
+		if got := countChangedLines(tt.before, tt.after); got != tt.want {
+			t.Errorf("countChangedLines(%q, %q) = %d, want %d", tt.before, tt.after, got, tt.want)
+		}
+	}
+}
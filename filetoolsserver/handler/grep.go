@@ -1,67 +1,275 @@
 package handler
 
 import (
+	"bufio"
 	"context"
 	"fmt"
+	"io"
 	"io/fs"
 	"log/slog"
 	"os"
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/dimitar-grigorov/mcp-file-tools/internal/encoding"
+	"github.com/dimitar-grigorov/mcp-file-tools/internal/glob"
+	"github.com/dimitar-grigorov/mcp-file-tools/internal/index"
 	"github.com/dimitar-grigorov/mcp-file-tools/internal/security"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 const (
 	defaultMaxMatches = 1000
-	binaryCheckSize   = 8192 // 8KB to catch files with text header but binary payload
+	binaryCheckSize   = 8192      // 8KB to catch files with text header but binary payload
+	grepBufferSize    = 64 * 1024 // read buffer per file
+	minGrepLineLength = 64 * 1024 // lines are never truncated below this length
 )
 
 // HandleGrep searches for a pattern in files with encoding support.
 func (h *Handler) HandleGrep(ctx context.Context, req *mcp.CallToolRequest, input GrepInput) (*mcp.CallToolResult, GrepOutput, error) {
-	if input.Pattern == "" {
+	if input.Pattern == "" && len(input.Patterns) == 0 {
 		return errorResult("pattern is required"), GrepOutput{}, nil
 	}
+	switch input.Format {
+	case "", grepFormatFlat, grepFormatGrouped, grepFormatText:
+	default:
+		return errorResult(fmt.Sprintf("invalid format: %s (valid: %s, %s, %s)", input.Format, grepFormatFlat, grepFormatGrouped, grepFormatText)), GrepOutput{}, nil
+	}
 	if len(input.Paths) == 0 {
 		return errorResult("paths is required"), GrepOutput{}, nil
 	}
-	re, err := compilePattern(input.Pattern, input.CaseSensitive)
+	query, err := newGrepQuery(input)
 	if err != nil {
-		return errorResult(fmt.Sprintf("invalid regex pattern: %v", err)), GrepOutput{}, nil
+		return errorResult(err.Error()), GrepOutput{}, nil
 	}
 	maxMatches := input.MaxMatches
 	if maxMatches <= 0 {
 		maxMatches = defaultMaxMatches
 	}
-	files := h.collectFiles(ctx, input.Paths, input.Include, input.Exclude)
+	filter, err := newFileFilter(input.Include, input.Exclude)
+	if err != nil {
+		return errorResult(err.Error()), GrepOutput{}, nil
+	}
+	filter.respectIgnoreFiles = input.RespectIgnoreFiles == nil || *input.RespectIgnoreFiles // default: true
+	files := h.collectFiles(ctx, input.Paths, filter)
 	if len(files) == 0 {
 		return &mcp.CallToolResult{}, GrepOutput{Matches: []GrepMatch{}, FilesSearched: 0}, nil
 	}
-	matches, filesMatched, truncated := h.searchFiles(ctx, files, re, input, maxMatches, h.config.MemoryThreshold)
-	return &mcp.CallToolResult{}, GrepOutput{
-		Matches:       matches,
-		TotalMatches:  len(matches),
-		FilesSearched: len(files),
-		FilesMatched:  filesMatched,
-		Truncated:     truncated,
-	}, nil
+	output := h.searchFiles(ctx, h.indexCandidates(files, query, input.Encoding), query, input, maxMatches, h.config.MemoryThreshold)
+	output.FilesSearched = len(files)
+	if input.Format == grepFormatText {
+		// Only the summary goes into structured output; the text carries the results
+		return &mcp.CallToolResult{
+			Content: []mcp.Content{&mcp.TextContent{Text: renderGrepText(output, query)}},
+		}, GrepOutput{
+			TotalMatches:  output.TotalMatches,
+			FilesSearched: output.FilesSearched,
+			FilesMatched:  output.FilesMatched,
+			Truncated:     output.Truncated,
+		}, nil
+	}
+	return &mcp.CallToolResult{}, output, nil
+}
+
+// Grep result formats
+const (
+	grepFormatFlat    = "flat"    // one list of matches (default)
+	grepFormatGrouped = "grouped" // matches grouped by file with per-file counts
+	grepFormatText    = "text"    // compact rg-style text
+)
+
+// Grep output modes
+const (
+	grepOutputMatches = "matches"          // matching lines with context (default)
+	grepOutputFiles   = "filesWithMatches" // only paths of files with a match
+	grepOutputCount   = "count"            // match counts per file
+)
+
+// grepQuery holds the compiled patterns of a grep request.
+type grepQuery struct {
+	patterns  []*regexp.Regexp
+	sources   []string // pattern text as given, reported on matches when there are several
+	all       bool     // every pattern must match somewhere in a file
+	multiline bool
+	word      bool   // matches must not touch letters, digits or underscores
+	invert    bool   // select lines that match no pattern
+	output    string // one of the grepOutput* modes
+}
+
+// newGrepQuery compiles pattern and patterns with the request options.
+func newGrepQuery(input GrepInput) (*grepQuery, error) {
+	q := &grepQuery{
+		multiline: input.Multiline,
+		word:      input.WordBoundary,
+		invert:    input.InvertMatch,
+		output:    input.OutputMode,
+	}
+	switch input.PatternMode {
+	case "", "any":
+	case "all":
+		q.all = true
+	default:
+		return nil, fmt.Errorf("invalid patternMode: %s (valid: any, all)", input.PatternMode)
+	}
+	switch q.output {
+	case "":
+		q.output = grepOutputMatches
+	case grepOutputMatches, grepOutputFiles, grepOutputCount:
+	default:
+		return nil, fmt.Errorf("invalid outputMode: %s (valid: %s, %s, %s)", q.output, grepOutputMatches, grepOutputFiles, grepOutputCount)
+	}
+	if q.invert && (q.all || q.multiline) {
+		return nil, fmt.Errorf("invertMatch cannot be combined with patternMode all or multiline")
+	}
+	sources := input.Patterns
+	if input.Pattern != "" {
+		sources = append([]string{input.Pattern}, sources...)
+	}
+	for _, src := range sources {
+		if src == "" {
+			return nil, fmt.Errorf("patterns must not be empty")
+		}
+		re, err := compilePattern(src, input.FixedString, input.CaseSensitive, input.Multiline)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex pattern %q: %v", src, err)
+		}
+		q.patterns = append(q.patterns, re)
+		q.sources = append(q.sources, src)
+	}
+	return q, nil
 }
 
 // compilePattern compiles the regex pattern with optional case sensitivity.
-func compilePattern(pattern string, caseSensitive *bool) (*regexp.Regexp, error) {
+// A fixed string is matched literally. In multiline mode ^ and $ match at line boundaries.
+func compilePattern(pattern string, fixedString bool, caseSensitive *bool, multiline bool) (*regexp.Regexp, error) {
+	if fixedString {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if multiline {
+		pattern = "(?m)" + pattern
+	}
 	if caseSensitive != nil && !*caseSensitive {
 		pattern = "(?i)" + pattern
 	}
 	return regexp.Compile(pattern)
 }
 
-// collectFiles gathers all files to search from the given paths.
-func (h *Handler) collectFiles(ctx context.Context, paths []string, include, exclude string) []string {
+// matchLine returns the earliest match of any pattern in line and that pattern's
+// index, or nil. Every pattern found in the line is marked in seen.
+func (q *grepQuery) matchLine(line string, seen []bool) ([]int, int) {
+	var best []int
+	bestIdx := -1
+	for i, re := range q.patterns {
+		loc := q.find(re, line)
+		if loc == nil {
+			continue
+		}
+		seen[i] = true
+		if best == nil || loc[0] < best[0] {
+			best, bestIdx = loc, i
+		}
+	}
+	return best, bestIdx
+}
+
+// find returns the first match of re in s, honoring the word boundary option.
+func (q *grepQuery) find(re *regexp.Regexp, s string) []int {
+	if !q.word {
+		return re.FindStringIndex(s)
+	}
+	for _, loc := range re.FindAllStringIndex(s, -1) {
+		if atWordBoundary(s, loc[0], loc[1]) {
+			return loc
+		}
+	}
+	return nil
+}
+
+// findAll returns up to n matches of re in s, honoring the word boundary option.
+func (q *grepQuery) findAll(re *regexp.Regexp, s string, n int) [][]int {
+	if !q.word {
+		return re.FindAllStringIndex(s, n)
+	}
+	var locs [][]int
+	for _, loc := range re.FindAllStringIndex(s, -1) {
+		if len(locs) == n {
+			break
+		}
+		if atWordBoundary(s, loc[0], loc[1]) {
+			locs = append(locs, loc)
+		}
+	}
+	return locs
+}
+
+// atWordBoundary reports whether s[start:end] is not directly preceded or followed
+// by a word character. Unlike regexp's \b this also works for non-ASCII words.
+func atWordBoundary(s string, start, end int) bool {
+	if r, size := utf8.DecodeLastRuneInString(s[:start]); size > 0 && isWordRune(r) {
+		return false
+	}
+	if r, size := utf8.DecodeRuneInString(s[end:]); size > 0 && isWordRune(r) {
+		return false
+	}
+	return true
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// patternLabel returns the pattern to report on a match, or "" for a single pattern.
+func (q *grepQuery) patternLabel(idx int) string {
+	if len(q.patterns) < 2 {
+		return ""
+	}
+	return q.sources[idx]
+}
+
+// allPatternsSeen reports whether every pattern matched somewhere.
+func allPatternsSeen(seen []bool) bool {
+	for _, ok := range seen {
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// indexCandidates drops files that the content index proves cannot match.
+// Inverted matches select lines without the patterns, so nothing can be dropped.
+func (h *Handler) indexCandidates(files []string, query *grepQuery, forcedEncoding string) []string {
+	if h.index == nil || query.invert {
+		return files
+	}
+	exprs := make([]string, len(query.patterns))
+	for i, re := range query.patterns {
+		exprs[i] = re.String()
+	}
+	q := index.NewQuery(exprs, query.all)
+	if q == nil {
+		return files
+	}
+	var candidates []string
+	for _, path := range files {
+		if h.index.Candidate(path, forcedEncoding, q) {
+			candidates = append(candidates, path)
+		}
+	}
+	return candidates
+}
+
+// collectFiles gathers all files to search from the given paths. Filters match
+// paths relative to the directory being walked (the base name for file paths).
+// Ignore files only apply to walked directories, not to files passed directly.
+func (h *Handler) collectFiles(ctx context.Context, paths []string, filter fileFilter) []string {
 	var files []string
 	seen := make(map[string]bool)
 	allowedDirs := h.ResolvedAllowedDirs()
@@ -81,7 +289,9 @@ func (h *Handler) collectFiles(ctx context.Context, paths []string, include, exc
 			continue
 		}
 		if info.IsDir() {
-			filepath.WalkDir(v.Path, func(p string, d fs.DirEntry, err error) error {
+			root := v.Path
+			ignores := h.ignoreMatcher(root, filter.respectIgnoreFiles)
+			filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
 				// Check for cancellation during walk
 				select {
 				case <-ctx.Done():
@@ -92,54 +302,96 @@ func (h *Handler) collectFiles(ctx context.Context, paths []string, include, exc
 					slog.Debug("skipping path due to error", "path", p, "error", err)
 					return nil
 				}
+				rel, relErr := filepath.Rel(root, p)
+				if relErr != nil {
+					return nil
+				}
+				rel = filepath.ToSlash(rel)
 				if d.IsDir() {
+					if p != root && (filter.excludeDir(rel) || ignores.Ignored(p, true)) {
+						return filepath.SkipDir
+					}
 					if !security.IsPathSafeResolved(p, allowedDirs) {
 						return filepath.SkipDir
 					}
 					return nil
 				}
-				if shouldIncludeFile(p, include, exclude) && !seen[p] {
+				if filter.includeFile(rel) && !ignores.Ignored(p, false) && !seen[p] {
 					seen[p] = true
 					files = append(files, p)
 				}
 				return nil
 			})
-		} else if shouldIncludeFile(v.Path, include, exclude) && !seen[v.Path] {
+		} else if filter.includeFile(filepath.Base(v.Path)) && !seen[v.Path] {
 			seen[v.Path] = true
 			files = append(files, v.Path)
 		}
 	}
+	// Sorted so results come out by path regardless of argument order
+	sort.Strings(files)
 	return files
 }
 
-// shouldIncludeFile checks if a file matches include/exclude patterns.
-// Matches against both full path (with forward slashes) and basename.
-func shouldIncludeFile(path string, include, exclude string) bool {
-	base := filepath.Base(path)
-	normalized := filepath.ToSlash(path)
-	if exclude != "" {
-		if matchedBase, _ := filepath.Match(exclude, base); matchedBase {
-			return false
-		}
-		if matchedPath, _ := filepath.Match(exclude, normalized); matchedPath {
-			return false
-		}
+// fileFilter selects files by include and exclude glob patterns and ignore files.
+type fileFilter struct {
+	include            *glob.Set
+	exclude            *glob.Set
+	respectIgnoreFiles bool
+}
+
+// newFileFilter compiles include and exclude patterns.
+func newFileFilter(include, exclude GlobList) (fileFilter, error) {
+	var f fileFilter
+	var err error
+	if f.include, err = glob.CompileSet(include); err != nil {
+		return f, fmt.Errorf("invalid include: %w", err)
 	}
-	if include != "" {
-		if matchedBase, _ := filepath.Match(include, base); matchedBase {
-			return true
-		}
-		if matchedPath, _ := filepath.Match(include, normalized); matchedPath {
-			return true
-		}
+	if f.exclude, err = glob.CompileSet(exclude); err != nil {
+		return f, fmt.Errorf("invalid exclude: %w", err)
+	}
+	return f, nil
+}
+
+// includeFile reports whether a slash-separated relative path passes the filter.
+func (f fileFilter) includeFile(rel string) bool {
+	if f.exclude.Match(rel) {
 		return false
 	}
-	return true
+	return f.include.Empty() || f.include.Match(rel)
+}
+
+// excludeDir reports whether a directory is excluded, so the walk can skip it.
+func (f fileFilter) excludeDir(rel string) bool {
+	return f.exclude.MatchDir(rel)
+}
+
+// fileSearchResult holds the matches of one file and any encoding problem found in it.
+type fileSearchResult struct {
+	path      string
+	count     int // matches counted in filesWithMatches and count modes
+	matches   []GrepMatch
+	truncated bool // the file has more matches than its cap
+	issue     *GrepEncodingIssue
+	tooLarge  bool // skipped in multiline mode
+}
+
+// matchBudget is how many more matches the ordered merge of searchFiles can
+// accept. Merging earlier files only lowers it, so a file can never contribute
+// more and its scan stops there, before the search is cancelled.
+type matchBudget struct {
+	remaining atomic.Int64
+}
+
+// cap returns the number of matches a file may still collect.
+func (b *matchBudget) cap(fileCap int) int {
+	return min(fileCap, int(b.remaining.Load()))
 }
 
-// searchFiles searches all files concurrently using a worker pool.
-// Uses a cancellable context to stop workers early when maxMatches is reached.
-func (h *Handler) searchFiles(ctx context.Context, files []string, re *regexp.Regexp, input GrepInput, maxMatches int, maxFileSize int64) ([]GrepMatch, int, bool) {
+// searchFiles searches all files concurrently using a worker pool. Results are
+// merged in file order, so output and truncation are deterministic: the first
+// maxMatches matches by path and line are returned. Each file stops reading at
+// its cap, and the search is cancelled once the ordered prefix fills the limit.
+func (h *Handler) searchFiles(ctx context.Context, files []string, query *grepQuery, input GrepInput, maxMatches int, memoryLimit int64) GrepOutput {
 	numWorkers := runtime.NumCPU()
 	if numWorkers > len(files) {
 		numWorkers = len(files)
@@ -147,40 +399,50 @@ func (h *Handler) searchFiles(ctx context.Context, files []string, re *regexp.Re
 	searchCtx, cancelSearch := context.WithCancel(ctx)
 	defer cancelSearch()
 
-	jobs := make(chan string, numWorkers)
-	results := make(chan []GrepMatch, numWorkers)
-	var filesMatched int
-	var mu sync.Mutex
-	// Start workers
+	fileCap := maxMatches
+	if input.MaxMatchesPerFile > 0 && input.MaxMatchesPerFile < fileCap {
+		fileCap = input.MaxMatchesPerFile
+	}
+	budget := &matchBudget{}
+	budget.remaining.Store(int64(maxMatches))
+
+	type job struct {
+		idx  int
+		path string
+	}
+	type indexedResult struct {
+		idx int
+		fileSearchResult
+	}
+	jobs := make(chan job, numWorkers)
+	results := make(chan indexedResult, numWorkers)
+	// Start workers. Every job yields a result so the ordered merge has no gaps.
 	var wg sync.WaitGroup
 	for i := 0; i < numWorkers; i++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			for path := range jobs {
-				select {
-				case <-searchCtx.Done():
-					results <- nil
+			for j := range jobs {
+				result := fileSearchResult{path: j.path}
+				switch {
+				case searchCtx.Err() != nil:
+				case query.multiline:
+					result = searchFileMultiline(searchCtx, j.path, query, input, budget.cap(fileCap), memoryLimit)
 				default:
-					matches := searchSingleFile(path, re, input, maxFileSize)
-					if len(matches) > 0 {
-						mu.Lock()
-						filesMatched++
-						mu.Unlock()
-					}
-					results <- matches
+					result = searchSingleFile(searchCtx, j.path, query, input, fileCap, budget, memoryLimit)
 				}
+				results <- indexedResult{j.idx, result}
 			}
 		}()
 	}
 	// Send jobs, stop early if search is cancelled
 	go func() {
 		defer close(jobs)
-		for _, file := range files {
+		for i, file := range files {
 			select {
 			case <-searchCtx.Done():
 				return
-			case jobs <- file:
+			case jobs <- job{i, file}:
 			}
 		}
 	}()
@@ -189,123 +451,306 @@ func (h *Handler) searchFiles(ctx context.Context, files []string, re *regexp.Re
 		wg.Wait()
 		close(results)
 	}()
-	// Collect results, cancel workers when limit reached
-	var allMatches []GrepMatch
-	truncated := false
-	for fileMatches := range results {
-		for _, m := range fileMatches {
-			if len(allMatches) >= maxMatches {
-				truncated = true
-				cancelSearch()
+
+	// Merge results in file order
+	var output GrepOutput
+	waiting := make(map[int]fileSearchResult)
+	next := 0
+	remaining := maxMatches
+	stopped := false
+	for r := range results {
+		waiting[r.idx] = r.fileSearchResult
+		for {
+			result, ok := waiting[next]
+			if !ok {
 				break
 			}
-			allMatches = append(allMatches, m)
+			delete(waiting, next)
+			next++
+			if stopped {
+				continue
+			}
+			if len(result.matches) > remaining {
+				// Past the global limit: keep the ordered prefix and stop the search
+				result.matches = result.matches[:remaining]
+				result.truncated = true
+				stopped = true
+				cancelSearch()
+			}
+			remaining -= len(result.matches)
+			budget.remaining.Store(int64(remaining))
+			addFileResult(&output, result, query, input.Format)
 		}
 	}
-	return allMatches, filesMatched, truncated
+	return output
 }
 
-// searchSingleFile searches for matches in a single file.
-func searchSingleFile(path string, re *regexp.Regexp, input GrepInput, maxFileSize int64) []GrepMatch {
-	// Check file size - warn if large file will be loaded to memory
-	if info, err := os.Stat(path); err == nil && info.Size() > maxFileSize {
-		slog.Warn("loading large file into memory", "path", path, "size", info.Size(), "threshold", maxFileSize)
+// addFileResult appends one file's results to the output in the requested shape.
+func addFileResult(output *GrepOutput, result fileSearchResult, query *grepQuery, format string) {
+	if result.issue != nil {
+		output.EncodingIssues = append(output.EncodingIssues, *result.issue)
 	}
-	data, err := os.ReadFile(path)
-	if err != nil || isBinaryFile(data) {
-		return nil
+	if result.tooLarge {
+		output.SkippedFiles = append(output.SkippedFiles, result.path)
 	}
-	content, detectedEncoding := decodeFileContent(data, input.Encoding)
-	if content == "" {
-		return nil
+	if result.truncated {
+		output.Truncated = true
 	}
-	lines := strings.Split(content, "\n")
-	var matches []GrepMatch
-	for lineNum, line := range lines {
-		loc := re.FindStringIndex(line)
-		if loc == nil {
-			continue
+	switch {
+	case result.count > 0 && query.output == grepOutputCount:
+		output.FilesMatched++
+		output.TotalMatches += result.count
+		output.Counts = append(output.Counts, GrepFileCount{Path: result.path, Count: result.count})
+	case result.count > 0:
+		output.FilesMatched++
+		output.Files = append(output.Files, result.path)
+	case len(result.matches) > 0 && format == grepFormatGrouped:
+		output.FilesMatched++
+		output.TotalMatches += len(result.matches)
+		group := GrepFileGroup{
+			Path:       result.path,
+			Encoding:   result.matches[0].Encoding,
+			MatchCount: len(result.matches),
+			Truncated:  result.truncated,
+			Matches:    result.matches,
+		}
+		// Path and encoding are reported once per group
+		for i := range group.Matches {
+			group.Matches[i].Path = ""
+			group.Matches[i].Encoding = ""
+		}
+		output.Groups = append(output.Groups, group)
+	case len(result.matches) > 0:
+		output.FilesMatched++
+		output.TotalMatches += len(result.matches)
+		output.Matches = append(output.Matches, result.matches...)
+	}
+}
+
+// searchSingleFile streams a file line by line through a decoding reader. Only the
+// context window is held in memory. Reading stops once the file has more than
+// fileCap matches, or more than the budget left for it (or the search is
+// cancelled), and pending after-context lines are filled. With "all" semantics
+// reading continues until every pattern was seen.
+func searchSingleFile(ctx context.Context, path string, query *grepQuery, input GrepInput, fileCap int, budget *matchBudget, memoryLimit int64) fileSearchResult {
+	result := fileSearchResult{path: path}
+	f, err := os.Open(path)
+	if err != nil {
+		return result
+	}
+	defer f.Close()
+
+	raw := bufio.NewReaderSize(f, grepBufferSize)
+	if head, _ := raw.Peek(binaryCheckSize); isBinaryFile(head) {
+		return result
+	}
+	encodingName := resolveGrepEncoding(path, input.Encoding)
+	var decoded io.Reader = raw
+	if !encoding.IsUTF8(encodingName) {
+		if enc, ok := encoding.Get(encodingName); ok && enc != nil {
+			decoded = enc.NewDecoder().Reader(raw)
+		} else {
+			encodingName = "utf-8"
+		}
+	}
+	reader := bufio.NewReaderSize(decoded, grepBufferSize)
+	maxLine := maxGrepLineLength(memoryLimit, input.ContextBefore, input.ContextAfter)
+
+	before := newLineRing(input.ContextBefore)
+	var pending []int // indices of matches still collecting after-context
+	var invalid encoding.InvalidSequences
+	seen := make([]bool, len(query.patterns))
+	for lineNum := 1; ; lineNum++ {
+		if ctx.Err() != nil {
+			if query.all {
+				// The file can't be completed
+				result.matches, result.count = nil, 0
+			}
+			break
 		}
-		match := GrepMatch{
-			Path:     path,
-			Line:     lineNum + 1,
-			Column:   loc[0] + 1,
-			Text:     line,
-			Encoding: detectedEncoding,
+		if result.truncated && len(pending) == 0 && (!query.all || allPatternsSeen(seen)) {
+			break
 		}
-		if input.ContextBefore > 0 {
-			match.Before = getContextBefore(lines, lineNum, input.ContextBefore)
+		line, err := readLine(reader, maxLine)
+		if err != nil {
+			break
+		}
+
+		// Fill after-context of earlier matches
+		kept := pending[:0]
+		for _, idx := range pending {
+			m := &result.matches[idx]
+			m.After = append(m.After, line)
+			if len(m.After) < input.ContextAfter {
+				kept = append(kept, idx)
+			}
+		}
+		pending = kept
+
+		invalid.AddLine(line, lineNum, encodingName)
+		if input.Strict && invalid.Count > 0 {
+			result.matches, result.count = nil, 0
+			break
+		}
+
+		loc, idx := query.matchLine(line, seen)
+		selected := (loc != nil) != query.invert
+		switch {
+		case !selected:
+		case query.output != grepOutputMatches:
+			result.count++
+		case len(result.matches) >= budget.cap(fileCap):
+			result.truncated = true
+		default:
+			match := GrepMatch{
+				Path:     path,
+				Line:     lineNum,
+				Text:     line,
+				Before:   before.lines(),
+				Encoding: encodingName,
+			}
+			// Inverted matches have no match position
+			if loc != nil {
+				match.Column = loc[0] + 1
+				match.Pattern = query.patternLabel(idx)
+			}
+			result.matches = append(result.matches, match)
+			if input.ContextAfter > 0 {
+				pending = append(pending, len(result.matches)-1)
+			}
 		}
-		if input.ContextAfter > 0 {
-			match.After = getContextAfter(lines, lineNum, input.ContextAfter)
+		// One match is enough to list the file
+		if query.output == grepOutputFiles && result.count > 0 && (!query.all || allPatternsSeen(seen)) {
+			break
 		}
-		matches = append(matches, match)
+		before.push(line)
 	}
-	return matches
-}
 
-// isBinaryFile checks if the data appears to be binary (contains null bytes).
-func isBinaryFile(data []byte) bool {
-	checkSize := binaryCheckSize
-	if len(data) < checkSize {
-		checkSize = len(data)
+	if query.all && !allPatternsSeen(seen) {
+		result.matches, result.count, result.truncated = nil, 0, false
 	}
-	for i := 0; i < checkSize; i++ {
-		if data[i] == 0 {
-			return true
+	if invalid.Count > 0 {
+		result.issue = &GrepEncodingIssue{
+			Path:             path,
+			Encoding:         encodingName,
+			InvalidSequences: toInvalidSequencesInfo(invalid),
+			Skipped:          input.Strict,
 		}
 	}
-	return false
+	return result
 }
 
-// decodeFileContent decodes file data to UTF-8 string.
-func decodeFileContent(data []byte, forcedEncoding string) (string, string) {
-	var encodingName string
+// resolveGrepEncoding returns the forced encoding or detects it from a sample of the file.
+func resolveGrepEncoding(path, forcedEncoding string) string {
 	if forcedEncoding != "" {
-		encodingName = strings.ToLower(forcedEncoding)
-	} else {
-		detection, _ := encoding.DetectSample(data)
-		if detection.Charset != "" {
-			encodingName = detection.Charset
+		return strings.ToLower(forcedEncoding)
+	}
+	detection, err := encoding.DetectFromFile(path, "sample")
+	if err != nil || detection.Charset == "" {
+		return "utf-8"
+	}
+	return detection.Charset
+}
+
+// maxGrepLineLength splits the memory limit across the lines held at once
+// (context window plus the current line), with a floor for small limits.
+func maxGrepLineLength(memoryLimit int64, contextBefore, contextAfter int) int {
+	perLine := memoryLimit / int64(contextBefore+contextAfter+1)
+	return int(max(perLine, minGrepLineLength))
+}
+
+// readLine reads one line without its "\n" terminator. Bytes past maxLen are
+// discarded so a single huge line cannot exhaust memory. Returns an error only
+// when no data is left.
+func readLine(r *bufio.Reader, maxLen int) (string, error) {
+	var buf []byte
+	truncated := false
+	readAny := false
+	for {
+		chunk, err := r.ReadSlice('\n')
+		readAny = readAny || len(chunk) > 0
+		if room := maxLen - len(buf); room < len(chunk) {
+			buf = append(buf, chunk[:max(room, 0)]...)
+			truncated = true
 		} else {
-			encodingName = "utf-8"
+			buf = append(buf, chunk...)
 		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil && !readAny {
+			return "", err
+		}
+		break
 	}
-	if encoding.IsUTF8(encodingName) {
-		return string(data), encodingName
+	if n := len(buf); n > 0 && buf[n-1] == '\n' {
+		buf = buf[:n-1]
 	}
-	enc, ok := encoding.Get(encodingName)
-	if !ok {
-		return string(data), "utf-8"
+	if truncated {
+		buf = trimPartialRune(buf)
 	}
-	decoder := enc.NewDecoder()
-	decoded, err := decoder.Bytes(data)
-	if err != nil {
-		return string(data), "utf-8"
+	return string(buf), nil
+}
+
+// trimPartialRune drops an incomplete UTF-8 sequence left at the end by truncation.
+func trimPartialRune(b []byte) []byte {
+	for i := len(b) - 1; i >= 0 && i >= len(b)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(b[i]) {
+			if !utf8.FullRune(b[i:]) {
+				return b[:i]
+			}
+			break
+		}
 	}
-	return string(decoded), encodingName
+	return b
 }
 
-// getContextBefore returns N lines before the given line index.
-func getContextBefore(lines []string, lineIdx, count int) []string {
-	start := lineIdx - count
-	if start < 0 {
-		start = 0
+// lineRing keeps the last n lines for before-context.
+type lineRing struct {
+	buf   []string
+	start int
+	size  int
+}
+
+func newLineRing(n int) *lineRing {
+	return &lineRing{buf: make([]string, n)}
+}
+
+func (r *lineRing) push(line string) {
+	if len(r.buf) == 0 {
+		return
+	}
+	if r.size < len(r.buf) {
+		r.buf[(r.start+r.size)%len(r.buf)] = line
+		r.size++
+		return
 	}
-	if start >= lineIdx {
+	r.buf[r.start] = line
+	r.start = (r.start + 1) % len(r.buf)
+}
+
+// lines returns the buffered lines oldest first, or nil if empty.
+func (r *lineRing) lines() []string {
+	if r.size == 0 {
 		return nil
 	}
-	return lines[start:lineIdx]
+	out := make([]string, r.size)
+	for i := range out {
+		out[i] = r.buf[(r.start+i)%len(r.buf)]
+	}
+	return out
 }
 
-// getContextAfter returns N lines after the given line index.
-func getContextAfter(lines []string, lineIdx, count int) []string {
-	end := lineIdx + count + 1
-	if end > len(lines) {
-		end = len(lines)
+// isBinaryFile checks if the data appears to be binary (contains null bytes).
+func isBinaryFile(data []byte) bool {
+	checkSize := binaryCheckSize
+	if len(data) < checkSize {
+		checkSize = len(data)
 	}
-	if lineIdx+1 >= end {
-		return nil
+	for i := 0; i < checkSize; i++ {
+		if data[i] == 0 {
+			return true
+		}
 	}
-	return lines[lineIdx+1 : end]
+	return false
 }
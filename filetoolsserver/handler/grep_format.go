@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// renderGrepText renders grep results in a compact rg-like layout:
+//
+//	path/to/file.pas
+//	11-context line
+//	12:5:matching line
+//	--
+//	40:1:another match
+//
+// Match lines are "line:column:text", context lines "line-text", and "--"
+// separates non-adjacent chunks. Files are separated by a blank line.
+func renderGrepText(output GrepOutput, query *grepQuery) string {
+	var sb strings.Builder
+	switch query.output {
+	case grepOutputFiles:
+		for _, path := range output.Files {
+			sb.WriteString(path + "\n")
+		}
+	case grepOutputCount:
+		for _, fc := range output.Counts {
+			fmt.Fprintf(&sb, "%s:%d\n", fc.Path, fc.Count)
+		}
+	default:
+		start := 0
+		for i := 1; i <= len(output.Matches); i++ {
+			if i < len(output.Matches) && output.Matches[i].Path == output.Matches[start].Path {
+				continue
+			}
+			if start > 0 {
+				sb.WriteString("\n")
+			}
+			writeGrepFileText(&sb, output.Matches[start].Path, output.Matches[start:i])
+			start = i
+		}
+	}
+
+	if output.TotalMatches == 0 && output.FilesMatched == 0 {
+		sb.WriteString("No matches found.\n")
+	}
+	if output.Truncated {
+		fmt.Fprintf(&sb, "\n[Truncated: showing %d matches. Narrow the search or raise maxMatches/maxMatchesPerFile.]\n", output.TotalMatches)
+	}
+	for _, issue := range output.EncodingIssues {
+		action := ""
+		if issue.Skipped {
+			action = ", skipped"
+		}
+		fmt.Fprintf(&sb, "[%s: %d invalid %s sequence(s)%s]\n", issue.Path, issue.InvalidSequences.Count, issue.Encoding, action)
+	}
+	for _, path := range output.SkippedFiles {
+		fmt.Fprintf(&sb, "[%s: too large for multiline search, skipped]\n", path)
+	}
+	return sb.String()
+}
+
+// writeGrepFileText writes one file's matches. Lines shared by several matches are
+// written once, and a line that is both a match and context is shown as a match.
+func writeGrepFileText(sb *strings.Builder, path string, matches []GrepMatch) {
+	type textLine struct {
+		text   string
+		column int // > 0 on the first line of a match
+		match  bool
+	}
+	lines := make(map[int]textLine)
+	addContext := func(num int, text string) {
+		if _, exists := lines[num]; !exists {
+			lines[num] = textLine{text: text}
+		}
+	}
+	for _, m := range matches {
+		for i, text := range m.Before {
+			addContext(m.Line-len(m.Before)+i, text)
+		}
+		last := m.Line
+		for i, text := range strings.Split(m.Text, "\n") {
+			line := textLine{text: text, match: true}
+			if i == 0 {
+				line.column = m.Column
+			}
+			// A line shared with an earlier match keeps its first column
+			if prev, exists := lines[m.Line+i]; exists && prev.column > 0 && (line.column == 0 || prev.column < line.column) {
+				line.column = prev.column
+			}
+			lines[m.Line+i] = line
+			last = m.Line + i
+		}
+		for i, text := range m.After {
+			addContext(last+1+i, text)
+		}
+	}
+
+	nums := make([]int, 0, len(lines))
+	for num := range lines {
+		nums = append(nums, num)
+	}
+	sort.Ints(nums)
+
+	sb.WriteString(path + "\n")
+	for i, num := range nums {
+		if i > 0 && num > nums[i-1]+1 {
+			sb.WriteString("--\n")
+		}
+		line := lines[num]
+		switch {
+		case line.column > 0:
+			fmt.Fprintf(sb, "%d:%d:%s\n", num, line.column, line.text)
+		case line.match:
+			fmt.Fprintf(sb, "%d:%s\n", num, line.text)
+		default:
+			fmt.Fprintf(sb, "%d-%s\n", num, line.text)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/dimitar-grigorov/mcp-file-tools/internal/encoding"
+)
+
+// searchFileMultiline matches patterns against the whole decoded content of a file,
+// so a match may span lines. Files larger than memoryLimit are skipped.
+func searchFileMultiline(ctx context.Context, path string, query *grepQuery, input GrepInput, fileCap int, memoryLimit int64) fileSearchResult {
+	result := fileSearchResult{path: path}
+	info, err := os.Stat(path)
+	if err != nil {
+		return result
+	}
+	if info.Size() > memoryLimit {
+		result.tooLarge = true
+		return result
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || isBinaryFile(data) {
+		return result
+	}
+	content, encodingName := decodeFileContent(data, resolveGrepEncoding(path, input.Encoding))
+
+	if invalid := encoding.FindInvalidSequences(content, encodingName); invalid.Count > 0 {
+		result.issue = &GrepEncodingIssue{
+			Path:             path,
+			Encoding:         encodingName,
+			InvalidSequences: toInvalidSequencesInfo(invalid),
+			Skipped:          input.Strict,
+		}
+		if input.Strict {
+			return result
+		}
+	}
+	if ctx.Err() != nil {
+		return result
+	}
+
+	// Collect matches of every pattern, then order them by position
+	type span struct {
+		start, end int
+		pattern    int
+	}
+	var spans []span
+	seen := make([]bool, len(query.patterns))
+	// Counting modes need every match; otherwise one past the cap shows truncation
+	findLimit := fileCap + 1
+	if query.output != grepOutputMatches {
+		findLimit = -1
+	}
+	for i, re := range query.patterns {
+		for _, loc := range query.findAll(re, content, findLimit) {
+			seen[i] = true
+			spans = append(spans, span{loc[0], loc[1], i})
+		}
+	}
+	if query.all && !allPatternsSeen(seen) {
+		return result
+	}
+	if query.output != grepOutputMatches {
+		result.count = len(spans)
+		return result
+	}
+	sort.SliceStable(spans, func(a, b int) bool { return spans[a].start < spans[b].start })
+
+	lines := strings.Split(content, "\n")
+	lineStarts := make([]int, len(lines))
+	for i, offset := 1, 0; i < len(lines); i++ {
+		offset += len(lines[i-1]) + 1
+		lineStarts[i] = offset
+	}
+	if len(spans) > fileCap {
+		spans = spans[:fileCap]
+		result.truncated = true
+	}
+	for _, sp := range spans {
+		startLine, startCol := offsetToLineCol(lineStarts, sp.start)
+		endLine, endCol := startLine, startCol
+		if sp.end > sp.start {
+			endLine, endCol = offsetToLineCol(lineStarts, sp.end-1)
+		}
+		match := GrepMatch{
+			Path:      path,
+			Line:      startLine,
+			Column:    startCol,
+			EndLine:   endLine,
+			EndColumn: endCol,
+			Text:      strings.Join(lines[startLine-1:endLine], "\n"),
+			Encoding:  encodingName,
+			Pattern:   query.patternLabel(sp.pattern),
+		}
+		if input.ContextBefore > 0 {
+			match.Before = lines[max(0, startLine-1-input.ContextBefore) : startLine-1]
+			if len(match.Before) == 0 {
+				match.Before = nil
+			}
+		}
+		if input.ContextAfter > 0 && endLine < len(lines) {
+			match.After = lines[endLine:min(len(lines), endLine+input.ContextAfter)]
+		}
+		result.matches = append(result.matches, match)
+	}
+	return result
+}
+
+// offsetToLineCol converts a byte offset into a 1-indexed line and byte column.
+func offsetToLineCol(lineStarts []int, offset int) (int, int) {
+	line := sort.Search(len(lineStarts), func(i int) bool { return lineStarts[i] > offset }) - 1
+	return line + 1, offset - lineStarts[line] + 1
+}
+
+// decodeFileContent decodes file data to UTF-8 string.
+// If decoding fails the raw bytes are returned as UTF-8, so invalid sequences stay
+// detectable by FindInvalidSequences.
+func decodeFileContent(data []byte, encodingName string) (string, string) {
+	if encoding.IsUTF8(encodingName) {
+		return string(data), encodingName
+	}
+	enc, ok := encoding.Get(encodingName)
+	if !ok || enc == nil {
+		return string(data), "utf-8"
+	}
+	decoded, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return string(data), "utf-8"
+	}
+	return string(decoded), encodingName
+}
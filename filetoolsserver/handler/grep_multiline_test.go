@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dimitar-grigorov/mcp-file-tools/internal/config"
+)
+
+func TestHandleGrep_Multiline(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+
+	testFile := filepath.Join(tempDir, "unit.pas")
+	content := "unit Main;\n\nprocedure Run;\nbegin\n  DoWork;\nend;\n\nend.\n"
+	os.WriteFile(testFile, []byte(content), 0644)
+
+	_, output, err := h.HandleGrep(context.Background(), nil, GrepInput{
+		Pattern:       `(?s)begin.*?end;`,
+		Paths:         []string{testFile},
+		Multiline:     true,
+		ContextBefore: 1,
+		ContextAfter:  1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.TotalMatches != 1 {
+		t.Fatalf("expected 1 match, got %d", output.TotalMatches)
+	}
+	m := output.Matches[0]
+	if m.Line != 4 || m.Column != 1 || m.EndLine != 6 || m.EndColumn != 4 {
+		t.Errorf("unexpected span %d:%d-%d:%d", m.Line, m.Column, m.EndLine, m.EndColumn)
+	}
+	if m.Text != "begin\n  DoWork;\nend;" {
+		t.Errorf("unexpected text %q", m.Text)
+	}
+	if len(m.Before) != 1 || m.Before[0] != "procedure Run;" || len(m.After) != 1 || m.After[0] != "" {
+		t.Errorf("unexpected context: before=%q after=%q", m.Before, m.After)
+	}
+}
+
+func TestHandleGrep_MultilineLineAnchors(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+
+	testFile := filepath.Join(tempDir, "query.sql")
+	os.WriteFile(testFile, []byte("SELECT id\nFROM users\nWHERE id = 1;\nSELECT 1;\n"), 0644)
+
+	_, output, err := h.HandleGrep(context.Background(), nil, GrepInput{
+		Patterns:    []string{`^SELECT[^;]*;`, `^FROM`},
+		PatternMode: "all",
+		Paths:       []string{testFile},
+		Multiline:   true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.TotalMatches != 3 {
+		t.Fatalf("expected 3 matches, got %+v", output.Matches)
+	}
+	first := output.Matches[0]
+	if first.Line != 1 || first.EndLine != 3 || first.Pattern != `^SELECT[^;]*;` {
+		t.Errorf("unexpected first match: %+v", first)
+	}
+	if output.Matches[1].Line != 2 || output.Matches[1].Pattern != "^FROM" {
+		t.Errorf("expected matches ordered by position, got %+v", output.Matches[1])
+	}
+}
+
+func TestHandleGrep_MultilineSkipsLargeFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir}, WithConfig(&config.Config{DefaultEncoding: "utf-8", MemoryThreshold: 8}))
+
+	testFile := filepath.Join(tempDir, "big.txt")
+	os.WriteFile(testFile, []byte("larger than eight bytes\n"), 0644)
+
+	_, output, err := h.HandleGrep(context.Background(), nil, GrepInput{
+		Pattern:   "eight",
+		Paths:     []string{testFile},
+		Multiline: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.TotalMatches != 0 || len(output.SkippedFiles) != 1 || output.SkippedFiles[0] != testFile {
+		t.Errorf("expected file to be skipped, got matches=%d skipped=%v", output.TotalMatches, output.SkippedFiles)
+	}
+}
+
+func TestHandleGrep_MultilineCount(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+
+	testFile := filepath.Join(tempDir, "unit.pas")
+	os.WriteFile(testFile, []byte("begin\n  a;\nend;\nbegin\nend;\n"), 0644)
+
+	_, output, err := h.HandleGrep(context.Background(), nil, GrepInput{
+		Pattern:    `(?s)begin.*?end;`,
+		Paths:      []string{testFile},
+		Multiline:  true,
+		OutputMode: "count",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(output.Counts) != 1 || output.Counts[0].Count != 2 {
+		t.Errorf("expected count 2, got %+v", output.Counts)
+	}
+}
@@ -1,11 +1,17 @@
 package handler
 
 import (
+	"bufio"
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/dimitar-grigorov/mcp-file-tools/internal/config"
+	"github.com/dimitar-grigorov/mcp-file-tools/internal/encoding"
 )
 
 func TestHandleGrep_SimpleMatch(t *testing.T) {
@@ -133,7 +139,7 @@ func TestHandleGrep_IncludePattern(t *testing.T) {
 	result, output, err := h.HandleGrep(context.Background(), nil, GrepInput{
 		Pattern: "findme",
 		Paths:   []string{tempDir},
-		Include: "*.pas",
+		Include: GlobList{"*.pas"},
 	})
 	if err != nil {
 		t.Fatal(err)
@@ -156,7 +162,7 @@ func TestHandleGrep_ExcludePattern(t *testing.T) {
 	result, output, err := h.HandleGrep(context.Background(), nil, GrepInput{
 		Pattern: "findme",
 		Paths:   []string{tempDir},
-		Exclude: "*.bak",
+		Exclude: GlobList{"*.bak"},
 	})
 	if err != nil {
 		t.Fatal(err)
@@ -325,3 +331,657 @@ func TestHandleGrep_SkipsBinaryFiles(t *testing.T) {
 		t.Errorf("expected 1 match (skipping binary), got %d", output.TotalMatches)
 	}
 }
+
+func TestHandleGrep_InvalidSequences(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+
+	testFile := filepath.Join(tempDir, "test.txt")
+	os.WriteFile(testFile, []byte("match here\nmatch \xff there\n"), 0644)
+
+	_, output, err := h.HandleGrep(context.Background(), nil, GrepInput{
+		Pattern:  "match",
+		Paths:    []string{testFile},
+		Encoding: "utf-8",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.TotalMatches != 2 {
+		t.Errorf("expected 2 matches, got %d", output.TotalMatches)
+	}
+	if len(output.EncodingIssues) != 1 {
+		t.Fatalf("expected 1 encoding issue, got %+v", output.EncodingIssues)
+	}
+	issue := output.EncodingIssues[0]
+	if issue.Path != testFile || issue.Skipped || issue.InvalidSequences.Count != 1 {
+		t.Errorf("unexpected issue: %+v", issue)
+	}
+
+	// Strict mode skips the file
+	_, output, err = h.HandleGrep(context.Background(), nil, GrepInput{
+		Pattern:  "match",
+		Paths:    []string{testFile},
+		Encoding: "utf-8",
+		Strict:   true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.TotalMatches != 0 {
+		t.Errorf("expected no matches in strict mode, got %d", output.TotalMatches)
+	}
+	if len(output.EncodingIssues) != 1 || !output.EncodingIssues[0].Skipped {
+		t.Errorf("expected skipped issue, got %+v", output.EncodingIssues)
+	}
+}
+
+func TestHandleGrep_StopsAtMaxMatchesWithContext(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+
+	testFile := filepath.Join(tempDir, "big.log")
+	var sb strings.Builder
+	for i := 1; i <= 10000; i++ {
+		fmt.Fprintf(&sb, "entry %d ERROR\n", i)
+	}
+	os.WriteFile(testFile, []byte(sb.String()), 0644)
+
+	_, output, err := h.HandleGrep(context.Background(), nil, GrepInput{
+		Pattern:       "ERROR",
+		Paths:         []string{testFile},
+		MaxMatches:    3,
+		ContextBefore: 1,
+		ContextAfter:  2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.TotalMatches != 3 || !output.Truncated {
+		t.Fatalf("expected 3 matches and truncated, got %d (truncated=%v)", output.TotalMatches, output.Truncated)
+	}
+	last := output.Matches[2]
+	if last.Line != 3 {
+		t.Errorf("expected last match on line 3, got %d", last.Line)
+	}
+	if len(last.Before) != 1 || last.Before[0] != "entry 2 ERROR" {
+		t.Errorf("unexpected before-context: %v", last.Before)
+	}
+	// After-context is still filled after the budget is spent
+	if len(last.After) != 2 || last.After[1] != "entry 5 ERROR" {
+		t.Errorf("unexpected after-context: %v", last.After)
+	}
+}
+
+func TestHandleGrep_LongLineTruncated(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir}, WithConfig(&config.Config{DefaultEncoding: "utf-8", MemoryThreshold: 1}))
+
+	testFile := filepath.Join(tempDir, "minified.js")
+	long := strings.Repeat("x", minGrepLineLength*2) + "needle"
+	os.WriteFile(testFile, []byte("needle first\n"+long+"\nneedle last\n"), 0644)
+
+	_, output, err := h.HandleGrep(context.Background(), nil, GrepInput{
+		Pattern: "needle",
+		Paths:   []string{testFile},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The match past the line length cap is not seen; line numbering stays correct
+	if output.TotalMatches != 2 {
+		t.Fatalf("expected 2 matches, got %d", output.TotalMatches)
+	}
+	if output.Matches[1].Line != 3 {
+		t.Errorf("expected second match on line 3, got %d", output.Matches[1].Line)
+	}
+}
+
+func TestHandleGrep_StreamsLegacyEncoding(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+
+	testFile := filepath.Join(tempDir, "unit.pas")
+	enc, _ := encoding.Get("cp1251")
+	data, _ := enc.NewEncoder().String("// Модул за настройки\nprocedure Запис;\nbegin\nend;\n")
+	os.WriteFile(testFile, []byte(data), 0644)
+
+	_, output, err := h.HandleGrep(context.Background(), nil, GrepInput{
+		Pattern:  "Запис",
+		Paths:    []string{testFile},
+		Encoding: "cp1251",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.TotalMatches != 1 || output.Matches[0].Text != "procedure Запис;" || output.Matches[0].Column != 11 {
+		t.Errorf("unexpected matches: %+v", output.Matches)
+	}
+}
+
+func TestReadLine(t *testing.T) {
+	r := bufio.NewReaderSize(strings.NewReader("short\nПриветствие\n\nlast"), 16)
+	want := []string{"short", "Привет", "", "last"}
+	for _, w := range want {
+		line, err := readLine(r, 13) // cuts "Приветствие" inside a rune
+		if err != nil {
+			t.Fatal(err)
+		}
+		if line != w {
+			t.Errorf("expected %q, got %q", w, line)
+		}
+	}
+	if _, err := readLine(r, 13); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+}
+
+func TestLineRing(t *testing.T) {
+	ring := newLineRing(2)
+	if ring.lines() != nil {
+		t.Error("expected nil for empty ring")
+	}
+	for _, l := range []string{"a", "b", "c"} {
+		ring.push(l)
+	}
+	if got := ring.lines(); len(got) != 2 || got[0] != "b" || got[1] != "c" {
+		t.Errorf("expected [b c], got %v", got)
+	}
+	zero := newLineRing(0)
+	zero.push("a")
+	if zero.lines() != nil {
+		t.Error("expected nil for zero-size ring")
+	}
+}
+
+func TestHandleGrep_MultiplePatternsAny(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+
+	testFile := filepath.Join(tempDir, "test.txt")
+	os.WriteFile(testFile, []byte("alpha\nbeta\ngamma\nalpha beta\n"), 0644)
+
+	_, output, err := h.HandleGrep(context.Background(), nil, GrepInput{
+		Patterns: []string{"beta", "alpha"},
+		Paths:    []string{testFile},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.TotalMatches != 3 {
+		t.Fatalf("expected 3 matches, got %d", output.TotalMatches)
+	}
+	// A line matching several patterns is reported once, for the earliest match
+	last := output.Matches[2]
+	if last.Line != 4 || last.Pattern != "alpha" || last.Column != 1 {
+		t.Errorf("unexpected match: %+v", last)
+	}
+}
+
+func TestHandleGrep_MultiplePatternsAll(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+
+	both := filepath.Join(tempDir, "both.sql")
+	one := filepath.Join(tempDir, "one.sql")
+	os.WriteFile(both, []byte("CREATE TABLE users;\nDROP TABLE users;\n"), 0644)
+	os.WriteFile(one, []byte("CREATE TABLE orders;\n"), 0644)
+
+	_, output, err := h.HandleGrep(context.Background(), nil, GrepInput{
+		Pattern:     "CREATE",
+		Patterns:    []string{"DROP"},
+		PatternMode: "all",
+		Paths:       []string{tempDir},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.TotalMatches != 2 || output.FilesMatched != 1 {
+		t.Fatalf("expected 2 matches in 1 file, got %d in %d", output.TotalMatches, output.FilesMatched)
+	}
+	for _, m := range output.Matches {
+		if m.Path != both {
+			t.Errorf("unexpected match in %s", m.Path)
+		}
+	}
+}
+
+func TestHandleGrep_InvalidPatternMode(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+
+	result, _, err := h.HandleGrep(context.Background(), nil, GrepInput{
+		Pattern:     "x",
+		PatternMode: "some",
+		Paths:       []string{tempDir},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.IsError {
+		t.Error("expected error for invalid patternMode")
+	}
+}
+
+func TestHandleGrep_FixedString(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+
+	testFile := filepath.Join(tempDir, "Unit1.pas")
+	os.WriteFile(testFile, []byte("procedure TForm1.Button1Click(Sender: TObject);\nTForm1xButton1Click\n"), 0644)
+
+	_, output, err := h.HandleGrep(context.Background(), nil, GrepInput{
+		Pattern:     "TForm1.Button1Click(",
+		Paths:       []string{testFile},
+		FixedString: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.TotalMatches != 1 || output.Matches[0].Line != 1 || output.Matches[0].Column != 11 {
+		t.Errorf("expected one literal match at 1:11, got %+v", output.Matches)
+	}
+}
+
+func TestHandleGrep_WordBoundary(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+
+	testFile := filepath.Join(tempDir, "test.pas")
+	os.WriteFile(testFile, []byte("Count := 0;\nRecordCount := 1;\nInc(Count);\nCount_2 := 3;\nСума := Сумата;\n"), 0644)
+
+	_, output, err := h.HandleGrep(context.Background(), nil, GrepInput{
+		Patterns:     []string{"Count", "Сума"},
+		Paths:        []string{testFile},
+		WordBoundary: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines []int
+	for _, m := range output.Matches {
+		lines = append(lines, m.Line)
+	}
+	if fmt.Sprint(lines) != "[1 3 5]" {
+		t.Errorf("expected matches on lines [1 3 5], got %v", lines)
+	}
+	// Non-ASCII word boundary: "Сума" at column 1, not inside "Сумата"
+	if last := output.Matches[2]; last.Column != 1 {
+		t.Errorf("expected match at column 1, got %d", last.Column)
+	}
+}
+
+func TestHandleGrep_InvertMatch(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+
+	testFile := filepath.Join(tempDir, "test.txt")
+	os.WriteFile(testFile, []byte("keep\n# comment\nkeep too\n"), 0644)
+
+	_, output, err := h.HandleGrep(context.Background(), nil, GrepInput{
+		Pattern:     "^#",
+		Paths:       []string{testFile},
+		InvertMatch: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.TotalMatches != 2 || output.Matches[0].Text != "keep" || output.Matches[1].Line != 3 {
+		t.Errorf("unexpected inverted matches: %+v", output.Matches)
+	}
+	if output.Matches[0].Column != 0 {
+		t.Errorf("expected no column for inverted match, got %d", output.Matches[0].Column)
+	}
+
+	result, _, _ := h.HandleGrep(context.Background(), nil, GrepInput{
+		Pattern:     "x",
+		Paths:       []string{testFile},
+		InvertMatch: true,
+		Multiline:   true,
+	})
+	if !result.IsError {
+		t.Error("expected error for invertMatch with multiline")
+	}
+}
+
+func TestHandleGrep_OutputModes(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+
+	a := filepath.Join(tempDir, "a.txt")
+	b := filepath.Join(tempDir, "b.txt")
+	c := filepath.Join(tempDir, "c.txt")
+	os.WriteFile(a, []byte("TODO one\nTODO two\nTODO three\n"), 0644)
+	os.WriteFile(b, []byte("TODO\n"), 0644)
+	os.WriteFile(c, []byte("nothing\n"), 0644)
+
+	_, output, err := h.HandleGrep(context.Background(), nil, GrepInput{
+		Pattern:    "TODO",
+		Paths:      []string{tempDir},
+		OutputMode: "filesWithMatches",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(output.Matches) != 0 || len(output.Files) != 2 || output.FilesMatched != 2 {
+		t.Errorf("expected 2 files and no matches, got files=%v matches=%d", output.Files, len(output.Matches))
+	}
+
+	_, output, err = h.HandleGrep(context.Background(), nil, GrepInput{
+		Pattern:    "TODO",
+		Paths:      []string{tempDir},
+		OutputMode: "count",
+		MaxMatches: 1, // does not limit counting
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := map[string]int{}
+	for _, fc := range output.Counts {
+		counts[fc.Path] = fc.Count
+	}
+	if counts[a] != 3 || counts[b] != 1 || len(counts) != 2 || output.TotalMatches != 4 || output.Truncated {
+		t.Errorf("unexpected counts: %+v (total %d, truncated %v)", output.Counts, output.TotalMatches, output.Truncated)
+	}
+
+	result, _, _ := h.HandleGrep(context.Background(), nil, GrepInput{
+		Pattern:    "TODO",
+		Paths:      []string{tempDir},
+		OutputMode: "paths",
+	})
+	if !result.IsError {
+		t.Error("expected error for invalid outputMode")
+	}
+}
+
+func TestHandleGrep_DeterministicOrder(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+
+	for i := 0; i < 20; i++ {
+		os.WriteFile(filepath.Join(tempDir, fmt.Sprintf("f%02d.txt", i)), []byte("hit\nmiss\nhit\n"), 0644)
+	}
+
+	var first []string
+	for run := 0; run < 5; run++ {
+		_, output, err := h.HandleGrep(context.Background(), nil, GrepInput{
+			Pattern:    "hit",
+			Paths:      []string{filepath.Join(tempDir, "f10.txt"), tempDir},
+			MaxMatches: 15,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if output.TotalMatches != 15 || !output.Truncated {
+			t.Fatalf("expected 15 truncated matches, got %d (truncated=%v)", output.TotalMatches, output.Truncated)
+		}
+		var got []string
+		for _, m := range output.Matches {
+			got = append(got, fmt.Sprintf("%s:%d", filepath.Base(m.Path), m.Line))
+		}
+		if run == 0 {
+			first = got
+			// Sorted by path then line; truncation keeps the first files
+			if got[0] != "f00.txt:1" || got[1] != "f00.txt:3" || got[14] != "f07.txt:1" {
+				t.Fatalf("unexpected order: %v", got)
+			}
+			continue
+		}
+		if fmt.Sprint(got) != fmt.Sprint(first) {
+			t.Fatalf("run %d order differs:\n%v\n%v", run, got, first)
+		}
+	}
+}
+
+func TestHandleGrep_MaxMatchesPerFileGrouped(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+
+	a := filepath.Join(tempDir, "a.txt")
+	b := filepath.Join(tempDir, "b.txt")
+	os.WriteFile(a, []byte("x1\nx2\nx3\nx4\n"), 0644)
+	os.WriteFile(b, []byte("x1\n"), 0644)
+
+	_, output, err := h.HandleGrep(context.Background(), nil, GrepInput{
+		Pattern:           "x",
+		Paths:             []string{tempDir},
+		MaxMatchesPerFile: 2,
+		Format:            "grouped",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(output.Matches) != 0 || len(output.Groups) != 2 {
+		t.Fatalf("expected 2 groups and no flat matches, got %+v", output)
+	}
+	ga, gb := output.Groups[0], output.Groups[1]
+	if ga.Path != a || ga.MatchCount != 2 || !ga.Truncated || ga.Encoding == "" {
+		t.Errorf("unexpected first group: %+v", ga)
+	}
+	if ga.Matches[1].Line != 2 || ga.Matches[0].Path != "" {
+		t.Errorf("unexpected group matches: %+v", ga.Matches)
+	}
+	if gb.Path != b || gb.MatchCount != 1 || gb.Truncated {
+		t.Errorf("unexpected second group: %+v", gb)
+	}
+	if output.TotalMatches != 3 || !output.Truncated {
+		t.Errorf("expected 3 matches and truncated, got %d (truncated=%v)", output.TotalMatches, output.Truncated)
+	}
+}
+
+func TestHandleGrep_TextFormat(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+
+	a := filepath.Join(tempDir, "a.pas")
+	b := filepath.Join(tempDir, "b.pas")
+	os.WriteFile(a, []byte("one\nbegin\ntwo\nbegin\nthree\nfour\nfive\nbegin\n"), 0644)
+	os.WriteFile(b, []byte("  begin\n"), 0644)
+
+	result, output, err := h.HandleGrep(context.Background(), nil, GrepInput{
+		Pattern:       "begin",
+		Paths:         []string{tempDir},
+		ContextBefore: 1,
+		Format:        "text",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := a + "\n1-one\n2:1:begin\n3-two\n4:1:begin\n--\n7-five\n8:1:begin\n\n" + b + "\n1:3:  begin\n"
+	if got := extractTextFromResult(result.Content); got != want {
+		t.Errorf("unexpected text:\n%s\nwant:\n%s", got, want)
+	}
+	if output.TotalMatches != 4 || output.FilesMatched != 2 || output.Matches != nil {
+		t.Errorf("expected summary only, got %+v", output)
+	}
+}
+
+func TestWriteGrepFileText_SharedMatchLines(t *testing.T) {
+	tests := []struct {
+		name    string
+		matches []GrepMatch
+		want    string
+	}{
+		{
+			"multiline match after a match with a column",
+			[]GrepMatch{{Line: 2, Column: 3, Text: "b foo"}, {Line: 1, Column: 1, Text: "x\nb foo"}},
+			"f\n1:1:x\n2:3:b foo\n",
+		},
+		{
+			"match with a column after a multiline match",
+			[]GrepMatch{{Line: 1, Column: 1, Text: "x\nb foo"}, {Line: 2, Column: 3, Text: "b foo"}},
+			"f\n1:1:x\n2:3:b foo\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sb strings.Builder
+			writeGrepFileText(&sb, "f", tt.matches)
+			if got := sb.String(); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleGrep_CountModesIgnoreMatchCaps(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		os.WriteFile(filepath.Join(tempDir, name), []byte("x\nx\nx\n"), 0644)
+	}
+
+	_, output, _ := h.HandleGrep(context.Background(), nil, GrepInput{
+		Pattern:           "x",
+		Paths:             []string{tempDir},
+		OutputMode:        "count",
+		MaxMatches:        2,
+		MaxMatchesPerFile: 1,
+	})
+	if output.TotalMatches != 9 || len(output.Counts) != 3 || output.Truncated {
+		t.Errorf("expected exact counts for all files, got %+v", output)
+	}
+
+	_, output, _ = h.HandleGrep(context.Background(), nil, GrepInput{
+		Pattern:    "x",
+		Paths:      []string{tempDir},
+		OutputMode: "filesWithMatches",
+		MaxMatches: 1,
+	})
+	if len(output.Files) != 3 || output.Truncated {
+		t.Errorf("expected every matching file, got %+v", output)
+	}
+}
+
+func TestHandleGrep_InvalidFormat(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+
+	result, _, _ := h.HandleGrep(context.Background(), nil, GrepInput{Pattern: "x", Paths: []string{tempDir}, Format: "xml"})
+	if !result.IsError {
+		t.Error("expected error for invalid format")
+	}
+}
+
+func TestHandleGrep_IncludeExcludeLists(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+
+	for _, rel := range []string{"src/a.pas", "src/b.dfm", "src/gen/c.pas", "src/d.txt", "e.pas"} {
+		os.MkdirAll(filepath.Dir(filepath.Join(tempDir, rel)), 0755)
+		os.WriteFile(filepath.Join(tempDir, rel), []byte("findme\n"), 0644)
+	}
+
+	_, output, _ := h.HandleGrep(context.Background(), nil, GrepInput{
+		Pattern: "findme",
+		Paths:   []string{tempDir},
+		Include: GlobList{"src/**/*.{pas,dfm}"},
+		Exclude: GlobList{"gen", "*.bak"},
+	})
+	if output.FilesMatched != 2 {
+		t.Errorf("expected 2 files, got %d: %+v", output.FilesMatched, output.Matches)
+	}
+
+	result, _, _ := h.HandleGrep(context.Background(), nil, GrepInput{
+		Pattern: "findme",
+		Paths:   []string{tempDir},
+		Include: GlobList{"[abc"},
+	})
+	if !result.IsError {
+		t.Error("expected error for invalid include pattern")
+	}
+}
+
+func TestHandleGrep_RespectIgnoreFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+
+	for _, rel := range []string{"src/a.pas", "node_modules/pkg/b.js", "__history/a.pas.~1~", "src/out/c.pas", "src/out/keep.pas", ".git/d"} {
+		os.MkdirAll(filepath.Dir(filepath.Join(tempDir, rel)), 0755)
+		os.WriteFile(filepath.Join(tempDir, rel), []byte("findme\n"), 0644)
+	}
+	os.WriteFile(filepath.Join(tempDir, ".gitignore"), []byte("node_modules/\n"), 0644)
+	os.WriteFile(filepath.Join(tempDir, "src", ".gitignore"), []byte("out/*\n!out/keep.pas\n"), 0644)
+	os.WriteFile(filepath.Join(tempDir, ".mcpignore"), []byte("__history\n"), 0644)
+
+	// Walking a subdirectory still honors the project root's ignore files
+	_, output, _ := h.HandleGrep(context.Background(), nil, GrepInput{Pattern: "findme", Paths: []string{filepath.Join(tempDir, "src")}})
+	if output.FilesMatched != 2 {
+		t.Errorf("expected src/a.pas and src/out/keep.pas, got %+v", output.Matches)
+	}
+
+	_, output, _ = h.HandleGrep(context.Background(), nil, GrepInput{Pattern: "findme", Paths: []string{tempDir}})
+	if output.FilesMatched != 2 {
+		t.Errorf("expected 2 files with ignore files, got %+v", output.Matches)
+	}
+
+	// Files named directly are searched even when ignored
+	_, output, _ = h.HandleGrep(context.Background(), nil, GrepInput{Pattern: "findme", Paths: []string{filepath.Join(tempDir, "node_modules", "pkg", "b.js")}})
+	if output.FilesMatched != 1 {
+		t.Errorf("expected explicitly named file to be searched, got %d", output.FilesMatched)
+	}
+
+	respect := false
+	_, output, _ = h.HandleGrep(context.Background(), nil, GrepInput{Pattern: "findme", Paths: []string{tempDir}, RespectIgnoreFiles: &respect})
+	if output.FilesMatched != 6 {
+		t.Errorf("expected 6 files without ignore files, got %d", output.FilesMatched)
+	}
+}
+
+func TestHandleGrep_ContentIndex(t *testing.T) {
+	tempDir := t.TempDir()
+	encoded, _ := encoding.Get("cp1251")
+	cp1251, _ := encoded.NewEncoder().String("// Главная форма\r\n")
+	mainFile := filepath.Join(tempDir, "main.pas")
+	os.WriteFile(mainFile, []byte(cp1251), 0644)
+	otherFile := filepath.Join(tempDir, "other.pas")
+	os.WriteFile(otherFile, []byte("unit Other;\n"), 0644)
+	h := NewHandler([]string{tempDir}, WithConfig(&config.Config{
+		MemoryThreshold: config.DefaultMaxSize,
+		ContentIndex:    true,
+		IndexDir:        t.TempDir(),
+	}))
+	h.index.Wait()
+
+	caseSensitive := false
+	query, _ := newGrepQuery(GrepInput{Pattern: "главная", CaseSensitive: &caseSensitive})
+	if got := h.indexCandidates([]string{mainFile, otherFile}, query, ""); len(got) != 1 || got[0] != mainFile {
+		t.Errorf("expected only %s as candidate, got %v", mainFile, got)
+	}
+	// Inverted matches can't use the index
+	query, _ = newGrepQuery(GrepInput{Pattern: "главная", InvertMatch: true})
+	if got := h.indexCandidates([]string{mainFile, otherFile}, query, ""); len(got) != 2 {
+		t.Errorf("expected every file for invertMatch, got %v", got)
+	}
+
+	_, output, _ := h.HandleGrep(context.Background(), nil, GrepInput{Pattern: "Главная", Paths: []string{tempDir}})
+	if output.FilesMatched != 1 || output.FilesSearched != 2 {
+		t.Errorf("unexpected output: %+v", output)
+	}
+
+	// Files changed after indexing are still searched
+	os.WriteFile(otherFile, []byte("// Главная\n"), 0644)
+	_, output, _ = h.HandleGrep(context.Background(), nil, GrepInput{Pattern: "Главная", Paths: []string{tempDir}})
+	if output.FilesMatched != 2 {
+		t.Errorf("expected changed file to be searched, got %+v", output.Matches)
+	}
+	h.index.Wait()
+}
+
+func TestSearchSingleFile_StopsAtBudget(t *testing.T) {
+	tempDir := t.TempDir()
+	path := filepath.Join(tempDir, "many.txt")
+	os.WriteFile(path, []byte(strings.Repeat("match\n", 1000)), 0644)
+	query, err := newGrepQuery(GrepInput{Pattern: "match"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Earlier files left room for 3 matches, far below the per-file cap
+	budget := &matchBudget{}
+	budget.remaining.Store(3)
+	result := searchSingleFile(context.Background(), path, query, GrepInput{Encoding: "utf-8"}, 100, budget, config.DefaultMaxSize)
+	if len(result.matches) != 3 || !result.truncated {
+		t.Errorf("expected 3 matches and truncation, got %d (truncated=%v)", len(result.matches), result.truncated)
+	}
+}
@@ -5,7 +5,10 @@ import (
 	"sync"
 
 	"github.com/dimitar-grigorov/mcp-file-tools/internal/config"
+	"github.com/dimitar-grigorov/mcp-file-tools/internal/ignore"
+	"github.com/dimitar-grigorov/mcp-file-tools/internal/index"
 	"github.com/dimitar-grigorov/mcp-file-tools/internal/security"
+	"github.com/dimitar-grigorov/mcp-file-tools/internal/watch"
 )
 
 // Default permissions for new files and directories
@@ -18,6 +21,8 @@ const (
 type Handler struct {
 	config      *config.Config
 	allowedDirs []string
+	index       *index.Manager // nil unless the content index is enabled
+	watcher     *watch.Watcher // nil until StartWatching
 	mu          sync.RWMutex
 }
 
@@ -45,6 +50,11 @@ func NewHandler(allowedDirs []string, opts ...Option) *Handler {
 		opt(h)
 	}
 
+	if h.config.ContentIndex {
+		h.index = index.NewManager(h.config.IndexDir, h.config.MemoryThreshold)
+		h.index.Sync(h.ResolvedAllowedDirs())
+	}
+
 	return h
 }
 
@@ -62,11 +72,67 @@ func (h *Handler) ResolvedAllowedDirs() []string {
 	return security.ResolveAllowedDirs(h.GetAllowedDirectories())
 }
 
+// ignoreMatcher returns a matcher for the ignore files that apply to a walk of root,
+// or nil when disabled. Ignore files are read from the allowed directory that
+// contains root downwards, so a .gitignore at the project root also applies when
+// walking a subdirectory.
+func (h *Handler) ignoreMatcher(root string, enabled bool) *ignore.Matcher {
+	if !enabled {
+		return nil
+	}
+	base := root
+	longest := 0
+	for _, dir := range h.ResolvedAllowedDirs() {
+		if len(dir) > longest && security.IsPathWithinAllowedDirectories(root, []string{dir}) {
+			base, longest = dir, len(dir)
+		}
+	}
+	return ignore.NewMatcher(base)
+}
+
 // UpdateAllowedDirectories updates the allowed directories (for MCP Roots protocol)
 func (h *Handler) UpdateAllowedDirectories(newDirs []string) {
 	h.mu.Lock()
-	defer h.mu.Unlock()
 	h.allowedDirs = newDirs
+	watcher := h.watcher
+	h.mu.Unlock()
+	resolved := h.ResolvedAllowedDirs()
+	h.index.Sync(resolved)
+	watcher.Sync(resolved)
+}
+
+// StartWatching watches the allowed directories for changes, which backs
+// list_changed_files. onChange, if not nil, is called for every change and
+// must not block. Does nothing when watching is disabled in the config.
+func (h *Handler) StartWatching(onChange func(watch.Change)) error {
+	if !h.config.Watch {
+		return nil
+	}
+	w, err := watch.New(onChange)
+	if err != nil {
+		return err
+	}
+	h.mu.Lock()
+	h.watcher = w
+	h.mu.Unlock()
+	w.Sync(h.ResolvedAllowedDirs())
+	return nil
+}
+
+// StopWatching stops watching for changes.
+func (h *Handler) StopWatching() error {
+	h.mu.Lock()
+	w := h.watcher
+	h.watcher = nil
+	h.mu.Unlock()
+	return w.Close()
+}
+
+// getWatcher returns the watcher, or nil if not watching.
+func (h *Handler) getWatcher() *watch.Watcher {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.watcher
 }
 
 // validatePath validates a path against allowed directories
@@ -3,26 +3,41 @@ package handler
 import (
 	"bufio"
 	"context"
+	"fmt"
 	"io"
 	"os"
 	"strings"
+	"unicode/utf8"
 
+	"github.com/dimitar-grigorov/mcp-file-tools/internal/encoding"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	xencoding "golang.org/x/text/encoding"
+	"golang.org/x/text/transform"
 )
 
 // LineEndingStyle constants for line ending types.
 const (
 	LineEndingCRLF  = "crlf"
 	LineEndingLF    = "lf"
+	LineEndingCR    = "cr" // classic Mac OS
 	LineEndingMixed = "mixed"
 	LineEndingNone  = "none"
 )
 
+// Unicode line separators, in UTF-8. They are not line endings to most tools,
+// so they are reported and converted but never split lines.
+const (
+	unicodeNEL = "\u0085" // next line
+	unicodeLS  = "\u2028" // line separator
+	unicodePS  = "\u2029" // paragraph separator
+)
+
 // LineEndingInfo holds detected line ending information.
 type LineEndingInfo struct {
-	Style     string // "crlf", "lf", "mixed", or "none"
+	Style     string // "crlf", "lf", "cr", "mixed", or "none"
 	CRLFCount int
 	LFCount   int // LF not preceded by CR
+	CRCount   int // CR not followed by LF
 }
 
 // DetectLineEndings analyzes data and returns line ending information.
@@ -34,48 +49,140 @@ func DetectLineEndings(data []byte) LineEndingInfo {
 		if data[i] == '\r' && i+1 < len(data) && data[i+1] == '\n' {
 			info.CRLFCount++
 			i++ // skip the \n
+		} else if data[i] == '\r' {
+			info.CRCount++
 		} else if data[i] == '\n' {
 			info.LFCount++
 		}
 	}
 
-	info.Style = determineStyle(info.CRLFCount, info.LFCount)
+	info.Style = determineStyle(info.CRLFCount, info.LFCount, info.CRCount)
 	return info
 }
 
 // determineStyle returns the line ending style based on counts.
-func determineStyle(crlfCount, lfCount int) string {
+func determineStyle(crlfCount, lfCount, crCount int) string {
 	switch {
-	case crlfCount == 0 && lfCount == 0:
+	case crlfCount == 0 && lfCount == 0 && crCount == 0:
 		return LineEndingNone
-	case crlfCount > 0 && lfCount == 0:
+	case lfCount == 0 && crCount == 0:
 		return LineEndingCRLF
-	case crlfCount == 0 && lfCount > 0:
+	case crlfCount == 0 && crCount == 0:
 		return LineEndingLF
+	case crlfCount == 0 && lfCount == 0:
+		return LineEndingCR
 	default:
 		return LineEndingMixed
 	}
 }
 
-// ConvertLineEndings converts text to the specified line ending style.
+// dominantStyle returns the most frequent line ending, preferring CRLF, then LF
+// on ties.
+func dominantStyle(crlfCount, lfCount, crCount int) string {
+	switch {
+	case crlfCount >= lfCount && crlfCount >= crCount:
+		return LineEndingCRLF
+	case lfCount >= crCount:
+		return LineEndingLF
+	default:
+		return LineEndingCR
+	}
+}
+
+// countLines returns the number of lines in text, where CRLF, LF and CR each end
+// a line. Text after the last line ending is a line, even if empty.
+func countLines(text string) int {
+	return strings.Count(text, "\n") + strings.Count(text, "\r") - strings.Count(text, "\r\n") + 1
+}
+
+// splitLines splits text after each CRLF, LF or CR, keeping the line endings.
+// Text after the last line ending is the last element, even if empty.
+func splitLines(text string) []string {
+	lines := make([]string, 0, countLines(text))
+	for {
+		i := strings.IndexAny(text, "\r\n")
+		if i < 0 {
+			return append(lines, text)
+		}
+		end := i + 1
+		if text[i] == '\r' && end < len(text) && text[end] == '\n' {
+			end++
+		}
+		lines = append(lines, text[:end])
+		text = text[end:]
+	}
+}
+
+// trimLineEnding removes one trailing CRLF, LF or CR.
+func trimLineEnding(line string) string {
+	if strings.HasSuffix(line, "\r\n") {
+		return line[:len(line)-2]
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+}
+
+// ConvertLineEndings converts CRLF, LF and CR line endings in text to the
+// specified style. Unicode line separators are left alone.
 func ConvertLineEndings(text string, targetStyle string) string {
-	hasCRLF := strings.Contains(text, "\r\n")
+	if strings.Contains(text, "\r") {
+		text = strings.ReplaceAll(text, "\r\n", "\n")
+		text = strings.ReplaceAll(text, "\r", "\n")
+	}
+	switch targetStyle {
+	case LineEndingCRLF:
+		return strings.ReplaceAll(text, "\n", "\r\n")
+	case LineEndingCR:
+		return strings.ReplaceAll(text, "\n", "\r")
+	default: // LF, or a non-convertible style
+		return text
+	}
+}
 
-	if targetStyle == LineEndingCRLF {
-		if !hasCRLF {
-			// Only LF present, single pass: LF -> CRLF
-			return strings.ReplaceAll(text, "\n", "\r\n")
+// isUTF8Prefix reports whether data is valid UTF-8, except for a rune cut off at
+// the end.
+func isUTF8Prefix(data []byte) bool {
+	for cut := 0; cut < utf8.UTFMax && cut <= len(data); cut++ {
+		if utf8.Valid(data[:len(data)-cut]) {
+			return true
 		}
-		// Has CRLF (might be mixed), normalize then convert
-		normalized := strings.ReplaceAll(text, "\r\n", "\n")
-		return strings.ReplaceAll(normalized, "\n", "\r\n")
 	}
+	return false
+}
 
-	// Target is LF (or other non-CRLF style)
-	if !hasCRLF {
-		return text // Already no CRLF
+// countUnicodeSeparators counts NEL, LS and PS characters in UTF-8 text.
+func countUnicodeSeparators(text string) int {
+	return strings.Count(text, unicodeNEL) + strings.Count(text, unicodeLS) + strings.Count(text, unicodePS)
+}
+
+// replaceUnicodeSeparators replaces NEL, LS and PS characters in UTF-8 text
+// with LF.
+func replaceUnicodeSeparators(text string) string {
+	return strings.NewReplacer(unicodeNEL, "\n", unicodeLS, "\n", unicodePS, "\n").Replace(text)
+}
+
+// wideEncoding returns the encoding a file must be decoded with before its line
+// endings can be read: UTF-16, where CR and LF take two bytes each. It returns
+// nil for all other encodings, which store CR and LF as single bytes. UTF-32 is
+// rejected because no UTF-32 codec is available.
+func wideEncoding(charset string) (xencoding.Encoding, error) {
+	switch charset {
+	case "utf-16-le", "utf-16-be":
+		enc, _ := encoding.Get(charset)
+		return enc, nil
+	case "utf-32-le", "utf-32-be":
+		return nil, fmt.Errorf("line endings of %s files are not supported", charset)
 	}
-	return strings.ReplaceAll(text, "\r\n", "\n")
+	return nil, nil
+}
+
+// detectWideEncoding detects whether data is UTF-16 (see wideEncoding).
+func detectWideEncoding(data []byte) (string, xencoding.Encoding, error) {
+	detected := encoding.Detect(data)
+	if detected.Confidence < encoding.MinConfidenceThreshold {
+		return "", nil, nil
+	}
+	enc, err := wideEncoding(detected.Charset)
+	return detected.Charset, enc, err
 }
 
 // HandleDetectLineEndings detects line ending style and returns inconsistent line numbers.
@@ -91,16 +198,37 @@ func (h *Handler) HandleDetectLineEndings(ctx context.Context, req *mcp.CallTool
 	}
 	defer f.Close()
 
+	// UTF-16 is decoded on the fly, so CR and LF are single bytes below
+	var r io.Reader = f
+	var enc xencoding.Encoding
+	detected, err := encoding.DetectFromFile(v.Path, "sample")
+	if err == nil && detected.Confidence >= encoding.MinConfidenceThreshold {
+		if enc, err = wideEncoding(detected.Charset); err != nil {
+			return errorResult(err.Error()), DetectLineEndingsOutput{}, nil
+		}
+		if enc != nil {
+			r = transform.NewReader(f, enc.NewDecoder())
+		}
+	}
+	// Unicode separators only exist in Unicode text; in legacy encodings their
+	// UTF-8 bytes are ordinary characters. Short UTF-8 text is often detected as
+	// a legacy encoding, so check the start of the file instead.
+	unicodeText := enc != nil
+	if !unicodeText {
+		head, _ := readFileHead(v.Path, encoding.ChunkSize)
+		unicodeText = isUTF8Prefix(head)
+	}
+
 	// Track each line's ending type
 	type lineEnding struct {
 		lineNum int
-		isCRLF  bool
+		style   string // a line ending style, or "unicode" for a Unicode separator
 	}
 	var lineEndings []lineEnding
+	var counts LineEndingCounts
 
-	br := bufio.NewReader(f)
+	br := bufio.NewReader(r)
 	lineNum := 1
-	prevWasCR := false
 
 	for {
 		b, err := br.ReadByte()
@@ -111,54 +239,65 @@ func (h *Handler) HandleDetectLineEndings(ctx context.Context, req *mcp.CallTool
 			return errorResult("failed to read file: " + err.Error()), DetectLineEndingsOutput{}, nil
 		}
 
-		if b == '\n' {
-			lineEndings = append(lineEndings, lineEnding{lineNum: lineNum, isCRLF: prevWasCR})
+		switch {
+		case b == '\r':
+			style := LineEndingCR
+			if next, err := br.Peek(1); err == nil && next[0] == '\n' {
+				br.ReadByte()
+				style = LineEndingCRLF
+				counts.CRLF++
+			} else {
+				counts.CR++
+			}
+			lineEndings = append(lineEndings, lineEnding{lineNum: lineNum, style: style})
+			lineNum++
+		case b == '\n':
+			counts.LF++
+			lineEndings = append(lineEndings, lineEnding{lineNum: lineNum, style: LineEndingLF})
 			lineNum++
+		case unicodeText && (b == unicodeNEL[0] || b == unicodeLS[0]):
+			// A separator does not end the line it is on
+			if isUnicodeSeparator(br, b) {
+				counts.Unicode++
+				lineEndings = append(lineEndings, lineEnding{lineNum: lineNum, style: "unicode"})
+			}
 		}
-		prevWasCR = (b == '\r')
 	}
 
-	// Count totals
-	crlfCount := 0
-	lfCount := 0
+	// Determine style and find inconsistent lines: those not ending in the
+	// dominant style, and all lines containing Unicode separators
+	style := determineStyle(counts.CRLF, counts.LF, counts.CR)
+	dominant := dominantStyle(counts.CRLF, counts.LF, counts.CR)
+	inconsistentLines := []int{}
 	for _, le := range lineEndings {
-		if le.isCRLF {
-			crlfCount++
-		} else {
-			lfCount++
-		}
-	}
-
-	// Determine style and find inconsistent lines
-	style := determineStyle(crlfCount, lfCount)
-	var inconsistentLines []int
-
-	if style == LineEndingMixed {
-		// Dominant is the one with more occurrences
-		dominantIsCRLF := crlfCount >= lfCount
-		for _, le := range lineEndings {
-			if le.isCRLF != dominantIsCRLF {
+		if (style == LineEndingMixed || le.style == "unicode") && le.style != dominant {
+			if n := len(inconsistentLines); n == 0 || inconsistentLines[n-1] != le.lineNum {
 				inconsistentLines = append(inconsistentLines, le.lineNum)
 			}
 		}
 	}
 
-	// Total lines = last line number (includes last line even without trailing newline)
-	totalLines := lineNum
-	if len(lineEndings) > 0 {
-		totalLines = lineNum // lineNum is 1 more than number of newlines found
-	} else {
-		totalLines = 1 // File has content but no newlines = 1 line
-	}
-
-	// Ensure we don't return nil slice (return empty array for JSON)
-	if inconsistentLines == nil {
-		inconsistentLines = []int{}
-	}
-
+	// lineNum is 1 more than the number of line endings found, which includes
+	// the last line even without a trailing line ending
 	return &mcp.CallToolResult{}, DetectLineEndingsOutput{
 		Style:             style,
-		TotalLines:        totalLines,
+		TotalLines:        lineNum,
 		InconsistentLines: inconsistentLines,
+		Counts:            counts,
 	}, nil
 }
+
+// isUnicodeSeparator reports whether first and the next bytes of br are a NEL,
+// LS or PS, consuming them if so.
+func isUnicodeSeparator(br *bufio.Reader, first byte) bool {
+	for _, sep := range []string{unicodeNEL, unicodeLS, unicodePS} {
+		if sep[0] != first {
+			continue
+		}
+		if rest, err := br.Peek(len(sep) - 1); err == nil && string(rest) == sep[1:] {
+			br.Discard(len(rest))
+			return true
+		}
+	}
+	return false
+}
@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
@@ -13,6 +15,7 @@ func TestDetectLineEndings(t *testing.T) {
 		wantStyle string
 		wantCRLF  int
 		wantLF    int
+		wantCR    int
 	}{
 		{
 			name:      "CRLF only",
@@ -64,8 +67,24 @@ func TestDetectLineEndings(t *testing.T) {
 			wantLF:    1,
 		},
 		{
-			name:      "standalone CR ignored",
+			name:      "CR only",
+			input:     []byte("line1\rline2\rline3"),
+			wantStyle: LineEndingCR,
+			wantCRLF:  0,
+			wantLF:    0,
+			wantCR:    2,
+		},
+		{
+			name:      "standalone CR in LF file",
 			input:     []byte("line1\rline2\nline3"),
+			wantStyle: LineEndingMixed,
+			wantCRLF:  0,
+			wantLF:    1,
+			wantCR:    1,
+		},
+		{
+			name:      "Unicode separators are not line endings",
+			input:     []byte("line1\u2028line2\u0085line3\n"),
 			wantStyle: LineEndingLF,
 			wantCRLF:  0,
 			wantLF:    1,
@@ -84,6 +103,9 @@ func TestDetectLineEndings(t *testing.T) {
 			if got.LFCount != tt.wantLF {
 				t.Errorf("LFCount = %d, want %d", got.LFCount, tt.wantLF)
 			}
+			if got.CRCount != tt.wantCR {
+				t.Errorf("CRCount = %d, want %d", got.CRCount, tt.wantCR)
+			}
 		})
 	}
 }
@@ -105,6 +127,13 @@ func TestConvertLineEndings(t *testing.T) {
 		{"CRLF to CRLF (unchanged)", "line1\r\nline2\r\n", LineEndingCRLF, "line1\r\nline2\r\n"},
 		{"mixed to CRLF", "line1\r\nline2\nline3", LineEndingCRLF, "line1\r\nline2\r\nline3"},
 
+		// To CR
+		{"LF to CR", "line1\nline2\n", LineEndingCR, "line1\rline2\r"},
+		{"mixed to CR", "line1\r\nline2\nline3\r", LineEndingCR, "line1\rline2\rline3\r"},
+		{"CR to LF", "line1\rline2\r", LineEndingLF, "line1\nline2\n"},
+		{"CR to CRLF", "line1\rline2\r", LineEndingCRLF, "line1\r\nline2\r\n"},
+		{"Unicode separators unchanged", "a\u2028b\n", LineEndingCRLF, "a\u2028b\r\n"},
+
 		// Other styles (treated as LF)
 		{"to mixed (becomes LF)", "line1\r\nline2\r\n", LineEndingMixed, "line1\nline2\n"},
 		{"to none (becomes LF)", "line1\r\nline2\r\n", LineEndingNone, "line1\nline2\n"},
@@ -236,3 +265,76 @@ func TestHandleDetectLineEndings_PathValidation(t *testing.T) {
 		t.Error("expected error for path outside allowed directory")
 	}
 }
+
+func TestHandleDetectLineEndings_UTF16(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+	testFile := filepath.Join(tempDir, "resource.rc")
+	// The raw bytes of CRLF in UTF-16 LE are 0D 00 0A 00, with no adjacent CR and LF
+	os.WriteFile(testFile, encodeUTF16(t, "utf-16-le", "line1\r\nline2\r\nline3\nline4\r\n"), 0644)
+
+	result, output, _ := h.HandleDetectLineEndings(context.Background(), nil, DetectLineEndingsInput{Path: testFile})
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", extractTextFromResult(result.Content))
+	}
+	if output.Style != LineEndingMixed || output.TotalLines != 5 || len(output.InconsistentLines) != 1 || output.InconsistentLines[0] != 3 {
+		t.Errorf("expected mixed endings with line 3 inconsistent, got %+v", output)
+	}
+}
+
+func TestHandleDetectLineEndings_CRAndUnicodeSeparators(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+
+	tests := []struct {
+		name             string
+		content          string
+		wantStyle        string
+		wantTotalLines   int
+		wantInconsistent []int
+		wantCounts       LineEndingCounts
+	}{
+		{
+			name:             "classic Mac",
+			content:          "object Form1: TForm1\r  Caption = 'Form1'\rend\r",
+			wantStyle:        LineEndingCR,
+			wantTotalLines:   4,
+			wantInconsistent: []int{},
+			wantCounts:       LineEndingCounts{CR: 3},
+		},
+		{
+			name:             "mostly CR with one CRLF",
+			content:          "a\rb\r\nc\rd",
+			wantStyle:        LineEndingMixed,
+			wantTotalLines:   4,
+			wantInconsistent: []int{2},
+			wantCounts:       LineEndingCounts{CRLF: 1, CR: 2},
+		},
+		{
+			name:             "Unicode separators",
+			content:          "a\nb\u2028c\u0085\u2029d\ne",
+			wantStyle:        LineEndingLF,
+			wantTotalLines:   3,
+			wantInconsistent: []int{2},
+			wantCounts:       LineEndingCounts{LF: 2, Unicode: 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			testFile := filepath.Join(tempDir, "test.txt")
+			os.WriteFile(testFile, []byte(tt.content), 0644)
+
+			result, output, _ := h.HandleDetectLineEndings(context.Background(), nil, DetectLineEndingsInput{Path: testFile})
+			if result.IsError {
+				t.Fatalf("unexpected error: %s", extractTextFromResult(result.Content))
+			}
+			if output.Style != tt.wantStyle || output.TotalLines != tt.wantTotalLines || output.Counts != tt.wantCounts {
+				t.Errorf("got style %q, %d lines, counts %+v", output.Style, output.TotalLines, output.Counts)
+			}
+			if fmt.Sprint(output.InconsistentLines) != fmt.Sprint(tt.wantInconsistent) {
+				t.Errorf("InconsistentLines = %v, want %v", output.InconsistentLines, tt.wantInconsistent)
+			}
+		})
+	}
+}
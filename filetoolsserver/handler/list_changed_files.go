@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/dimitar-grigorov/mcp-file-tools/internal/security"
+	"github.com/dimitar-grigorov/mcp-file-tools/internal/watch"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+const defaultMaxChanges = 1000
+
+// Sources of list_changed_files results
+const (
+	changeSourceWatcher = "watcher" // journal of the filesystem watcher
+	changeSourceScan    = "scan"    // modification times, for times before the watcher started
+)
+
+// HandleListChangedFiles lists files changed since a token from an earlier call
+// or a timestamp. Changes come from the watcher's journal; times before the
+// watcher started fall back to scanning modification times.
+func (h *Handler) HandleListChangedFiles(ctx context.Context, req *mcp.CallToolRequest, input ListChangedFilesInput) (*mcp.CallToolResult, ListChangedFilesOutput, error) {
+	roots := h.ResolvedAllowedDirs()
+	if len(input.Paths) > 0 {
+		roots = nil
+		for _, path := range input.Paths {
+			v := h.ValidatePath(path)
+			if !v.Ok() {
+				return v.Result, ListChangedFilesOutput{}, nil
+			}
+			roots = append(roots, v.Path)
+		}
+	}
+	maxResults := input.MaxResults
+	if maxResults <= 0 {
+		maxResults = defaultMaxChanges
+	}
+	w := h.getWatcher()
+
+	if input.Since == "" {
+		if w == nil {
+			return errorResult("watching is disabled (MCP_WATCH=false); pass an RFC 3339 timestamp as since to scan modification times"), ListChangedFilesOutput{}, nil
+		}
+		return &mcp.CallToolResult{}, ListChangedFilesOutput{
+			Changes: []FileChange{},
+			Token:   w.Token(),
+			Source:  changeSourceWatcher,
+			Message: "Pass token as since in a later call to list the changes made from now on",
+		}, nil
+	}
+
+	if since, err := time.Parse(time.RFC3339, input.Since); err == nil {
+		if w != nil {
+			if changes, token, ok := w.After(since); ok {
+				output := summarizeChanges(changes, roots, maxResults)
+				output.Token = token
+				return &mcp.CallToolResult{}, output, nil
+			}
+		}
+		output := h.scanChangedFiles(ctx, roots, since, maxResults)
+		if w != nil {
+			output.Token = w.Token()
+		}
+		return &mcp.CallToolResult{}, output, nil
+	}
+
+	if w == nil {
+		return errorResult("watching is disabled (MCP_WATCH=false); since must be an RFC 3339 timestamp"), ListChangedFilesOutput{}, nil
+	}
+	changes, token, err := w.Since(input.Since)
+	switch {
+	case errors.Is(err, watch.ErrInvalidToken):
+		return errorResult("invalid since: expected a token from a previous call or an RFC 3339 timestamp (e.g. 2024-01-02T15:04:05Z)"), ListChangedFilesOutput{}, nil
+	case errors.Is(err, watch.ErrTokenExpired):
+		return errorResult("token expired (the server restarted or too many changes happened since); pass a timestamp as since instead"), ListChangedFilesOutput{}, nil
+	case err != nil:
+		return errorResult(err.Error()), ListChangedFilesOutput{}, nil
+	}
+	output := summarizeChanges(changes, roots, maxResults)
+	output.Token = token
+	return &mcp.CallToolResult{}, output, nil
+}
+
+// summarizeChanges reduces journal entries to the net change of each path below
+// roots: a file created and then modified was created, one created and then
+// deleted did not change, and one deleted and then created was modified.
+func summarizeChanges(changes []watch.Change, roots []string, maxResults int) ListChangedFilesOutput {
+	byPath := make(map[string]*FileChange)
+	for _, c := range changes {
+		if !security.IsPathWithinAllowedDirectories(c.Path, roots) {
+			continue
+		}
+		op := c.Op
+		if prev, ok := byPath[c.Path]; ok {
+			switch {
+			case prev.Type == string(watch.Created) && op == watch.Modified:
+				op = watch.Created
+			case prev.Type == string(watch.Created) && op == watch.Deleted:
+				delete(byPath, c.Path)
+				continue
+			case prev.Type == string(watch.Deleted) && op == watch.Created:
+				op = watch.Modified
+			}
+		}
+		byPath[c.Path] = &FileChange{Path: c.Path, Type: string(op), Time: c.Time.Format(time.RFC3339Nano)}
+	}
+	output := ListChangedFilesOutput{Changes: make([]FileChange, 0, len(byPath)), Source: changeSourceWatcher}
+	for _, change := range byPath {
+		output.Changes = append(output.Changes, *change)
+	}
+	sortAndTruncateChanges(&output, maxResults)
+	return output
+}
+
+// scanChangedFiles lists files below roots modified after since. Deletions
+// cannot be found this way.
+func (h *Handler) scanChangedFiles(ctx context.Context, roots []string, since time.Time, maxResults int) ListChangedFilesOutput {
+	output := ListChangedFilesOutput{
+		Changes: []FileChange{},
+		Source:  changeSourceScan,
+		Message: "Found by modification time because the watcher was not running at that time; deleted files are not listed",
+	}
+	allowedDirs := h.ResolvedAllowedDirs()
+	seen := make(map[string]bool)
+	for _, root := range roots {
+		ignores := h.ignoreMatcher(root, true)
+		filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err != nil {
+				return nil
+			}
+			if d.IsDir() {
+				if p != root && (ignores.Ignored(p, true) || !security.IsPathSafeResolved(p, allowedDirs)) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if seen[p] || ignores.Ignored(p, false) {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil || !info.ModTime().After(since) {
+				return nil
+			}
+			seen[p] = true
+			output.Changes = append(output.Changes, FileChange{
+				Path: p,
+				Type: string(watch.Modified),
+				Time: info.ModTime().Format(time.RFC3339Nano),
+			})
+			return nil
+		})
+	}
+	sortAndTruncateChanges(&output, maxResults)
+	return output
+}
+
+// sortAndTruncateChanges orders changes by path and applies the result limit.
+func sortAndTruncateChanges(output *ListChangedFilesOutput, maxResults int) {
+	sort.Slice(output.Changes, func(i, j int) bool { return output.Changes[i].Path < output.Changes[j].Path })
+	if len(output.Changes) > maxResults {
+		output.Changes = output.Changes[:maxResults]
+		output.Truncated = true
+	}
+}
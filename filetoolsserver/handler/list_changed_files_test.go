@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dimitar-grigorov/mcp-file-tools/internal/config"
+	"github.com/dimitar-grigorov/mcp-file-tools/internal/watch"
+)
+
+func TestHandleListChangedFiles_Watcher(t *testing.T) {
+	tempDir := t.TempDir()
+	existing := filepath.Join(tempDir, "existing.pas")
+	os.WriteFile(existing, []byte("unit A;\n"), 0644)
+	h := NewHandler([]string{tempDir})
+	changes := make(chan watch.Change, 100)
+	if err := h.StartWatching(func(c watch.Change) { changes <- c }); err != nil {
+		t.Fatal(err)
+	}
+	defer h.StopWatching()
+
+	result, output, _ := h.HandleListChangedFiles(context.Background(), nil, ListChangedFilesInput{})
+	if result.IsError || output.Token == "" || len(output.Changes) != 0 {
+		t.Fatalf("expected only a token, got %+v", output)
+	}
+	token := output.Token
+
+	created := filepath.Join(tempDir, "new.pas")
+	temporary := filepath.Join(tempDir, "tmp.txt")
+	os.WriteFile(temporary, []byte("x"), 0644)
+	os.Remove(temporary)
+	os.WriteFile(created, []byte("unit B;\n"), 0644)
+	os.WriteFile(existing, []byte("unit A; // changed\n"), 0644)
+	// Wait until the watcher has seen the last changes
+	deadline := time.After(10 * time.Second)
+	for sawCreated, sawModified := false, false; !sawCreated || !sawModified; {
+		select {
+		case c := <-changes:
+			sawCreated = sawCreated || c.Path == created
+			sawModified = sawModified || (c.Path == existing && c.Op == watch.Modified)
+		case <-deadline:
+			t.Fatal("timed out waiting for changes")
+		}
+	}
+
+	result, output, _ = h.HandleListChangedFiles(context.Background(), nil, ListChangedFilesInput{Since: token})
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", extractTextFromResult(result.Content))
+	}
+	// The temporary file was created and deleted, so it did not change
+	if len(output.Changes) != 2 || output.Source != "watcher" {
+		t.Fatalf("expected 2 changes from the watcher, got %+v", output)
+	}
+	if output.Changes[0].Path != existing || output.Changes[0].Type != "modified" {
+		t.Errorf("unexpected change %+v", output.Changes[0])
+	}
+	if output.Changes[1].Path != created || output.Changes[1].Type != "created" {
+		t.Errorf("unexpected change %+v", output.Changes[1])
+	}
+
+	_, output, _ = h.HandleListChangedFiles(context.Background(), nil, ListChangedFilesInput{Since: output.Token})
+	if len(output.Changes) != 0 {
+		t.Errorf("expected no changes since the latest token, got %+v", output.Changes)
+	}
+
+	for _, since := range []string{"not a token", "1-1"} {
+		result, _, _ = h.HandleListChangedFiles(context.Background(), nil, ListChangedFilesInput{Since: since})
+		if !result.IsError {
+			t.Errorf("expected error for since %q", since)
+		}
+	}
+}
+
+func TestHandleListChangedFiles_Scan(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir}, WithConfig(&config.Config{MemoryThreshold: config.DefaultMaxSize}))
+
+	old := filepath.Join(tempDir, "old.txt")
+	recent := filepath.Join(tempDir, "sub", "recent.txt")
+	ignored := filepath.Join(tempDir, "build", "out.txt")
+	for _, path := range []string{old, recent, ignored} {
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte("x"), 0644)
+	}
+	os.WriteFile(filepath.Join(tempDir, ".gitignore"), []byte("build/\n"), 0644)
+	since := time.Now().Add(-time.Hour)
+	os.Chtimes(old, since.Add(-time.Hour), since.Add(-time.Hour))
+
+	// Without a watcher, timestamps are answered by modification times
+	result, output, _ := h.HandleListChangedFiles(context.Background(), nil, ListChangedFilesInput{Since: since.Format(time.RFC3339)})
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", extractTextFromResult(result.Content))
+	}
+	if output.Source != "scan" || output.Token != "" {
+		t.Errorf("unexpected source %q or token %q", output.Source, output.Token)
+	}
+	var paths []string
+	for _, c := range output.Changes {
+		paths = append(paths, c.Path)
+	}
+	if len(paths) != 2 || paths[0] != filepath.Join(tempDir, ".gitignore") || paths[1] != recent {
+		t.Errorf("expected .gitignore and sub/recent.txt, got %v", paths)
+	}
+
+	_, output, _ = h.HandleListChangedFiles(context.Background(), nil, ListChangedFilesInput{
+		Since:      since.Format(time.RFC3339),
+		Paths:      []string{filepath.Join(tempDir, "sub")},
+		MaxResults: 1,
+	})
+	if len(output.Changes) != 1 || output.Changes[0].Path != recent {
+		t.Errorf("expected only sub/recent.txt, got %+v", output.Changes)
+	}
+
+	// Tokens need the watcher
+	result, _, _ = h.HandleListChangedFiles(context.Background(), nil, ListChangedFilesInput{})
+	if !result.IsError {
+		t.Error("expected error without a watcher")
+	}
+}
@@ -1,14 +1,33 @@
 package handler
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
-// HandleMoveFile moves or renames a file or directory.
+// Strategies reported by move_file
+const (
+	moveStrategyRename = "rename"
+	moveStrategyCopy   = "copy"
+)
+
+// errorNotSameDevice is ERROR_NOT_SAME_DEVICE, the Windows counterpart of EXDEV.
+const errorNotSameDevice = syscall.Errno(17)
+
+// HandleMoveFile moves or renames a file or directory. When source and
+// destination are on different file systems, where a rename is impossible, it
+// copies and verifies the data and then deletes the source.
 func (h *Handler) HandleMoveFile(ctx context.Context, req *mcp.CallToolRequest, input MoveFileInput) (*mcp.CallToolResult, MoveFileOutput, error) {
 	src, dst := h.ValidateSourceDest(input.Source, input.Destination)
 	if !src.Ok() {
@@ -18,20 +37,171 @@ func (h *Handler) HandleMoveFile(ctx context.Context, req *mcp.CallToolRequest,
 		return dst.Result, MoveFileOutput{}, nil
 	}
 
-	// Check if source exists
-	if _, err := os.Stat(src.Path); os.IsNotExist(err) {
+	policy, err := parseOverwritePolicy(input.Overwrite)
+	if err != nil {
+		return errorResult(err.Error()), MoveFileOutput{}, nil
+	}
+
+	srcInfo, err := os.Stat(src.Path)
+	if os.IsNotExist(err) {
 		return errorResult(fmt.Sprintf("source does not exist: %s", input.Source)), MoveFileOutput{}, nil
 	}
+	if err != nil {
+		return errorResult(fmt.Sprintf("failed to access source: %v", err)), MoveFileOutput{}, nil
+	}
 
-	// Check if destination already exists
-	if _, err := os.Stat(dst.Path); err == nil {
-		return errorResult(fmt.Sprintf("destination already exists: %s", input.Destination)), MoveFileOutput{}, nil
+	dest, err := resolveDestination(src.Path, srcInfo, dst.Path, policy)
+	if err != nil {
+		return errorResult(err.Error()), MoveFileOutput{}, nil
+	}
+	if dest.skip != "" {
+		message := fmt.Sprintf("Nothing moved: %s (%s)", dest.skip, dest.path)
+		return &mcp.CallToolResult{}, MoveFileOutput{Message: message, Destination: dest.path, Skipped: true}, nil
 	}
 
-	if err := os.Rename(src.Path, dst.Path); err != nil {
+	strategy := moveStrategyRename
+	err = dest.write(func() error {
+		err := os.Rename(src.Path, dest.path)
+		if err == nil || !isCrossDevice(err) {
+			return err
+		}
+		strategy = moveStrategyCopy
+		if err := moveAcrossDevices(ctx, src.Path, dest.path); err != nil {
+			return fmt.Errorf("across file systems: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
 		return errorResult(fmt.Sprintf("failed to move file: %v", err)), MoveFileOutput{}, nil
 	}
 
-	message := fmt.Sprintf("Successfully moved %s to %s", input.Source, input.Destination)
-	return &mcp.CallToolResult{}, MoveFileOutput{Message: message}, nil
+	message := fmt.Sprintf("Successfully moved %s to %s", input.Source, dest.path)
+	if strategy == moveStrategyCopy {
+		message += " (copied across file systems, then deleted the source)"
+	}
+	if dest.backup != "" {
+		message += fmt.Sprintf(", existing file backed up to %s", dest.backup)
+	}
+	output := MoveFileOutput{Message: message, Strategy: strategy, Destination: dest.path, BackupPath: dest.backup}
+	return &mcp.CallToolResult{}, output, nil
+}
+
+// isCrossDevice reports whether a rename failed because source and destination
+// are on different file systems, e.g. a Docker volume and the container.
+func isCrossDevice(err error) bool {
+	var errno syscall.Errno
+	if !errors.As(err, &errno) {
+		return false
+	}
+	return errno == syscall.EXDEV || (runtime.GOOS == "windows" && errno == errorNotSameDevice)
+}
+
+// moveAcrossDevices copies a file or directory tree with its modes and
+// modification times, verifies every copied file against its source and only
+// then deletes the source. The copy is made next to the destination and
+// renamed into place, so a failed copy leaves neither a partial destination
+// nor touches the source, and an overwritten file is replaced atomically.
+func moveAcrossDevices(ctx context.Context, src, dst string) error {
+	tempPath, err := generateTempPath(dst)
+	if err != nil {
+		return err
+	}
+	if err := copyTreeVerified(ctx, src, tempPath); err != nil {
+		os.RemoveAll(tempPath)
+		return err
+	}
+	if err := os.Rename(tempPath, dst); err != nil {
+		os.RemoveAll(tempPath)
+		return err
+	}
+	// The copy is complete, so a source that cannot be fully deleted leaves
+	// both behind rather than losing data
+	if err := os.RemoveAll(src); err != nil {
+		return fmt.Errorf("copied to %s but failed to delete the source: %w", dst, err)
+	}
+	return nil
+}
+
+// copyTreeVerified copies a file or directory tree, recreating symbolic links
+// as links, and checks each copied file against its source.
+func copyTreeVerified(ctx context.Context, src, dst string) error {
+	// Directories stay writable until their contents are in; their own mode
+	// and time are set last, as adding entries changes both
+	var dirs []copyEntry
+	err := filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			dirs = append(dirs, copyEntry{src: p, dst: target, mode: info.Mode().Perm(), modTime: info.ModTime()})
+			return os.Mkdir(target, info.Mode().Perm()|0o700)
+		case d.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(p)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case d.Type().IsRegular():
+			if err := copyFile(p, target, info.Mode().Perm(), info.ModTime()); err != nil {
+				return err
+			}
+			return verifyCopy(p, target)
+		default:
+			return fmt.Errorf("cannot move %s: not a regular file", p)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err := os.Chmod(dirs[i].dst, dirs[i].mode); err != nil {
+			return err
+		}
+		if err := os.Chtimes(dirs[i].dst, dirs[i].modTime, dirs[i].modTime); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyCopy compares the SHA-256 of a copied file with its source.
+func verifyCopy(src, dst string) error {
+	srcSum, err := fileSHA256(src)
+	if err != nil {
+		return err
+	}
+	dstSum, err := fileSHA256(dst)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(srcSum, dstSum) {
+		return fmt.Errorf("copy of %s does not match the source", src)
+	}
+	return nil
+}
+
+func fileSHA256(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return nil, err
+	}
+	return hash.Sum(nil), nil
 }
@@ -2,10 +2,14 @@ package handler
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"syscall"
 	"testing"
+	"time"
 )
 
 func TestHandleMoveFile_MoveToNewLocation(t *testing.T) {
@@ -35,6 +39,9 @@ func TestHandleMoveFile_MoveToNewLocation(t *testing.T) {
 	if !strings.Contains(output.Message, "Successfully moved") {
 		t.Errorf("expected success message, got %q", output.Message)
 	}
+	if output.Strategy != "rename" {
+		t.Errorf("expected rename strategy, got %q", output.Strategy)
+	}
 
 	// Verify source no longer exists
 	if _, err := os.Stat(srcFile); !os.IsNotExist(err) {
@@ -277,3 +284,159 @@ func TestHandleMoveFile_SourceNotExists(t *testing.T) {
 		t.Errorf("expected error for non-existent source")
 	}
 }
+
+func TestIsCrossDevice(t *testing.T) {
+	exdev := &os.LinkError{Op: "rename", Old: "a", New: "b", Err: syscall.EXDEV}
+	if !isCrossDevice(exdev) {
+		t.Error("expected EXDEV to be a cross-device error")
+	}
+	if !isCrossDevice(fmt.Errorf("wrapped: %w", exdev)) {
+		t.Error("expected wrapped EXDEV to be a cross-device error")
+	}
+	if isCrossDevice(&os.LinkError{Op: "rename", Old: "a", New: "b", Err: syscall.ENOENT}) {
+		t.Error("ENOENT is not a cross-device error")
+	}
+}
+
+// The fallback is tested on one file system; only the rename failure differs
+func TestMoveAcrossDevices_Directory(t *testing.T) {
+	tempDir := t.TempDir()
+	src := filepath.Join(tempDir, "src")
+	dst := filepath.Join(tempDir, "dst")
+	os.MkdirAll(filepath.Join(src, "sub"), 0750)
+	os.WriteFile(filepath.Join(src, "a.txt"), []byte("a"), 0600)
+	os.WriteFile(filepath.Join(src, "sub", "b.txt"), []byte("b"), 0644)
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	os.Chtimes(filepath.Join(src, "a.txt"), modTime, modTime)
+	os.Chtimes(filepath.Join(src, "sub"), modTime, modTime)
+	hasSymlink := os.Symlink("a.txt", filepath.Join(src, "link")) == nil
+
+	if err := moveAcrossDevices(context.Background(), src, dst); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Error("source should not exist after move")
+	}
+	if data, _ := os.ReadFile(filepath.Join(dst, "sub", "b.txt")); string(data) != "b" {
+		t.Errorf("unexpected content %q", data)
+	}
+	info, err := os.Stat(filepath.Join(dst, "a.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.ModTime().Equal(modTime) {
+		t.Errorf("modification time not preserved: %v", info.ModTime())
+	}
+	if info, _ := os.Stat(filepath.Join(dst, "sub")); !info.ModTime().Equal(modTime) {
+		t.Errorf("directory modification time not preserved: %v", info.ModTime())
+	}
+	if runtime.GOOS != "windows" {
+		if info.Mode().Perm() != 0600 {
+			t.Errorf("mode not preserved: %v", info.Mode().Perm())
+		}
+		if info, _ := os.Stat(filepath.Join(dst, "sub")); info.Mode().Perm() != 0750 {
+			t.Errorf("directory mode not preserved: %v", info.Mode().Perm())
+		}
+	}
+	if hasSymlink {
+		if link, err := os.Readlink(filepath.Join(dst, "link")); err != nil || link != "a.txt" {
+			t.Errorf("symbolic link not moved as a link: %q, %v", link, err)
+		}
+	}
+}
+
+func TestMoveAcrossDevices_File(t *testing.T) {
+	tempDir := t.TempDir()
+	src := filepath.Join(tempDir, "a.txt")
+	dst := filepath.Join(tempDir, "sub", "a.txt")
+	os.WriteFile(src, []byte("content"), 0644)
+
+	// A failed copy leaves the source alone and no partial destination
+	if err := moveAcrossDevices(context.Background(), src, dst); err == nil {
+		t.Fatal("expected error for missing destination directory")
+	}
+	if _, err := os.Stat(src); err != nil {
+		t.Fatal("source should be kept after a failed copy")
+	}
+
+	os.Mkdir(filepath.Dir(dst), 0755)
+	if err := moveAcrossDevices(context.Background(), src, dst); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Error("source should not exist after move")
+	}
+	if data, _ := os.ReadFile(dst); string(data) != "content" {
+		t.Errorf("unexpected content %q", data)
+	}
+}
+
+func TestHandleMoveFile_OverwritePolicies(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+	dst := filepath.Join(tempDir, "dest.txt")
+	os.WriteFile(dst, []byte("existing"), 0644)
+
+	src := filepath.Join(tempDir, "a.txt")
+	os.WriteFile(src, []byte("a"), 0644)
+	_, output, _ := h.HandleMoveFile(context.Background(), nil, MoveFileInput{Source: src, Destination: dst, Overwrite: "rename-with-suffix"})
+	if output.Destination != filepath.Join(tempDir, "dest_1.txt") {
+		t.Errorf("expected a suffixed destination, got %+v", output)
+	}
+
+	src = filepath.Join(tempDir, "b.txt")
+	os.WriteFile(src, []byte("b"), 0644)
+	_, output, _ = h.HandleMoveFile(context.Background(), nil, MoveFileInput{Source: src, Destination: dst, Overwrite: "backup-existing"})
+	if data, _ := os.ReadFile(dst + ".bak"); string(data) != "existing" || output.BackupPath != dst+".bak" {
+		t.Errorf("existing file not backed up: %q, %+v", data, output)
+	}
+
+	// An older backup is kept
+	src = filepath.Join(tempDir, "b2.txt")
+	os.WriteFile(src, []byte("b2"), 0644)
+	_, output, _ = h.HandleMoveFile(context.Background(), nil, MoveFileInput{Source: src, Destination: dst, Overwrite: "backup-existing"})
+	if data, _ := os.ReadFile(dst + ".bak"); string(data) != "existing" {
+		t.Errorf("older backup was replaced: %q", data)
+	}
+	if data, _ := os.ReadFile(output.BackupPath); output.BackupPath != filepath.Join(tempDir, "dest.txt_1.bak") || string(data) != "b" {
+		t.Errorf("expected a second backup, got %q, %+v", data, output)
+	}
+
+	src = filepath.Join(tempDir, "c.txt")
+	os.WriteFile(src, []byte("c"), 0644)
+	result, _, _ := h.HandleMoveFile(context.Background(), nil, MoveFileInput{Source: src, Destination: dst, Overwrite: "overwrite"})
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", extractTextFromResult(result.Content))
+	}
+	if data, _ := os.ReadFile(dst); string(data) != "c" {
+		t.Errorf("expected destination overwritten, got %q", data)
+	}
+	if _, err := os.Stat(src); !os.IsNotExist(err) {
+		t.Error("source should not exist after move")
+	}
+}
+
+func TestHandleMoveFile_DirectoryIntoDirectory(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+	srcDir := filepath.Join(tempDir, "forms")
+	destDir := filepath.Join(tempDir, "archive")
+	os.Mkdir(srcDir, 0755)
+	os.Mkdir(destDir, 0755)
+	os.WriteFile(filepath.Join(srcDir, "main.dfm"), []byte("object Main"), 0644)
+
+	result, _, _ := h.HandleMoveFile(context.Background(), nil, MoveFileInput{Source: srcDir, Destination: destDir})
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", extractTextFromResult(result.Content))
+	}
+	if data, _ := os.ReadFile(filepath.Join(destDir, "forms", "main.dfm")); string(data) != "object Main" {
+		t.Errorf("directory not moved into the destination: %q", data)
+	}
+
+	// Directories are never overwritten
+	os.Mkdir(srcDir, 0755)
+	result, _, _ = h.HandleMoveFile(context.Background(), nil, MoveFileInput{Source: srcDir, Destination: destDir, Overwrite: "overwrite"})
+	if !result.IsError {
+		t.Error("expected error when overwriting a directory")
+	}
+}
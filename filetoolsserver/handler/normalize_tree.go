@@ -0,0 +1,332 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/dimitar-grigorov/mcp-file-tools/internal/encoding"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// normalizeProfile is the validated target of normalize_tree.
+type normalizeProfile struct {
+	from         string // source encoding of non-UTF-8 files, empty to detect
+	encoding     string // empty keeps each file's encoding
+	bom          *bool
+	lineEndings  string // empty keeps each file's line endings
+	trim         bool
+	finalNewline *bool
+}
+
+// normalizeResult is the outcome of preparing one file.
+type normalizeResult struct {
+	write      *pendingWrite
+	file       NormalizeFileResult
+	skipReason string
+}
+
+// HandleNormalizeTree rewrites every matching file to a target profile of
+// encoding, BOM, line endings, trailing whitespace and final newline. Files are
+// prepared concurrently; nothing is written until all of them are, and writes
+// that fail midway are rolled back.
+func (h *Handler) HandleNormalizeTree(ctx context.Context, req *mcp.CallToolRequest, input NormalizeTreeInput) (*mcp.CallToolResult, NormalizeTreeOutput, error) {
+	if len(input.Paths) == 0 {
+		return errorResult("paths is required"), NormalizeTreeOutput{}, nil
+	}
+	profile, err := newNormalizeProfile(input)
+	if err != nil {
+		return errorResult(err.Error()), NormalizeTreeOutput{}, nil
+	}
+	filter, err := newFileFilter(input.Include, input.Exclude)
+	if err != nil {
+		return errorResult(err.Error()), NormalizeTreeOutput{}, nil
+	}
+	filter.respectIgnoreFiles = input.RespectIgnoreFiles == nil || *input.RespectIgnoreFiles // default: true
+	forceWritable := input.ForceWritable != nil && *input.ForceWritable                      // default: false
+
+	files := h.collectFiles(ctx, input.Paths, filter)
+	output := NormalizeTreeOutput{Files: []NormalizeFileResult{}, FilesScanned: len(files)}
+	if len(files) == 0 {
+		output.Message = "No files to normalize"
+		return &mcp.CallToolResult{}, output, nil
+	}
+
+	results := h.prepareNormalizeAll(ctx, files, profile, forceWritable)
+	if ctx.Err() != nil {
+		return errorResult("normalize cancelled, no files were changed"), NormalizeTreeOutput{}, nil
+	}
+	var writes []pendingWrite
+	for i, result := range results {
+		switch {
+		case result.skipReason != "":
+			output.Skipped = append(output.Skipped, NormalizeSkippedFile{Path: files[i], Reason: result.skipReason})
+		case result.write != nil:
+			writes = append(writes, *result.write)
+			output.Files = append(output.Files, result.file)
+		}
+	}
+	output.FilesChanged = len(writes)
+
+	if len(writes) == 0 {
+		output.Message = fmt.Sprintf("All %d files already match the profile", len(files)-len(output.Skipped))
+		return &mcp.CallToolResult{}, output, nil
+	}
+
+	dryRun := input.DryRun == nil || *input.DryRun // default: true
+	if dryRun {
+		output.Message = fmt.Sprintf("Would normalize %d of %d files. Review the changes and call again with dryRun=false to apply.",
+			output.FilesChanged, output.FilesScanned)
+		return &mcp.CallToolResult{}, output, nil
+	}
+
+	if err := applyWrites(writes); err != nil {
+		return errorResult(err.Error()), NormalizeTreeOutput{}, nil
+	}
+	output.Applied = true
+	output.Message = fmt.Sprintf("Normalized %d of %d files", output.FilesChanged, output.FilesScanned)
+	// Writes line up with output.Files; forceWritable files stay writable afterwards
+	cleared := 0
+	for i, w := range writes {
+		if isReadOnly(w.mode) {
+			output.Files[i].ReadOnlyCleared = true
+			cleared++
+		}
+	}
+	if cleared > 0 {
+		output.Message += fmt.Sprintf(" (read-only flag cleared on %d files)", cleared)
+	}
+	return &mcp.CallToolResult{}, output, nil
+}
+
+// newNormalizeProfile validates the profile fields of the input.
+func newNormalizeProfile(input NormalizeTreeInput) (normalizeProfile, error) {
+	profile := normalizeProfile{
+		from:         strings.ToLower(input.From),
+		encoding:     strings.ToLower(input.Encoding),
+		bom:          input.BOM,
+		lineEndings:  strings.ToLower(input.LineEndings),
+		trim:         input.TrimTrailingWhitespace,
+		finalNewline: input.FinalNewline,
+	}
+	if profile.encoding != "" {
+		name, ok := encoding.CanonicalName(profile.encoding)
+		if !ok {
+			return profile, fmt.Errorf("%w: %s. Use list_encodings to see available encodings", ErrEncodingUnsupported, profile.encoding)
+		}
+		profile.encoding = name
+		if profile.bom != nil && *profile.bom && !hasBOM(profile.encoding) {
+			return profile, fmt.Errorf("%s has no BOM; bom=true needs a Unicode encoding", profile.encoding)
+		}
+	}
+	if profile.from != "" {
+		if _, ok := encoding.Get(profile.from); !ok {
+			return profile, fmt.Errorf("%w: %s. Use list_encodings to see available encodings", ErrEncodingUnsupported, profile.from)
+		}
+	}
+	switch profile.lineEndings {
+	case "", LineEndingLF, LineEndingCRLF, LineEndingCR:
+	default:
+		return profile, fmt.Errorf("lineEndings must be \"lf\", \"crlf\" or \"cr\"")
+	}
+	if profile.encoding == "" && profile.bom == nil && profile.lineEndings == "" && !profile.trim && profile.finalNewline == nil {
+		return profile, fmt.Errorf("nothing to normalize: set at least one of encoding, bom, lineEndings, trimTrailingWhitespace or finalNewline")
+	}
+	return profile, nil
+}
+
+// hasBOM reports whether the canonically named charset is a Unicode encoding
+// with a BOM.
+func hasBOM(charset string) bool {
+	return encoding.BOMBytesFor(charset) != nil
+}
+
+// prepareNormalizeAll prepares files concurrently. Results are in file order.
+func (h *Handler) prepareNormalizeAll(ctx context.Context, files []string, profile normalizeProfile, forceWritable bool) []normalizeResult {
+	results := make([]normalizeResult, len(files))
+	numWorkers := runtime.NumCPU()
+	if numWorkers > len(files) {
+		numWorkers = len(files)
+	}
+	jobs := make(chan int, len(files))
+	var wg sync.WaitGroup
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				if ctx.Err() != nil {
+					continue
+				}
+				results[idx] = h.prepareNormalize(files[idx], profile, forceWritable)
+			}
+		}()
+	}
+	for i := range files {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// prepareNormalize reads a file and computes its normalized bytes. The write is
+// nil when the file already matches the profile.
+func (h *Handler) prepareNormalize(path string, profile normalizeProfile, forceWritable bool) normalizeResult {
+	if loadToMemory, _ := h.shouldLoadEntireFile(path); !loadToMemory {
+		return normalizeResult{skipReason: "file too large"}
+	}
+	mode := getFileMode(path)
+	if isReadOnly(mode) && !forceWritable {
+		return normalizeResult{skipReason: "read-only (set forceWritable to modify)"}
+	}
+	original, err := os.ReadFile(path)
+	if err != nil {
+		return normalizeResult{skipReason: fmt.Sprintf("failed to read file: %v", err)}
+	}
+	// UTF-16 text is full of NUL bytes, but its BOM tells it apart from binary
+	if _, bom := encoding.DetectBOM(original); !bom && isBinaryFile(original) {
+		return normalizeResult{}
+	}
+
+	content, sourceEncoding, err := h.decodeForRewrite(original, profile.from, path)
+	if err != nil {
+		return normalizeResult{skipReason: err.Error()}
+	}
+
+	file := NormalizeFileResult{Path: path, Encoding: sourceEncoding, Changes: []string{}}
+	// Unicode decoders keep the BOM as a leading U+FEFF
+	hadBOM := strings.HasPrefix(content, "\ufeff")
+	content = strings.TrimPrefix(content, "\ufeff")
+
+	info := DetectLineEndings([]byte(content))
+	if profile.lineEndings != "" && info.Style != profile.lineEndings && info.Style != LineEndingNone {
+		content = ConvertLineEndings(content, profile.lineEndings)
+		file.Changes = append(file.Changes, fmt.Sprintf("line endings %s -> %s", info.Style, profile.lineEndings))
+	}
+	if profile.trim {
+		var trimmed int
+		content, trimmed = trimTrailingWhitespace(content)
+		if trimmed > 0 {
+			file.Changes = append(file.Changes, fmt.Sprintf("trailing whitespace on %d lines", trimmed))
+		}
+	}
+	if profile.finalNewline != nil && content != "" {
+		endsWithNewline := strings.HasSuffix(content, "\n") || strings.HasSuffix(content, "\r")
+		switch {
+		case *profile.finalNewline && !endsWithNewline:
+			content += finalLineEnding(profile.lineEndings, info)
+			file.Changes = append(file.Changes, "final newline added")
+		case !*profile.finalNewline && endsWithNewline:
+			content = trimLineEnding(content)
+			file.Changes = append(file.Changes, "final newline removed")
+		}
+	}
+
+	targetEncoding := sourceEncoding
+	if profile.encoding != "" && profile.encoding != sourceEncoding {
+		targetEncoding = profile.encoding
+		file.Changes = append(file.Changes, fmt.Sprintf("encoding %s -> %s", sourceEncoding, targetEncoding))
+	}
+	data, err := encodeContent(content, targetEncoding)
+	if err != nil {
+		return normalizeResult{skipReason: err.Error()}
+	}
+
+	// A BOM is kept unless the profile says otherwise or the target has none
+	withBOM := hadBOM && hasBOM(targetEncoding)
+	if profile.bom != nil && hasBOM(targetEncoding) {
+		withBOM = *profile.bom
+	}
+	if withBOM {
+		data = append(encoding.BOMBytesFor(targetEncoding), data...)
+	}
+	switch {
+	case withBOM && !hadBOM:
+		file.Changes = append(file.Changes, "BOM added")
+	case !withBOM && hadBOM:
+		file.Changes = append(file.Changes, "BOM removed")
+	}
+
+	if bytes.Equal(data, original) {
+		return normalizeResult{}
+	}
+	return normalizeResult{
+		write: &pendingWrite{path: path, mode: mode, data: data, original: original},
+		file:  file,
+	}
+}
+
+// decodeForRewrite decodes file content to UTF-8 before it is written back in
+// another form, and returns it with the canonical name of its encoding. A BOM is
+// kept as a leading U+FEFF. Content that does not decode cleanly is refused.
+func (h *Handler) decodeForRewrite(original []byte, from, path string) (string, string, error) {
+	// Detection often takes short UTF-8 text for a legacy code page, which
+	// converting would garble; legacy text is rarely valid UTF-8. A UTF-16 BOM
+	// wins over the declared source encoding.
+	sourceEncoding := "utf-8"
+	if !utf8.Valid(original) {
+		if _, ok := encoding.DetectBOM(original); ok {
+			from = ""
+		}
+		detected, err := h.resolveEncodingFromData(from, original, path)
+		if err != nil {
+			return "", "", err
+		}
+		sourceEncoding, _ = encoding.CanonicalName(detected) // validated by resolveEncodingFromData
+	}
+	content := string(original)
+	if !encoding.IsUTF8(sourceEncoding) {
+		enc, _ := encoding.Get(sourceEncoding)
+		decoded, err := enc.NewDecoder().Bytes(original)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to decode %s content: %w", sourceEncoding, err)
+		}
+		content = string(decoded)
+	}
+	// Re-encoding replacement characters would corrupt the undecodable bytes
+	if invalid := encoding.FindInvalidSequences(content, sourceEncoding); invalid.Count > 0 {
+		return "", "", fmt.Errorf("%d invalid byte sequences in %s", invalid.Count, sourceEncoding)
+	}
+	return content, sourceEncoding, nil
+}
+
+// trimTrailingWhitespace removes spaces and tabs before each line ending and at
+// the end of text. Returns the new text and the number of lines trimmed.
+func trimTrailingWhitespace(text string) (string, int) {
+	var sb strings.Builder
+	sb.Grow(len(text))
+	trimmed := 0
+	for _, line := range splitLines(text) {
+		body := trimLineEnding(line)
+		kept := strings.TrimRight(body, " \t")
+		if len(kept) != len(body) {
+			trimmed++
+		}
+		sb.WriteString(kept)
+		sb.WriteString(line[len(body):])
+	}
+	return sb.String(), trimmed
+}
+
+// finalLineEnding returns the line ending to add at the end of a file: the
+// target style, else the file's dominant style, else LF.
+func finalLineEnding(target string, info LineEndingInfo) string {
+	style := target
+	if style == "" && info.Style != LineEndingNone {
+		style = dominantStyle(info.CRLFCount, info.LFCount, info.CRCount)
+	}
+	switch style {
+	case LineEndingCRLF:
+		return "\r\n"
+	case LineEndingCR:
+		return "\r"
+	default:
+		return "\n"
+	}
+}
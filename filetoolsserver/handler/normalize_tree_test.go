@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+func TestHandleNormalizeTree(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+
+	legacy := "unit Main;  \r\n// Главная форма\t\r\nbegin\r\nend."
+	encoded, _ := charmap.Windows1251.NewEncoder().String(legacy)
+	cp1251File := filepath.Join(tempDir, "src", "main.pas")
+	bomFile := filepath.Join(tempDir, "src", "bom.pas")
+	cleanFile := filepath.Join(tempDir, "src", "clean.pas")
+	ignoredFile := filepath.Join(tempDir, "notes.txt")
+	os.MkdirAll(filepath.Dir(cp1251File), 0755)
+	os.WriteFile(cp1251File, []byte(encoded), 0644)
+	os.WriteFile(bomFile, []byte("\xef\xbb\xbfunit Bom;\r\n"), 0644)
+	os.WriteFile(cleanFile, []byte("unit Clean;\n"), 0644)
+	os.WriteFile(ignoredFile, []byte("notes  \r\n"), 0644)
+
+	bom, finalNewline := false, true
+	input := NormalizeTreeInput{
+		Paths:                  []string{tempDir},
+		Include:                GlobList{"*.pas"},
+		From:                   "cp1251",
+		Encoding:               "utf8",
+		BOM:                    &bom,
+		LineEndings:            "LF",
+		TrimTrailingWhitespace: true,
+		FinalNewline:           &finalNewline,
+	}
+
+	// Dry run is the default
+	result, output, _ := h.HandleNormalizeTree(context.Background(), nil, input)
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", extractTextFromResult(result.Content))
+	}
+	if output.Applied || output.FilesScanned != 3 || output.FilesChanged != 2 || len(output.Files) != 2 {
+		t.Fatalf("unexpected dry run output: %+v", output)
+	}
+	if got := output.Files[0]; got.Path != bomFile || strings.Join(got.Changes, "; ") != "line endings crlf -> lf; BOM removed" {
+		t.Errorf("unexpected result %+v", got)
+	}
+	want := "line endings crlf -> lf; trailing whitespace on 2 lines; final newline added; encoding windows-1251 -> utf-8"
+	if got := output.Files[1]; got.Path != cp1251File || got.Encoding != "windows-1251" || strings.Join(got.Changes, "; ") != want {
+		t.Errorf("unexpected result %+v", got)
+	}
+	if data, _ := os.ReadFile(cp1251File); string(data) != encoded {
+		t.Error("dry run modified the file")
+	}
+
+	dryRun := false
+	input.DryRun = &dryRun
+	_, output, _ = h.HandleNormalizeTree(context.Background(), nil, input)
+	if !output.Applied || output.FilesChanged != 2 {
+		t.Fatalf("expected changes to be applied: %+v", output)
+	}
+	if data, _ := os.ReadFile(cp1251File); string(data) != "unit Main;\n// Главная форма\nbegin\nend.\n" {
+		t.Errorf("unexpected content %q", data)
+	}
+	if data, _ := os.ReadFile(bomFile); string(data) != "unit Bom;\n" {
+		t.Errorf("unexpected content %q", data)
+	}
+	if data, _ := os.ReadFile(ignoredFile); string(data) != "notes  \r\n" {
+		t.Error("excluded file was modified")
+	}
+
+	// Everything matches the profile now
+	_, output, _ = h.HandleNormalizeTree(context.Background(), nil, input)
+	if output.FilesChanged != 0 || output.Applied {
+		t.Errorf("expected no changes, got %+v", output)
+	}
+}
+
+func TestHandleNormalizeTree_ToLegacyEncoding(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+	convertible := filepath.Join(tempDir, "a.txt")
+	unconvertible := filepath.Join(tempDir, "b.txt")
+	os.WriteFile(convertible, []byte("Привет\r\nмир\n"), 0644)
+	os.WriteFile(unconvertible, []byte("日本語\n"), 0644)
+
+	dryRun := false
+	_, output, _ := h.HandleNormalizeTree(context.Background(), nil, NormalizeTreeInput{
+		Paths:    []string{tempDir},
+		Encoding: "cp1251",
+		DryRun:   &dryRun,
+	})
+	if output.FilesChanged != 1 || len(output.Skipped) != 1 || output.Skipped[0].Path != unconvertible {
+		t.Fatalf("expected one file converted and one skipped, got %+v", output)
+	}
+	// Line endings are left alone, mixed as they are
+	want, _ := charmap.Windows1251.NewEncoder().String("Привет\r\nмир\n")
+	if data, _ := os.ReadFile(convertible); string(data) != want {
+		t.Errorf("unexpected content % x", data)
+	}
+	if data, _ := os.ReadFile(unconvertible); string(data) != "日本語\n" {
+		t.Error("unconvertible file was modified")
+	}
+}
+
+func TestHandleNormalizeTree_UTF16AndFinalNewline(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+	regFile := filepath.Join(tempDir, "settings.reg")
+	os.WriteFile(regFile, encodeUTF16(t, "utf-16-le", "REGEDIT4\r\n\r\n"), 0644)
+
+	// The BOM and the CRLF style are kept while removing the final newline;
+	// the blank line before it stays
+	dryRun, finalNewline := false, false
+	_, output, _ := h.HandleNormalizeTree(context.Background(), nil, NormalizeTreeInput{
+		Paths:        []string{regFile},
+		FinalNewline: &finalNewline,
+		DryRun:       &dryRun,
+	})
+	if output.FilesChanged != 1 {
+		t.Fatalf("expected one change, got %+v", output)
+	}
+	if data, _ := os.ReadFile(regFile); string(data) != string(encodeUTF16(t, "utf-16-le", "REGEDIT4\r\n")) {
+		t.Errorf("unexpected content % x", data)
+	}
+}
+
+func TestHandleNormalizeTree_ForceWritableReportsClearedFlag(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+	readOnly := filepath.Join(tempDir, "ro.txt")
+	os.WriteFile(readOnly, []byte("a\r\n"), 0444)
+	writable := filepath.Join(tempDir, "rw.txt")
+	os.WriteFile(writable, []byte("b\r\n"), 0644)
+
+	dryRun, forceWritable := false, true
+	_, output, _ := h.HandleNormalizeTree(context.Background(), nil, NormalizeTreeInput{
+		Paths:         []string{tempDir},
+		LineEndings:   "lf",
+		DryRun:        &dryRun,
+		ForceWritable: &forceWritable,
+	})
+	if !output.Applied || len(output.Files) != 2 {
+		t.Fatalf("expected both files to be normalized: %+v", output)
+	}
+	for _, file := range output.Files {
+		if want := file.Path == readOnly; file.ReadOnlyCleared != want {
+			t.Errorf("%s: readOnlyCleared = %v, want %v", file.Path, file.ReadOnlyCleared, want)
+		}
+	}
+	if !strings.Contains(output.Message, "read-only flag cleared on 1 files") {
+		t.Errorf("message does not mention the cleared flag: %q", output.Message)
+	}
+}
+
+func TestHandleNormalizeTree_InvalidProfile(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+	bom := true
+
+	tests := []struct {
+		name  string
+		input NormalizeTreeInput
+	}{
+		{"no paths", NormalizeTreeInput{LineEndings: "lf"}},
+		{"empty profile", NormalizeTreeInput{Paths: []string{tempDir}}},
+		{"unknown encoding", NormalizeTreeInput{Paths: []string{tempDir}, Encoding: "nope"}},
+		{"BOM for legacy encoding", NormalizeTreeInput{Paths: []string{tempDir}, Encoding: "cp1251", BOM: &bom}},
+		{"invalid line endings", NormalizeTreeInput{Paths: []string{tempDir}, LineEndings: "unix"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _, _ := h.HandleNormalizeTree(context.Background(), nil, tt.input)
+			if !result.IsError {
+				t.Error("expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Overwrite policies of copy_file, move_file and copy_directory
+const (
+	overwriteFail   = "fail"
+	overwriteSkip   = "skip"
+	overwriteAlways = "overwrite"
+	overwriteNewer  = "overwrite-if-newer"
+	overwriteRename = "rename-with-suffix"
+	overwriteBackup = "backup-existing"
+)
+
+// overwritePolicyNames lists the policies for error messages.
+const overwritePolicyNames = `"fail", "skip", "overwrite", "overwrite-if-newer", "rename-with-suffix" or "backup-existing"`
+
+// destination is where copy_file, move_file or copy_directory writes once the
+// overwrite policy has been applied.
+type destination struct {
+	path   string
+	backup string // an existing file is first moved aside to this path
+	skip   string // reason nothing is written
+}
+
+// parseOverwritePolicy validates an overwrite policy. The default is to fail
+// when the destination exists.
+func parseOverwritePolicy(policy string) (string, error) {
+	policy = strings.ToLower(policy)
+	switch policy {
+	case "":
+		return overwriteFail, nil
+	case overwriteFail, overwriteSkip, overwriteAlways, overwriteNewer, overwriteRename, overwriteBackup:
+		return policy, nil
+	}
+	return "", fmt.Errorf("overwrite must be %s", overwritePolicyNames)
+}
+
+// resolveDestination applies the overwrite policy. Like cp and mv, an existing
+// directory as the destination means into that directory.
+func resolveDestination(src string, srcInfo os.FileInfo, dst, policy string) (destination, error) {
+	if info, err := os.Stat(dst); err == nil && info.IsDir() {
+		dst = filepath.Join(dst, filepath.Base(src))
+	}
+	dstInfo, err := os.Stat(dst)
+	if os.IsNotExist(err) {
+		return destination{path: dst}, nil
+	}
+	if err != nil {
+		return destination{path: dst}, fmt.Errorf("failed to access destination: %v", err)
+	}
+	return applyOverwritePolicy(srcInfo, dst, dstInfo, policy, nil)
+}
+
+// applyOverwritePolicy decides what to do about the existing file dst. Names
+// for which taken reports true are not used for renamed files and backups, so
+// callers writing several files can keep them from colliding.
+func applyOverwritePolicy(srcInfo os.FileInfo, dst string, dstInfo os.FileInfo, policy string, taken func(string) bool) (destination, error) {
+	dest := destination{path: dst}
+	replacesDirectory := srcInfo.IsDir() || dstInfo.IsDir()
+	switch policy {
+	case overwriteSkip:
+		dest.skip = "destination exists"
+	case overwriteAlways, overwriteNewer:
+		if replacesDirectory {
+			return dest, fmt.Errorf("destination already exists: %s. Directories are never overwritten; use overwrite %q or %q", dst, overwriteRename, overwriteBackup)
+		}
+		if policy == overwriteNewer && !srcInfo.ModTime().After(dstInfo.ModTime()) {
+			dest.skip = "destination is up to date"
+		}
+	case overwriteRename:
+		dest.path = nextFreeName(dst, taken)
+	case overwriteBackup:
+		// An older backup is kept: name.ext.bak, then name.ext_1.bak, ...
+		dest.backup = dst + ".bak"
+		if _, err := os.Lstat(dest.backup); err == nil || (taken != nil && taken(dest.backup)) {
+			dest.backup = nextFreeName(dest.backup, taken)
+		}
+	default:
+		return dest, fmt.Errorf("destination already exists: %s. Set overwrite to %s", dst, overwritePolicyNames)
+	}
+	return dest, nil
+}
+
+// nextFreeName returns the first of name_1.ext, name_2.ext, ... that does not
+// exist and, if taken is set, is not taken.
+func nextFreeName(path string, taken func(string) bool) string {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	for i := 1; ; i++ {
+		candidate := fmt.Sprintf("%s_%d%s", base, i, ext)
+		if taken != nil && taken(candidate) {
+			continue
+		}
+		if _, err := os.Lstat(candidate); os.IsNotExist(err) {
+			return candidate
+		}
+	}
+}
+
+// write moves an existing destination aside when backing up, then runs the
+// write, putting the backup back if the write fails.
+func (d destination) write(write func() error) error {
+	if d.backup != "" {
+		if err := os.Rename(d.path, d.backup); err != nil {
+			return fmt.Errorf("failed to back up %s: %w", d.path, err)
+		}
+	}
+	if err := write(); err != nil {
+		if d.backup != "" {
+			os.Rename(d.backup, d.path)
+		}
+		return err
+	}
+	return nil
+}
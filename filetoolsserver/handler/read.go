@@ -39,6 +39,10 @@ func (h *Handler) HandleReadTextFile(ctx context.Context, req *mcp.CallToolReque
 		slog.Warn("loading large file into memory", "path", input.Path, "size", size, "threshold", h.config.MemoryThreshold)
 	}
 
+	if input.MixedEncoding {
+		return h.readMixedEncoding(v.Path, input, fileSizeBytes)
+	}
+
 	encResult, err := h.resolveEncoding(input.Encoding, v.Path)
 	if err != nil {
 		return errorResult(err.Error()), ReadTextFileOutput{}, nil
@@ -54,11 +58,68 @@ func (h *Handler) HandleReadTextFile(ctx context.Context, req *mcp.CallToolReque
 		return errorResult(fmt.Sprintf("failed to decode file content: %v", err)), ReadTextFileOutput{}, nil
 	}
 
-	totalLines := strings.Count(content, "\n") + 1
+	invalid, err := checkInvalidSequences(content, encResult.name, input.Strict)
+	if err != nil {
+		return errorResult(err.Error()), ReadTextFileOutput{}, nil
+	}
+
+	output := buildReadOutput(content, input, fileSizeBytes)
+	output.InvalidSequences = invalid
+	if encResult.autoDetected {
+		output.DetectedEncoding = encResult.detectedEncoding
+		output.EncodingConfidence = encResult.encodingConfidence
+	}
+
+	return &mcp.CallToolResult{}, output, nil
+}
+
+// readMixedEncoding decodes each encoding region of the file with its own charset.
+// An explicit encoding is applied to all non-UTF-8 regions instead of detection.
+func (h *Handler) readMixedEncoding(path string, input ReadTextFileInput, fileSizeBytes int64) (*mcp.CallToolResult, ReadTextFileOutput, error) {
+	override := strings.ToLower(input.Encoding)
+	if override != "" {
+		if _, ok := encoding.Get(override); !ok {
+			return errorResult(fmt.Sprintf("%v: %s. Use list_encodings to see available encodings", ErrEncodingUnsupported, override)), ReadTextFileOutput{}, nil
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return errorResult(fmt.Sprintf("failed to read file: %v", err)), ReadTextFileOutput{}, nil
+	}
+
+	regions := h.detectRegions(data, override)
+	content, err := encoding.DecodeRegions(data, regions)
+	if err != nil {
+		return errorResult(fmt.Sprintf("failed to decode file content: %v", err)), ReadTextFileOutput{}, nil
+	}
+
+	// Regions mix charsets, so check for both invalid UTF-8 and replacement characters
+	invalid, err := checkInvalidSequences(content, "mixed", input.Strict)
+	if err != nil {
+		return errorResult(err.Error()), ReadTextFileOutput{}, nil
+	}
+
+	output := buildReadOutput(content, input, fileSizeBytes)
+	output.InvalidSequences = invalid
+	output.EncodingRegions = toEncodingRegions(regions)
+	if len(regions) == 1 {
+		output.DetectedEncoding = regions[0].Charset
+		output.EncodingConfidence = regions[0].Confidence
+	} else {
+		output.DetectedEncoding = "mixed"
+	}
+	return &mcp.CallToolResult{}, output, nil
+}
+
+// buildReadOutput applies offset/limit and maxCharacters to decoded content.
+func buildReadOutput(content string, input ReadTextFileInput, fileSizeBytes int64) ReadTextFileOutput {
+
+	totalLines := countLines(content)
 
 	var startLine, endLine int
 	if input.Offset != nil || input.Limit != nil {
-		lines := strings.Split(content, "\n")
+		lines := splitLines(content)
 		content, startLine, endLine = applyOffsetLimit(lines, input.Offset, input.Limit)
 	} else {
 		startLine = 1
@@ -82,7 +143,7 @@ func (h *Handler) HandleReadTextFile(ctx context.Context, req *mcp.CallToolReque
 		truncated = true
 	}
 
-	output := ReadTextFileOutput{
+	return ReadTextFileOutput{
 		Content:       content,
 		TotalLines:    totalLines,
 		FileSizeBytes: fileSizeBytes,
@@ -90,12 +151,6 @@ func (h *Handler) HandleReadTextFile(ctx context.Context, req *mcp.CallToolReque
 		EndLine:       endLine,
 		Truncated:     truncated,
 	}
-	if encResult.autoDetected {
-		output.DetectedEncoding = encResult.detectedEncoding
-		output.EncodingConfidence = encResult.encodingConfidence
-	}
-
-	return &mcp.CallToolResult{}, output, nil
 }
 
 // resolveWriteEncoding returns encoding for writes: explicit > existing file > config default.
@@ -225,7 +280,32 @@ func decodeContent(data []byte, encResult encodingResult) (string, error) {
 	return string(utf8Content), nil
 }
 
-// applyOffsetLimit applies offset and limit to select a range of lines.
+// checkInvalidSequences reports invalid byte sequences in decoded content, or nil if
+// there are none. In strict mode any invalid sequence is returned as an error.
+func checkInvalidSequences(content, charset string, strict bool) (*InvalidSequencesInfo, error) {
+	found := encoding.FindInvalidSequences(content, charset)
+	if found.Count == 0 {
+		return nil, nil
+	}
+	if strict {
+		first := found.Positions[0]
+		return nil, fmt.Errorf("%w: %d in %s content, first at line %d, column %d",
+			ErrInvalidSequences, found.Count, charset, first.Line, first.Column)
+	}
+	info := toInvalidSequencesInfo(found)
+	return &info, nil
+}
+
+func toInvalidSequencesInfo(found encoding.InvalidSequences) InvalidSequencesInfo {
+	info := InvalidSequencesInfo{Count: found.Count, Positions: make([]TextPosition, len(found.Positions))}
+	for i, p := range found.Positions {
+		info.Positions[i] = TextPosition{Line: p.Line, Column: p.Column}
+	}
+	return info
+}
+
+// applyOffsetLimit applies offset and limit to select a range of lines, as split
+// by splitLines. The line ending of the last selected line is dropped.
 // Offset is 1-indexed (like line numbers). Returns content, startLine, endLine.
 // Negative values are treated as not provided.
 func applyOffsetLimit(lines []string, offset, limit *int) (string, int, int) {
@@ -250,5 +330,5 @@ func applyOffsetLimit(lines []string, offset, limit *int) (string, int, int) {
 	}
 
 	selectedLines := lines[startIdx:endIdx]
-	return strings.Join(selectedLines, "\n"), startIdx + 1, endIdx
+	return trimLineEnding(strings.Join(selectedLines, "")), startIdx + 1, endIdx
 }
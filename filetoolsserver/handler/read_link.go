@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/dimitar-grigorov/mcp-file-tools/internal/security"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// HandleReadLink returns the target of a symbolic link without following it,
+// including links that other tools refuse because they lead outside allowed
+// directories or are broken. Targets outside allowed directories are reported
+// only as stored in the link.
+func (h *Handler) HandleReadLink(ctx context.Context, req *mcp.CallToolRequest, input ReadLinkInput) (*mcp.CallToolResult, ReadLinkOutput, error) {
+	link := h.ValidateLinkPath(input.Path)
+	if !link.Ok() {
+		return link.Result, ReadLinkOutput{}, nil
+	}
+
+	info, err := os.Lstat(link.Path)
+	if os.IsNotExist(err) {
+		return errorResult(fmt.Sprintf("path does not exist: %s", input.Path)), ReadLinkOutput{}, nil
+	}
+	if err != nil {
+		return errorResult(fmt.Sprintf("failed to access path: %v", err)), ReadLinkOutput{}, nil
+	}
+	if info.Mode()&fs.ModeSymlink == 0 {
+		return errorResult(fmt.Sprintf("not a symbolic link: %s", input.Path)), ReadLinkOutput{}, nil
+	}
+	target, err := os.Readlink(link.Path)
+	if err != nil {
+		return errorResult(fmt.Sprintf("failed to read link: %v", err)), ReadLinkOutput{}, nil
+	}
+
+	output := ReadLinkOutput{Target: target}
+	lexical := target
+	if !filepath.IsAbs(lexical) {
+		lexical = filepath.Join(filepath.Dir(link.Path), target)
+	}
+	lexical = filepath.Clean(lexical)
+	// Nothing outside allowed directories is looked at, so whether such a path
+	// exists is not revealed: only the stored target is reported
+	allowed := h.ResolvedAllowedDirs()
+	if !security.IsPathWithinAllowedDirectories(lexical, allowed) {
+		return &mcp.CallToolResult{}, output, nil
+	}
+	resolved, exists := resolveLinkTarget(link.Path, lexical)
+	if !security.IsPathWithinAllowedDirectories(resolved, allowed) {
+		return &mcp.CallToolResult{}, output, nil
+	}
+	output.ResolvedTarget = resolved
+	output.Exists = exists
+	output.InsideAllowedDirectories = true
+	return &mcp.CallToolResult{}, output, nil
+}
+
+// resolveLinkTarget returns where a link leads with every symlink resolved. For a
+// broken link, the deepest existing directory above the target is resolved
+// instead, so a directory link on the way that leads elsewhere is still seen.
+func resolveLinkTarget(link, target string) (string, bool) {
+	if resolved, err := filepath.EvalSymlinks(link); err == nil {
+		return resolved, true
+	}
+	rest := ""
+	for dir := target; ; {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return target, false
+		}
+		rest = filepath.Join(filepath.Base(dir), rest)
+		dir = parent
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			return filepath.Join(resolved, rest), false
+		}
+	}
+}
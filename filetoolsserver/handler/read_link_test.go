@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHandleReadLink(t *testing.T) {
+	tempDir := t.TempDir()
+	outside := t.TempDir()
+	h := NewHandler([]string{tempDir})
+	target := filepath.Join(tempDir, "target.txt")
+	os.WriteFile(target, []byte("content"), 0644)
+	if err := os.Symlink("target.txt", filepath.Join(tempDir, "inside")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	os.Symlink(outside, filepath.Join(tempDir, "outside"))
+	os.Symlink(filepath.Join(outside, "missing.txt"), filepath.Join(tempDir, "outside-missing"))
+	os.Symlink(filepath.Join("outside", "missing.txt"), filepath.Join(tempDir, "through-outside"))
+	os.Symlink("missing.txt", filepath.Join(tempDir, "broken"))
+	resolvedTarget, _ := filepath.EvalSymlinks(target)
+
+	tests := []struct {
+		link       string
+		wantTarget string
+		exists     bool
+		inside     bool
+	}{
+		{"inside", "target.txt", true, true},
+		// Outside allowed directories, existing or not, only the stored target is reported
+		{"outside", outside, false, false},
+		{"outside-missing", filepath.Join(outside, "missing.txt"), false, false},
+		{"through-outside", filepath.Join("outside", "missing.txt"), false, false},
+		{"broken", "missing.txt", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.link, func(t *testing.T) {
+			result, output, _ := h.HandleReadLink(context.Background(), nil, ReadLinkInput{Path: filepath.Join(tempDir, tt.link)})
+			if result.IsError {
+				t.Fatalf("unexpected error: %s", extractTextFromResult(result.Content))
+			}
+			if output.Target != tt.wantTarget || output.Exists != tt.exists || output.InsideAllowedDirectories != tt.inside {
+				t.Errorf("unexpected output %+v", output)
+			}
+			if tt.link == "inside" && output.ResolvedTarget != resolvedTarget {
+				t.Errorf("expected resolved target %s, got %s", resolvedTarget, output.ResolvedTarget)
+			}
+			if !tt.inside && output.ResolvedTarget != "" {
+				t.Errorf("resolved target outside allowed directories reported: %s", output.ResolvedTarget)
+			}
+		})
+	}
+
+	result, _, _ := h.HandleReadLink(context.Background(), nil, ReadLinkInput{Path: target})
+	if !result.IsError {
+		t.Error("expected error for a regular file")
+	}
+}
@@ -44,7 +44,7 @@ func (h *Handler) HandleReadMultipleFiles(ctx context.Context, req *mcp.CallTool
 						ErrorCode: ErrCodeOperationFailed,
 					}
 				default:
-					results[j.idx] = h.readSingleFile(j.filePath, input.Encoding)
+					results[j.idx] = h.readSingleFile(j.filePath, input.Encoding, input.Strict)
 				}
 			}
 		}()
@@ -75,7 +75,7 @@ func (h *Handler) HandleReadMultipleFiles(ctx context.Context, req *mcp.CallTool
 }
 
 // readSingleFile reads a single file with optional encoding.
-func (h *Handler) readSingleFile(path, requestedEncoding string) FileReadResult {
+func (h *Handler) readSingleFile(path, requestedEncoding string, strict bool) FileReadResult {
 	result := FileReadResult{Path: path}
 
 	v := h.ValidatePath(path)
@@ -107,7 +107,15 @@ func (h *Handler) readSingleFile(path, requestedEncoding string) FileReadResult
 		return result
 	}
 
+	invalid, err := checkInvalidSequences(content, encResult.name, strict)
+	if err != nil {
+		result.Error = err.Error()
+		result.ErrorCode = ErrCodeEncoding
+		return result
+	}
+
 	result.Content = content
+	result.InvalidSequences = invalid
 	if encResult.autoDetected {
 		result.DetectedEncoding = encResult.detectedEncoding
 		result.EncodingConfidence = encResult.encodingConfidence
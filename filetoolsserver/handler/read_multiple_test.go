@@ -164,3 +164,42 @@ func TestHandleReadMultipleFiles_ErrorsSummary(t *testing.T) {
 		}
 	}
 }
+
+func TestHandleReadMultipleFiles_InvalidSequences(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+
+	good := filepath.Join(tempDir, "good.txt")
+	bad := filepath.Join(tempDir, "bad.txt")
+	os.WriteFile(good, []byte("fine\n"), 0644)
+	os.WriteFile(bad, []byte("bro\xffken\n"), 0644)
+
+	_, output, err := h.HandleReadMultipleFiles(context.Background(), nil, ReadMultipleFilesInput{
+		Paths:    []string{good, bad},
+		Encoding: "utf-8",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.Results[0].InvalidSequences != nil {
+		t.Errorf("expected no invalid sequences for good file, got %+v", output.Results[0].InvalidSequences)
+	}
+	if inv := output.Results[1].InvalidSequences; inv == nil || inv.Count != 1 || inv.Positions[0] != (TextPosition{Line: 1, Column: 4}) {
+		t.Errorf("expected one invalid sequence at 1:4, got %+v", inv)
+	}
+
+	_, output, err = h.HandleReadMultipleFiles(context.Background(), nil, ReadMultipleFilesInput{
+		Paths:    []string{good, bad},
+		Encoding: "utf-8",
+		Strict:   true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.SuccessCount != 1 || output.ErrorCount != 1 {
+		t.Errorf("expected 1 success and 1 error, got %d/%d", output.SuccessCount, output.ErrorCount)
+	}
+	if output.Results[1].ErrorCode != ErrCodeEncoding {
+		t.Errorf("expected error code %s, got %q", ErrCodeEncoding, output.Results[1].ErrorCode)
+	}
+}
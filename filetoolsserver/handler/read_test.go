@@ -331,6 +331,31 @@ func TestHandleReadTextFile_OffsetLimit(t *testing.T) {
 	}
 }
 
+func TestHandleReadTextFile_OffsetLimitCR(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+	testFile := filepath.Join(tempDir, "Unit1.dfm")
+
+	// Classic Mac line endings, with a stray CRLF
+	content := "object Form1: TForm1\r  Left = 0\r\n  Top = 0\r  Caption = 'Form1'\rend\r"
+	if err := os.WriteFile(testFile, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	offset := 2
+	limit := 3
+	_, output, _ := h.HandleReadTextFile(context.Background(), nil, ReadTextFileInput{Path: testFile, Offset: &offset, Limit: &limit})
+
+	// Line endings between the selected lines are kept as they are in the file
+	expected := "  Left = 0\r\n  Top = 0\r  Caption = 'Form1'"
+	if output.Content != expected {
+		t.Errorf("expected %q, got %q", expected, output.Content)
+	}
+	if output.TotalLines != 6 || output.StartLine != 2 || output.EndLine != 4 {
+		t.Errorf("expected lines 2-4 of 6, got %d-%d of %d", output.StartLine, output.EndLine, output.TotalLines)
+	}
+}
+
 func TestHandleReadTextFile_OffsetOnly(t *testing.T) {
 	tempDir := t.TempDir()
 	h := NewHandler([]string{tempDir})
@@ -636,3 +661,130 @@ func TestHandleReadTextFile_TotalLinesReturned(t *testing.T) {
 		t.Errorf("expected EndLine=3, got %d", output.EndLine)
 	}
 }
+
+func TestHandleReadTextFile_MixedEncoding(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+	testFile := filepath.Join(tempDir, "dump.sql")
+
+	enc, _ := encoding.Get("cp1251")
+	cp1251Part, err := enc.NewEncoder().String("INSERT INTO t VALUES ('Здравей свят');\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := "INSERT INTO t VALUES ('Привет');\n" + cp1251Part
+	if err := os.WriteFile(testFile, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, output, err := h.HandleReadTextFile(context.Background(), nil, ReadTextFileInput{
+		Path:          testFile,
+		MixedEncoding: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.IsError {
+		t.Fatalf("expected success, got error: %s", extractTextFromResultRead(result.Content))
+	}
+
+	want := "INSERT INTO t VALUES ('Привет');\nINSERT INTO t VALUES ('Здравей свят');\n"
+	if output.Content != want {
+		t.Errorf("expected %q, got %q", want, output.Content)
+	}
+	if output.DetectedEncoding != "mixed" {
+		t.Errorf("expected detectedEncoding 'mixed', got %q", output.DetectedEncoding)
+	}
+	if len(output.EncodingRegions) != 2 {
+		t.Fatalf("expected 2 regions, got %+v", output.EncodingRegions)
+	}
+	if r := output.EncodingRegions[1]; r.Encoding != "windows-1251" || r.StartLine != 2 || r.EndLine != 2 {
+		t.Errorf("unexpected second region: %+v", r)
+	}
+}
+
+func TestHandleReadTextFile_MixedEncodingExplicitLegacy(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+	testFile := filepath.Join(tempDir, "log.txt")
+
+	enc, _ := encoding.Get("koi8-r")
+	koiPart, err := enc.NewEncoder().String("Ошибка\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(testFile, []byte("Успех\n"+koiPart), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, output, err := h.HandleReadTextFile(context.Background(), nil, ReadTextFileInput{
+		Path:          testFile,
+		Encoding:      "koi8-r",
+		MixedEncoding: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.Content != "Успех\nОшибка\n" {
+		t.Errorf("expected both sections decoded, got %q", output.Content)
+	}
+}
+
+func TestHandleReadTextFile_InvalidSequences(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+	testFile := filepath.Join(tempDir, "broken.txt")
+	if err := os.WriteFile(testFile, []byte("first line\nsecond \xff\xfe line\nthird \xc3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, output, err := h.HandleReadTextFile(context.Background(), nil, ReadTextFileInput{Path: testFile, Encoding: "utf-8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.IsError {
+		t.Fatalf("expected success, got error: %s", extractTextFromResultRead(result.Content))
+	}
+	if output.InvalidSequences == nil {
+		t.Fatal("expected invalid sequences to be reported")
+	}
+	if output.InvalidSequences.Count != 2 {
+		t.Errorf("expected 2 invalid sequences, got %d", output.InvalidSequences.Count)
+	}
+	want := []TextPosition{{Line: 2, Column: 8}, {Line: 3, Column: 7}}
+	for i, p := range want {
+		if i >= len(output.InvalidSequences.Positions) || output.InvalidSequences.Positions[i] != p {
+			t.Errorf("expected positions %v, got %v", want, output.InvalidSequences.Positions)
+			break
+		}
+	}
+
+	// Strict mode fails instead
+	result, _, err = h.HandleReadTextFile(context.Background(), nil, ReadTextFileInput{Path: testFile, Encoding: "utf-8", Strict: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.IsError {
+		t.Fatal("expected error in strict mode")
+	}
+	if msg := extractTextFromResultRead(result.Content); !strings.Contains(msg, "line 2, column 8") {
+		t.Errorf("expected position in error message, got %q", msg)
+	}
+}
+
+func TestHandleReadTextFile_ValidFileNoInvalidSequences(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+	testFile := filepath.Join(tempDir, "ok.txt")
+	if err := os.WriteFile(testFile, []byte("Здравей свят!\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	result, output, err := h.HandleReadTextFile(context.Background(), nil, ReadTextFileInput{Path: testFile, Strict: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.IsError || output.InvalidSequences != nil {
+		t.Errorf("expected clean read, got error=%v invalid=%+v", result.IsError, output.InvalidSequences)
+	}
+}
@@ -0,0 +1,294 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// maxRenameCollisions caps the collisions listed in an error.
+const maxRenameCollisions = 10
+
+// renameStep is one os.Rename done while applying a plan, kept for rollback.
+type renameStep struct {
+	from, to string
+}
+
+// HandleRenameFiles renames every file whose name matches a pattern, computing
+// the whole plan first. Collisions abort the plan; chains and cycles such as
+// swapping two names are applied through temporary names. A failed rename
+// rolls back the ones already done.
+func (h *Handler) HandleRenameFiles(ctx context.Context, req *mcp.CallToolRequest, input RenameFilesInput) (*mcp.CallToolResult, RenameFilesOutput, error) {
+	if input.Pattern == "" {
+		return errorResult(ErrPatternRequired.Error()), RenameFilesOutput{}, nil
+	}
+	v := h.ValidatePath(input.Path)
+	if !v.Ok() {
+		return v.Result, RenameFilesOutput{}, nil
+	}
+	info, err := os.Stat(v.Path)
+	if err != nil {
+		return errorResult(fmt.Sprintf("failed to access directory: %v", err)), RenameFilesOutput{}, nil
+	}
+	if !info.IsDir() {
+		return errorResult(ErrPathMustBeDirectory.Error()), RenameFilesOutput{}, nil
+	}
+	re, err := compileRenamePattern(input.Pattern, input.Glob, input.CaseSensitive == nil || *input.CaseSensitive)
+	if err != nil {
+		return errorResult(err.Error()), RenameFilesOutput{}, nil
+	}
+
+	files, err := h.listRenameCandidates(ctx, v.Path, input.Recursive, input.RespectIgnoreFiles == nil || *input.RespectIgnoreFiles)
+	if err != nil {
+		return errorResult(err.Error()), RenameFilesOutput{}, nil
+	}
+	renames, err := planRenames(files, re, input.Replacement)
+	if err != nil {
+		return errorResult(err.Error()), RenameFilesOutput{}, nil
+	}
+	output := RenameFilesOutput{Renames: renames, FilesMatched: len(renames), Cycles: countRenameCycles(renames)}
+	if len(renames) == 0 {
+		output.Renames = []FileRename{}
+		output.Message = "No file names match the pattern"
+		return &mcp.CallToolResult{}, output, nil
+	}
+
+	dryRun := input.DryRun == nil || *input.DryRun // default: true
+	if dryRun {
+		output.Message = fmt.Sprintf("Would rename %d files. Review the plan and call again with dryRun=false to apply.", len(renames))
+		return &mcp.CallToolResult{}, output, nil
+	}
+	if err := applyRenames(renames); err != nil {
+		return errorResult(err.Error()), RenameFilesOutput{}, nil
+	}
+	output.Applied = true
+	output.Message = fmt.Sprintf("Renamed %d files", len(renames))
+	return &mcp.CallToolResult{}, output, nil
+}
+
+// compileRenamePattern compiles a regex, or a glob matched against the whole
+// name whose * and ? become the capture groups $1, $2, ... Rename globs support
+// only * and ?; everything else, including the classes, braces and extglob
+// groups of internal/glob, matches literally, since those have no natural
+// capture groups.
+func compileRenamePattern(pattern string, isGlob, caseSensitive bool) (*regexp.Regexp, error) {
+	source := pattern
+	if isGlob {
+		var sb strings.Builder
+		sb.WriteString("^")
+		for _, r := range pattern {
+			switch r {
+			case '*':
+				sb.WriteString("(.*)")
+			case '?':
+				sb.WriteString("(.)")
+			default:
+				sb.WriteString(regexp.QuoteMeta(string(r)))
+			}
+		}
+		sb.WriteString("$")
+		pattern = sb.String()
+	}
+	if !caseSensitive {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %v", source, err)
+	}
+	return re, nil
+}
+
+// listRenameCandidates lists the files directly in dir, or below it when
+// recursive. Directories are not renamed.
+func (h *Handler) listRenameCandidates(ctx context.Context, dir string, recursive, respectIgnoreFiles bool) ([]string, error) {
+	var files []string
+	if !recursive {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read directory: %v", err)
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				files = append(files, filepath.Join(dir, entry.Name()))
+			}
+		}
+		return files, nil
+	}
+	ignores := h.ignoreMatcher(dir, respectIgnoreFiles)
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			slog.Debug("skipping path due to error", "path", p, "error", err)
+			return nil
+		}
+		if d.IsDir() {
+			if p != dir && ignores.Ignored(p, true) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !ignores.Ignored(p, false) {
+			files = append(files, p)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// planRenames computes the new name of every matching file and refuses the
+// plan if two files would get the same name, or a new name is taken by a file
+// that is not itself renamed.
+func planRenames(files []string, re *regexp.Regexp, replacement string) ([]FileRename, error) {
+	var renames []FileRename
+	sources := make(map[string]bool, len(files))
+	for _, file := range files {
+		name := filepath.Base(file)
+		if !re.MatchString(name) {
+			continue
+		}
+		newName := re.ReplaceAllString(name, replacement)
+		if newName == "" || newName == "." || newName == ".." || strings.ContainsAny(newName, `/\`) {
+			return nil, fmt.Errorf("invalid new name %q for %s: names cannot be empty or contain path separators", newName, file)
+		}
+		if newName == name {
+			continue
+		}
+		renames = append(renames, FileRename{From: file, To: filepath.Join(filepath.Dir(file), newName)})
+		sources[pathKey(file)] = true
+	}
+
+	var collisions []string
+	targets := make(map[string]string, len(renames))
+	for _, r := range renames {
+		key := pathKey(r.To)
+		if other, ok := targets[key]; ok {
+			collisions = append(collisions, fmt.Sprintf("%s and %s -> %s", other, r.From, r.To))
+			continue
+		}
+		targets[key] = r.From
+		if sources[key] {
+			continue // freed by its own rename
+		}
+		if _, err := os.Lstat(r.To); err == nil && !sameFile(r.From, r.To) {
+			collisions = append(collisions, fmt.Sprintf("%s -> %s, which exists", r.From, r.To))
+		}
+	}
+	if len(collisions) > 0 {
+		msg := fmt.Sprintf("%d name collisions, no files were renamed:\n%s", len(collisions), strings.Join(collisions[:min(len(collisions), maxRenameCollisions)], "\n"))
+		if len(collisions) > maxRenameCollisions {
+			msg += fmt.Sprintf("\n... and %d more", len(collisions)-maxRenameCollisions)
+		}
+		return nil, fmt.Errorf("%s", msg)
+	}
+	sort.Slice(renames, func(i, j int) bool { return renames[i].From < renames[j].From })
+	return renames, nil
+}
+
+// pathKey is the key two paths share when they name the same file, ignoring
+// case on the usually case-insensitive file systems of Windows and macOS.
+func pathKey(path string) string {
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		return strings.ToLower(path)
+	}
+	return path
+}
+
+// sameFile reports whether both paths are the same file, as for a change of
+// case only on a case-insensitive file system.
+func sameFile(a, b string) bool {
+	infoA, err := os.Lstat(a)
+	if err != nil {
+		return false
+	}
+	infoB, err := os.Lstat(b)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(infoA, infoB)
+}
+
+// countRenameCycles counts the cycles in a plan, such as two files swapping
+// names, which cannot be applied by renaming one file after another.
+func countRenameCycles(renames []FileRename) int {
+	next := make(map[string]string, len(renames))
+	for _, r := range renames {
+		next[pathKey(r.From)] = pathKey(r.To)
+	}
+	visited := make(map[string]bool, len(renames))
+	cycles := 0
+	for start := range next {
+		if visited[start] {
+			continue
+		}
+		path := map[string]bool{}
+		for node := start; ; {
+			if path[node] {
+				cycles++
+				break
+			}
+			if visited[node] {
+				break
+			}
+			visited[node], path[node] = true, true
+			to, ok := next[node]
+			if !ok {
+				break
+			}
+			node = to
+		}
+	}
+	return cycles
+}
+
+// applyRenames moves every file to a temporary name first and then to its new
+// name, so chains and cycles need no ordering. If a rename fails, the renames
+// already done are undone in reverse order.
+func applyRenames(renames []FileRename) error {
+	var done []renameStep
+	temps := make([]string, len(renames))
+	for i, r := range renames {
+		temp, err := generateTempPath(r.From)
+		if err == nil {
+			err = os.Rename(r.From, temp)
+		}
+		if err != nil {
+			failed := rollbackRenames(done)
+			return fmt.Errorf("%s: failed to rename: %v (%s)", r.From, err, rollbackOutcome("renamed", failed))
+		}
+		temps[i] = temp
+		done = append(done, renameStep{from: r.From, to: temp})
+	}
+	for i, r := range renames {
+		if err := os.Rename(temps[i], r.To); err != nil {
+			failed := rollbackRenames(done)
+			return fmt.Errorf("%s: failed to rename to %s: %v (%s)", r.From, r.To, err, rollbackOutcome("renamed", failed))
+		}
+		done = append(done, renameStep{from: temps[i], to: r.To})
+	}
+	return nil
+}
+
+// rollbackRenames undoes renames in reverse order and returns, for each file
+// it could not put back, its name and where it was left.
+func rollbackRenames(done []renameStep) []string {
+	var failed []string
+	for i := len(done) - 1; i >= 0; i-- {
+		if err := os.Rename(done[i].to, done[i].from); err != nil {
+			slog.Error("failed to undo rename after rename error", "from", done[i].to, "to", done[i].from, "error", err)
+			failed = append(failed, fmt.Sprintf("%s (left at %s)", done[i].from, done[i].to))
+		}
+	}
+	return failed
+}
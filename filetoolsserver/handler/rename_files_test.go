@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHandleRenameFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+	for _, name := range []string{"frmMain.pas", "frmMain.dfm", "frmAbout.pas", "Utils.pas"} {
+		os.WriteFile(filepath.Join(tempDir, name), []byte(name), 0644)
+	}
+
+	input := RenameFilesInput{Path: tempDir, Pattern: "frm*.pas", Glob: true, Replacement: "${1}Form.pas"}
+	result, output, _ := h.HandleRenameFiles(context.Background(), nil, input)
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", extractTextFromResult(result.Content))
+	}
+	if output.Applied || len(output.Renames) != 2 {
+		t.Fatalf("unexpected dry run output: %+v", output)
+	}
+	if got := output.Renames[0]; got.From != filepath.Join(tempDir, "frmAbout.pas") || got.To != filepath.Join(tempDir, "AboutForm.pas") {
+		t.Errorf("unexpected rename %+v", got)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "frmMain.pas")); err != nil {
+		t.Fatal("dry run renamed a file")
+	}
+
+	dryRun := false
+	input.DryRun = &dryRun
+	_, output, _ = h.HandleRenameFiles(context.Background(), nil, input)
+	if !output.Applied {
+		t.Fatalf("expected renames to be applied: %+v", output)
+	}
+	if data, _ := os.ReadFile(filepath.Join(tempDir, "MainForm.pas")); string(data) != "frmMain.pas" {
+		t.Errorf("unexpected content %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "frmMain.dfm")); err != nil {
+		t.Error("file not matching the pattern was renamed")
+	}
+}
+
+func TestHandleRenameFiles_RegexRecursive(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+	os.MkdirAll(filepath.Join(tempDir, "forms"), 0755)
+	os.WriteFile(filepath.Join(tempDir, "forms", "FRMmain.pas"), []byte("a"), 0644)
+	os.WriteFile(filepath.Join(tempDir, "frmmain.txt"), []byte("b"), 0644)
+
+	dryRun, caseSensitive := false, false
+	_, output, _ := h.HandleRenameFiles(context.Background(), nil, RenameFilesInput{
+		Path:          tempDir,
+		Pattern:       `^frm(\w+)\.pas$`,
+		Replacement:   "${1}_form.pas",
+		CaseSensitive: &caseSensitive,
+		Recursive:     true,
+		DryRun:        &dryRun,
+	})
+	if !output.Applied || len(output.Renames) != 1 {
+		t.Fatalf("unexpected output: %+v", output)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "forms", "main_form.pas")); err != nil {
+		t.Error("file in subdirectory not renamed in place")
+	}
+}
+
+func TestHandleRenameFiles_Chain(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+	os.WriteFile(filepath.Join(tempDir, "a.txt"), []byte("a"), 0644)
+	os.WriteFile(filepath.Join(tempDir, "a.txt.txt"), []byte("b"), 0644)
+
+	// a.txt.txt takes the name a.txt frees
+	dryRun := false
+	result, output, _ := h.HandleRenameFiles(context.Background(), nil, RenameFilesInput{
+		Path:        tempDir,
+		Pattern:     `\.txt$`,
+		Replacement: "",
+		DryRun:      &dryRun,
+	})
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", extractTextFromResult(result.Content))
+	}
+	if !output.Applied || output.Cycles != 0 {
+		t.Fatalf("unexpected output: %+v", output)
+	}
+	if data, _ := os.ReadFile(filepath.Join(tempDir, "a")); string(data) != "a" {
+		t.Errorf("unexpected content %q", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(tempDir, "a.txt")); string(data) != "b" {
+		t.Errorf("unexpected content %q", data)
+	}
+}
+
+func TestApplyRenames_Swap(t *testing.T) {
+	tempDir := t.TempDir()
+	a, b := filepath.Join(tempDir, "a.txt"), filepath.Join(tempDir, "b.txt")
+	os.WriteFile(a, []byte("a"), 0644)
+	os.WriteFile(b, []byte("b"), 0644)
+
+	renames := []FileRename{{From: a, To: b}, {From: b, To: a}}
+	if n := countRenameCycles(renames); n != 1 {
+		t.Errorf("expected 1 cycle, got %d", n)
+	}
+	if err := applyRenames(renames); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(a); string(data) != "b" {
+		t.Errorf("expected swapped content, got %q", data)
+	}
+
+	// A failed rename undoes the others
+	missing := filepath.Join(tempDir, "missing.txt")
+	err := applyRenames([]FileRename{{From: a, To: filepath.Join(tempDir, "c.txt")}, {From: missing, To: filepath.Join(tempDir, "d.txt")}})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if data, _ := os.ReadFile(a); string(data) != "b" {
+		t.Error("rename was not rolled back")
+	}
+	if !strings.Contains(err.Error(), "no files were renamed") {
+		t.Errorf("expected a clean rollback, got %v", err)
+	}
+
+	// A rename that cannot be undone is reported with where the file was left
+	gone := filepath.Join(tempDir, "gone.txt")
+	failed := rollbackRenames([]renameStep{{from: filepath.Join(tempDir, "e.txt"), to: gone}})
+	if len(failed) != 1 || !strings.Contains(failed[0], gone) {
+		t.Errorf("expected the failed undo reported, got %v", failed)
+	}
+}
+
+func TestHandleRenameFiles_Collisions(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+	for _, name := range []string{"v1.pas", "v2.pas", "x.pas", "x.bak"} {
+		os.WriteFile(filepath.Join(tempDir, name), []byte(name), 0644)
+	}
+
+	tests := []struct {
+		name, pattern, replacement string
+	}{
+		{"two files to one name", `^v\d`, "v"},
+		{"name taken by another file", `\.pas$`, ".bak"},
+		{"path separator", `^x`, "sub/x"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dryRun := false
+			result, _, _ := h.HandleRenameFiles(context.Background(), nil, RenameFilesInput{Path: tempDir, Pattern: tt.pattern, Replacement: tt.replacement, DryRun: &dryRun})
+			if !result.IsError {
+				t.Error("expected error")
+			}
+		})
+	}
+	entries, _ := os.ReadDir(tempDir)
+	if len(entries) != 4 {
+		t.Errorf("files were renamed despite collisions: %v", entries)
+	}
+	if _, err := os.Stat(filepath.Join(tempDir, "v1.pas")); err != nil {
+		t.Error("file was renamed despite collisions")
+	}
+}
+
+func TestCompileRenamePattern_GlobSupportsOnlyStarAndQuestionMark(t *testing.T) {
+	re, err := compileRenamePattern("*[1]{a,b}.?", true, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := re.FindStringSubmatch("unit[1]{a,b}.c"); len(got) != 3 || got[1] != "unit" || got[2] != "c" {
+		t.Errorf("expected classes and braces to match literally, got %q", got)
+	}
+	if re.MatchString("unit1a.c") {
+		t.Error("[1] and {a,b} must not act as a class and an alternation")
+	}
+}
@@ -0,0 +1,260 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/dimitar-grigorov/mcp-file-tools/internal/encoding"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// HandleReplaceInFiles replaces a pattern in every matching file, preserving each
+// file's encoding and line endings. All replacements are prepared before anything
+// is written, and writes that fail midway are rolled back.
+func (h *Handler) HandleReplaceInFiles(ctx context.Context, req *mcp.CallToolRequest, input ReplaceInFilesInput) (*mcp.CallToolResult, ReplaceInFilesOutput, error) {
+	if input.Pattern == "" {
+		return errorResult(ErrPatternRequired.Error()), ReplaceInFilesOutput{}, nil
+	}
+	if len(input.Paths) == 0 {
+		return errorResult("paths is required"), ReplaceInFilesOutput{}, nil
+	}
+	grepInput := GrepInput{
+		Pattern:       input.Pattern,
+		Paths:         input.Paths,
+		CaseSensitive: input.CaseSensitive,
+		FixedString:   input.FixedString,
+		WordBoundary:  input.WordBoundary,
+		Multiline:     input.Multiline,
+		OutputMode:    grepOutputFiles,
+		Include:       input.Include,
+		Exclude:       input.Exclude,
+		Encoding:      input.Encoding,
+	}
+	query, err := newGrepQuery(grepInput)
+	if err != nil {
+		return errorResult(err.Error()), ReplaceInFilesOutput{}, nil
+	}
+	filter, err := newFileFilter(input.Include, input.Exclude)
+	if err != nil {
+		return errorResult(err.Error()), ReplaceInFilesOutput{}, nil
+	}
+	filter.respectIgnoreFiles = input.RespectIgnoreFiles == nil || *input.RespectIgnoreFiles // default: true
+	replacement := ConvertLineEndings(input.Replacement, LineEndingLF)
+
+	files := h.collectFiles(ctx, input.Paths, filter)
+	output := ReplaceInFilesOutput{Files: []ReplaceFileResult{}, FilesSearched: len(files)}
+	if len(files) == 0 {
+		output.Message = "No files to search"
+		return &mcp.CallToolResult{}, output, nil
+	}
+	// Prepare every change before writing anything. Each file is matched on its
+	// decoded, LF-normalized text, the same text the replacement is applied to.
+	var changes []pendingReplace
+	for _, path := range files {
+		if ctx.Err() != nil {
+			return errorResult("replace cancelled, no files were changed"), ReplaceInFilesOutput{}, nil
+		}
+		change, skipReason, err := h.prepareReplace(path, query, replacement, input)
+		if err != nil {
+			return errorResult(fmt.Sprintf("%s: %v (no files were changed)", path, err)), ReplaceInFilesOutput{}, nil
+		}
+		if skipReason != "" {
+			output.Skipped = append(output.Skipped, ReplaceSkippedFile{Path: path, Reason: skipReason})
+			continue
+		}
+		if change == nil {
+			continue
+		}
+		changes = append(changes, *change)
+		output.Files = append(output.Files, ReplaceFileResult{
+			Path:         path,
+			Encoding:     change.file.encoding,
+			Replacements: change.count,
+		})
+		output.TotalReplacements += change.count
+		output.Diff += createUnifiedDiff(change.file.content, change.modified, path)
+	}
+	output.FilesChanged = len(changes)
+
+	if len(changes) == 0 {
+		output.Message = fmt.Sprintf("No matches found in %d files", len(files))
+		return &mcp.CallToolResult{}, output, nil
+	}
+
+	dryRun := input.DryRun == nil || *input.DryRun // default: true
+	if dryRun {
+		output.Message = fmt.Sprintf("Would replace %d occurrences in %d files. Review the diff and call again with dryRun=false to apply.",
+			output.TotalReplacements, output.FilesChanged)
+		return &mcp.CallToolResult{}, output, nil
+	}
+
+	writes := make([]pendingWrite, len(changes))
+	for i, change := range changes {
+		writes[i] = change.write
+	}
+	if err := applyWrites(writes); err != nil {
+		return errorResult(err.Error()), ReplaceInFilesOutput{}, nil
+	}
+	output.Applied = true
+	output.Message = fmt.Sprintf("Replaced %d occurrences in %d files", output.TotalReplacements, output.FilesChanged)
+	return &mcp.CallToolResult{}, output, nil
+}
+
+// pendingReplace is a prepared replacement in one file.
+type pendingReplace struct {
+	write    pendingWrite
+	file     textFile
+	modified string
+	count    int
+}
+
+// pendingWrite is a prepared change to one file: the new content already encoded,
+// plus the original bytes for rollback.
+type pendingWrite struct {
+	path     string
+	mode     os.FileMode
+	data     []byte
+	original []byte
+}
+
+// prepareReplace reads a file and computes its replacement. It returns a nil change
+// when nothing matches, or a reason when the file is skipped. Errors (such as a
+// replacement that cannot be encoded) abort the whole operation.
+func (h *Handler) prepareReplace(path string, query *grepQuery, replacement string, input ReplaceInFilesInput) (*pendingReplace, string, error) {
+	if loadToMemory, _ := h.shouldLoadEntireFile(path); !loadToMemory {
+		return nil, "file too large", nil
+	}
+	original, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read file: %w", err)
+	}
+	if isBinaryFile(original) {
+		return nil, "", nil
+	}
+	file, err := h.readTextForEdit(path, input.Encoding)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var modified string
+	var count int
+	if query.multiline {
+		modified, count = query.replaceAll(query.patterns[0], file.content, replacement, input.FixedString)
+	} else {
+		modified, count = query.replaceLines(file.content, replacement, input.FixedString)
+	}
+	if count == 0 || modified == file.content {
+		return nil, "", nil
+	}
+
+	// Only files that would change are reported as skipped
+	mode := file.mode
+	forceWritable := input.ForceWritable != nil && *input.ForceWritable // default: false
+	if isReadOnly(mode) && !forceWritable {
+		return nil, "read-only (set forceWritable to modify)", nil
+	}
+	// Re-encoding replacement characters would corrupt the undecodable bytes
+	if invalid := encoding.FindInvalidSequences(file.content, file.encoding); invalid.Count > 0 {
+		return nil, fmt.Sprintf("%d invalid byte sequences in %s", invalid.Count, file.encoding), nil
+	}
+
+	data, err := file.encode(modified)
+	if err != nil {
+		return nil, "", err
+	}
+	return &pendingReplace{
+		write:    pendingWrite{path: path, mode: mode, data: data, original: original},
+		file:     file,
+		modified: modified,
+		count:    count,
+	}, "", nil
+}
+
+// applyWrites writes every prepared change atomically. If a write fails, the
+// files already written are restored from their original bytes.
+func applyWrites(writes []pendingWrite) error {
+	for i, w := range writes {
+		if isReadOnly(w.mode) {
+			if err := clearReadOnly(w.path, w.mode); err != nil {
+				failed := rollbackWrites(writes[:i])
+				return fmt.Errorf("%s: failed to clear read-only flag: %v (%s)", w.path, err, rollbackOutcome("changed", failed))
+			}
+			w.mode |= 0200
+		}
+		if err := atomicWriteFile(w.path, w.data, w.mode); err != nil {
+			failed := rollbackWrites(writes[:i])
+			return fmt.Errorf("%s: failed to write file: %v (%s)", w.path, err, rollbackOutcome("changed", failed))
+		}
+	}
+	return nil
+}
+
+// rollbackWrites restores files that were already written and returns the
+// paths it could not restore.
+func rollbackWrites(written []pendingWrite) []string {
+	var failed []string
+	for _, w := range written {
+		if err := atomicWriteFile(w.path, w.original, w.mode); err != nil {
+			slog.Error("failed to restore file after write error", "path", w.path, "error", err)
+			failed = append(failed, w.path)
+		}
+	}
+	return failed
+}
+
+// rollbackOutcome describes the state a rollback left behind: "no files were
+// <verb>", or the paths it could not restore.
+func rollbackOutcome(verb string, failed []string) string {
+	if len(failed) == 0 {
+		return "no files were " + verb
+	}
+	return fmt.Sprintf("rollback failed, %d files could not be restored: %s", len(failed), strings.Join(failed, ", "))
+}
+
+// replaceLines replaces matches of the query's pattern line by line, so a match
+// never spans lines. Returns the new content and the number of replacements.
+func (q *grepQuery) replaceLines(content, replacement string, literal bool) (string, int) {
+	lines := strings.Split(content, "\n")
+	total := 0
+	for i, line := range lines {
+		var n int
+		lines[i], n = q.replaceAll(q.patterns[0], line, replacement, literal)
+		total += n
+	}
+	return strings.Join(lines, "\n"), total
+}
+
+// replaceAll replaces matches of re in s, honoring the word boundary option. Unless
+// literal, $1 and ${name} in replacement expand to capture groups.
+func (q *grepQuery) replaceAll(re *regexp.Regexp, s, replacement string, literal bool) (string, int) {
+	locs := re.FindAllStringSubmatchIndex(s, -1)
+	if len(locs) == 0 {
+		return s, 0
+	}
+	var sb strings.Builder
+	var expanded []byte
+	last, count := 0, 0
+	for _, loc := range locs {
+		if q.word && !atWordBoundary(s, loc[0], loc[1]) {
+			continue
+		}
+		sb.WriteString(s[last:loc[0]])
+		if literal {
+			sb.WriteString(replacement)
+		} else {
+			expanded = re.ExpandString(expanded[:0], replacement, s, loc)
+			sb.Write(expanded)
+		}
+		last = loc[1]
+		count++
+	}
+	if count == 0 {
+		return s, 0
+	}
+	sb.WriteString(s[last:])
+	return sb.String(), count
+}
@@ -0,0 +1,275 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+func TestHandleReplaceInFiles_PreservesEncodingAndLineEndings(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+
+	original := "unit Main;\r\n// Главная форма\r\nprocedure TForm1.Init;\r\nbegin\r\n  TForm1.Create;\r\nend;\r\n"
+	encoded, _ := charmap.Windows1251.NewEncoder().String(original)
+	cp1251File := filepath.Join(tempDir, "main.pas")
+	os.WriteFile(cp1251File, []byte(encoded), 0644)
+	utf8File := filepath.Join(tempDir, "other.pas")
+	os.WriteFile(utf8File, []byte("uses TForm1;\n"), 0644)
+	untouched := filepath.Join(tempDir, "readme.txt")
+	os.WriteFile(untouched, []byte("TForm1\n"), 0644)
+
+	// Dry run is the default
+	result, output, err := h.HandleReplaceInFiles(context.Background(), nil, ReplaceInFilesInput{
+		Pattern:     "TForm1",
+		Replacement: "TMainForm",
+		Paths:       []string{tempDir},
+		Include:     GlobList{"*.pas"},
+		Encoding:    "cp1251",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", extractTextFromResult(result.Content))
+	}
+	if output.Applied || output.TotalReplacements != 3 || output.FilesChanged != 2 || output.FilesSearched != 2 {
+		t.Fatalf("unexpected dry run output: %+v", output)
+	}
+	if output.Files[0].Path != cp1251File || output.Files[0].Replacements != 2 || output.Files[0].Encoding != "cp1251" {
+		t.Errorf("unexpected file result: %+v", output.Files[0])
+	}
+	if !strings.Contains(output.Diff, "+procedure TMainForm.Init;") || !strings.Contains(output.Diff, "+uses TMainForm;") {
+		t.Errorf("combined diff missing changes:\n%s", output.Diff)
+	}
+	if data, _ := os.ReadFile(cp1251File); string(data) != encoded {
+		t.Error("dry run modified the file")
+	}
+
+	dryRun := false
+	_, output, _ = h.HandleReplaceInFiles(context.Background(), nil, ReplaceInFilesInput{
+		Pattern:     "TForm1",
+		Replacement: "TMainForm",
+		Paths:       []string{tempDir},
+		Include:     GlobList{"*.pas"},
+		Encoding:    "cp1251",
+		DryRun:      &dryRun,
+	})
+	if !output.Applied {
+		t.Fatalf("expected changes to be applied: %+v", output)
+	}
+	want, _ := charmap.Windows1251.NewEncoder().String(strings.ReplaceAll(original, "TForm1", "TMainForm"))
+	if data, _ := os.ReadFile(cp1251File); string(data) != want {
+		t.Errorf("cp1251 file not preserved:\n%q\nwant:\n%q", data, want)
+	}
+	if data, _ := os.ReadFile(utf8File); string(data) != "uses TMainForm;\n" {
+		t.Errorf("unexpected utf-8 file content: %q", data)
+	}
+	if data, _ := os.ReadFile(untouched); string(data) != "TForm1\n" {
+		t.Error("excluded file was modified")
+	}
+}
+
+func TestHandleReplaceInFiles_CaptureGroupsAndLiteral(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+	testFile := filepath.Join(tempDir, "a.txt")
+	dryRun := false
+
+	os.WriteFile(testFile, []byte("key1=value1\nkey2=value2\n"), 0644)
+	h.HandleReplaceInFiles(context.Background(), nil, ReplaceInFilesInput{
+		Pattern:     `(\w+)=(?P<val>\w+)`,
+		Replacement: "${val}=$1",
+		Paths:       []string{testFile},
+		DryRun:      &dryRun,
+	})
+	if data, _ := os.ReadFile(testFile); string(data) != "value1=key1\nvalue2=key2\n" {
+		t.Errorf("unexpected capture group result: %q", data)
+	}
+
+	os.WriteFile(testFile, []byte("price (a.b)\n"), 0644)
+	h.HandleReplaceInFiles(context.Background(), nil, ReplaceInFilesInput{
+		Pattern:     "(a.b)",
+		Replacement: "$1 $",
+		Paths:       []string{testFile},
+		FixedString: true,
+		DryRun:      &dryRun,
+	})
+	if data, _ := os.ReadFile(testFile); string(data) != "price $1 $\n" {
+		t.Errorf("unexpected literal result: %q", data)
+	}
+}
+
+func TestHandleReplaceInFiles_WordBoundaryAndMultiline(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+	testFile := filepath.Join(tempDir, "a.pas")
+	dryRun := false
+
+	os.WriteFile(testFile, []byte("Count := Count + CountAll;\n"), 0644)
+	_, output, _ := h.HandleReplaceInFiles(context.Background(), nil, ReplaceInFilesInput{
+		Pattern:      "Count",
+		Replacement:  "Total",
+		Paths:        []string{testFile},
+		WordBoundary: true,
+		DryRun:       &dryRun,
+	})
+	if output.TotalReplacements != 2 {
+		t.Errorf("expected 2 replacements, got %d", output.TotalReplacements)
+	}
+	if data, _ := os.ReadFile(testFile); string(data) != "Total := Total + CountAll;\n" {
+		t.Errorf("unexpected word boundary result: %q", data)
+	}
+
+	os.WriteFile(testFile, []byte("begin\r\n  x;\r\nend;\r\n"), 0644)
+	h.HandleReplaceInFiles(context.Background(), nil, ReplaceInFilesInput{
+		Pattern:     `begin\n(.*)\nend;`,
+		Replacement: "try\n$1\nfinally end;",
+		Paths:       []string{testFile},
+		Multiline:   true,
+		DryRun:      &dryRun,
+	})
+	if data, _ := os.ReadFile(testFile); string(data) != "try\r\n  x;\r\nfinally end;\r\n" {
+		t.Errorf("unexpected multiline result: %q", data)
+	}
+}
+
+func TestHandleReplaceInFiles_SkipsReadOnlyAndNoMatches(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+
+	readOnly := filepath.Join(tempDir, "ro.txt")
+	os.WriteFile(readOnly, []byte("foo\n"), 0444)
+	dryRun := false
+
+	_, output, _ := h.HandleReplaceInFiles(context.Background(), nil, ReplaceInFilesInput{
+		Pattern:     "foo",
+		Replacement: "bar",
+		Paths:       []string{tempDir},
+		DryRun:      &dryRun,
+	})
+	if output.Applied || len(output.Skipped) != 1 || output.Skipped[0].Path != readOnly {
+		t.Errorf("expected read-only file to be skipped: %+v", output)
+	}
+	if data, _ := os.ReadFile(readOnly); string(data) != "foo\n" {
+		t.Error("read-only file was modified")
+	}
+
+	_, output, _ = h.HandleReplaceInFiles(context.Background(), nil, ReplaceInFilesInput{
+		Pattern:     "missing",
+		Replacement: "x",
+		Paths:       []string{tempDir},
+	})
+	if output.FilesChanged != 0 || output.Applied || len(output.Skipped) != 0 || !strings.Contains(output.Message, "No matches") {
+		t.Errorf("unexpected output for no matches: %+v", output)
+	}
+}
+
+func TestHandleReplaceInFiles_AnchoredPatternInCRLFFile(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+
+	crlfFile := filepath.Join(tempDir, "unit.pas")
+	os.WriteFile(crlfFile, []byte("uses Foo\r\nbegin Foo;\r\nend Foo\r\n"), 0644)
+	dryRun := false
+
+	result, output, err := h.HandleReplaceInFiles(context.Background(), nil, ReplaceInFilesInput{
+		Pattern:     "Foo$",
+		Replacement: "Bar",
+		Paths:       []string{tempDir},
+		DryRun:      &dryRun,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.IsError {
+		t.Fatalf("unexpected error: %s", extractTextFromResult(result.Content))
+	}
+	if !output.Applied || output.TotalReplacements != 2 {
+		t.Fatalf("expected 2 replacements in the CRLF file: %+v", output)
+	}
+	if data, _ := os.ReadFile(crlfFile); string(data) != "uses Bar\r\nbegin Foo;\r\nend Bar\r\n" {
+		t.Errorf("unexpected content: %q", data)
+	}
+}
+
+func TestHandleReplaceInFiles_UnencodableReplacementChangesNothing(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+
+	first := filepath.Join(tempDir, "a.txt")
+	second := filepath.Join(tempDir, "b.txt")
+	os.WriteFile(first, []byte("name\n"), 0644)
+	os.WriteFile(second, []byte("name\n"), 0644)
+	dryRun := false
+
+	result, _, _ := h.HandleReplaceInFiles(context.Background(), nil, ReplaceInFilesInput{
+		Pattern:     "name",
+		Replacement: "名前",
+		Paths:       []string{tempDir},
+		Encoding:    "cp1251",
+		DryRun:      &dryRun,
+	})
+	if !result.IsError {
+		t.Fatal("expected error for replacement that cannot be encoded")
+	}
+	for _, path := range []string{first, second} {
+		if data, _ := os.ReadFile(path); string(data) != "name\n" {
+			t.Errorf("%s was modified", path)
+		}
+	}
+}
+
+func TestHandleReplaceInFiles_ValidationErrors(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+
+	tests := []struct {
+		name  string
+		input ReplaceInFilesInput
+	}{
+		{"missing pattern", ReplaceInFilesInput{Paths: []string{tempDir}}},
+		{"missing paths", ReplaceInFilesInput{Pattern: "x"}},
+		{"invalid regex", ReplaceInFilesInput{Pattern: "(", Paths: []string{tempDir}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, _, _ := h.HandleReplaceInFiles(context.Background(), nil, tt.input)
+			if !result.IsError {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestApplyWrites_ReportsRollback(t *testing.T) {
+	tempDir := t.TempDir()
+	written := filepath.Join(tempDir, "a.txt")
+	os.WriteFile(written, []byte("original"), 0644)
+
+	// The second write fails and the first is restored
+	err := applyWrites([]pendingWrite{
+		{path: written, mode: 0644, data: []byte("changed"), original: []byte("original")},
+		{path: filepath.Join(tempDir, "missing", "b.txt"), mode: 0644, data: []byte("b")},
+	})
+	if err == nil || !strings.Contains(err.Error(), "no files were changed") {
+		t.Errorf("expected a clean rollback, got %v", err)
+	}
+	if data, _ := os.ReadFile(written); string(data) != "original" {
+		t.Errorf("file not restored: %q", data)
+	}
+
+	// A file that cannot be restored is named instead
+	lost := filepath.Join(tempDir, "gone", "c.txt")
+	failed := rollbackWrites([]pendingWrite{{path: written, mode: 0644, original: []byte("original")}, {path: lost, mode: 0644}})
+	if len(failed) != 1 || failed[0] != lost {
+		t.Fatalf("expected %s reported, got %v", lost, failed)
+	}
+	if msg := rollbackOutcome("changed", failed); strings.Contains(msg, "no files were changed") || !strings.Contains(msg, lost) {
+		t.Errorf("unexpected outcome %q", msg)
+	}
+}
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// MIME types of resources without a more specific type
+const (
+	MIMETypeDirectory = "text/uri-list" // directories are read as a list of entry URIs
+	mimeTypeText      = "text/plain"
+	mimeTypeBinary    = "application/octet-stream"
+)
+
+// ErrFileTooLarge is returned when a file exceeds the memory threshold for reading
+// as a whole, e.g. as a resource.
+var ErrFileTooLarge = errors.New("file is too large to read at once")
+
+// ResourceContent is a file or directory read as an MCP resource.
+type ResourceContent struct {
+	MIMEType string
+	Text     string // decoded UTF-8 text of a text file
+	Blob     []byte // raw content of a binary file
+	Encoding string // encoding Text was decoded from
+	IsDir    bool
+	Entries  []ResourceEntry // entries of a directory
+}
+
+// ResourceEntry is one entry of a directory resource.
+type ResourceEntry struct {
+	Path  string
+	IsDir bool
+}
+
+// ReadResource reads a file or directory for a file:// resource. Text files are
+// decoded to UTF-8 with the same encoding detection as read_text_file, binary
+// files are returned as is, and directories list their entries, leaving out
+// paths excluded by ignore files. Missing paths return an error wrapping
+// os.ErrNotExist.
+func (h *Handler) ReadResource(path string) (ResourceContent, error) {
+	v := h.ValidatePath(path)
+	if !v.Ok() {
+		return ResourceContent{}, v.Err
+	}
+	info, err := os.Stat(v.Path)
+	if err != nil {
+		return ResourceContent{}, err
+	}
+	if info.IsDir() {
+		return h.readDirectoryResource(v.Path)
+	}
+	if info.Size() > h.config.MemoryThreshold {
+		return ResourceContent{}, fmt.Errorf("%w (%d bytes): use read_text_file with offset/limit", ErrFileTooLarge, info.Size())
+	}
+
+	data, err := os.ReadFile(v.Path)
+	if err != nil {
+		return ResourceContent{}, err
+	}
+	mimeType := mime.TypeByExtension(filepath.Ext(v.Path))
+	if isBinaryFile(data) {
+		if mimeType == "" {
+			mimeType = mimeTypeBinary
+		}
+		return ResourceContent{MIMEType: mimeType, Blob: data}, nil
+	}
+
+	encResult, err := h.resolveEncoding("", v.Path)
+	if err != nil {
+		return ResourceContent{}, err
+	}
+	content, err := decodeContent(data, encResult)
+	if err != nil {
+		return ResourceContent{}, fmt.Errorf("failed to decode file content: %w", err)
+	}
+	// The text is UTF-8 now, whatever the file's charset
+	mimeType, _, _ = strings.Cut(mimeType, ";")
+	if mimeType == "" {
+		mimeType = mimeTypeText
+	}
+	return ResourceContent{MIMEType: mimeType, Text: content, Encoding: encResult.name}, nil
+}
+
+// readDirectoryResource lists a directory, directories first, then by name.
+func (h *Handler) readDirectoryResource(dir string) (ResourceContent, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return ResourceContent{}, err
+	}
+	ignores := h.ignoreMatcher(dir, true)
+	result := ResourceContent{MIMEType: MIMETypeDirectory, IsDir: true, Entries: []ResourceEntry{}}
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		if ignores.Ignored(path, entry.IsDir()) {
+			continue
+		}
+		result.Entries = append(result.Entries, ResourceEntry{Path: path, IsDir: entry.IsDir()})
+	}
+	sort.SliceStable(result.Entries, func(i, j int) bool {
+		a, b := result.Entries[i], result.Entries[j]
+		if a.IsDir != b.IsDir {
+			return a.IsDir
+		}
+		return a.Path < b.Path
+	})
+	return result, nil
+}
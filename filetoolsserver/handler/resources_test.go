@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dimitar-grigorov/mcp-file-tools/internal/config"
+)
+
+func TestReadResource(t *testing.T) {
+	tempDir := t.TempDir()
+	os.WriteFile(filepath.Join(tempDir, "page.html"), []byte("<p>hi</p>"), 0644)
+	os.WriteFile(filepath.Join(tempDir, "big.txt"), []byte(strings.Repeat("x", 100)), 0644)
+	os.WriteFile(filepath.Join(tempDir, "image.png"), []byte{0x89, 'P', 'N', 'G', 0x00}, 0644)
+	h := NewHandler([]string{tempDir}, WithConfig(&config.Config{MemoryThreshold: 50}))
+
+	content, err := h.ReadResource(filepath.Join(tempDir, "page.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The charset parameter is dropped because the text is always UTF-8
+	if content.MIMEType != "text/html" || content.Text != "<p>hi</p>" || content.Encoding == "" {
+		t.Errorf("unexpected content %+v", content)
+	}
+
+	content, err = h.ReadResource(filepath.Join(tempDir, "image.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if content.MIMEType != "image/png" || len(content.Blob) != 5 || content.Encoding != "" {
+		t.Errorf("unexpected content %+v", content)
+	}
+
+	if _, err := h.ReadResource(filepath.Join(tempDir, "big.txt")); !errors.Is(err, ErrFileTooLarge) {
+		t.Errorf("expected ErrFileTooLarge, got %v", err)
+	}
+	if _, err := h.ReadResource(filepath.Join(tempDir, "missing.txt")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected fs.ErrNotExist, got %v", err)
+	}
+	if _, err := h.ReadResource(filepath.Join(t.TempDir(), "outside.txt")); err == nil {
+		t.Error("expected error outside allowed directories")
+	}
+}
@@ -3,12 +3,14 @@ package handler
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io/fs"
 	"log/slog"
 	"os"
 	"path/filepath"
-	"strings"
 
+	"github.com/dimitar-grigorov/mcp-file-tools/internal/glob"
+	"github.com/dimitar-grigorov/mcp-file-tools/internal/ignore"
 	"github.com/dimitar-grigorov/mcp-file-tools/internal/security"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -35,7 +37,17 @@ func (h *Handler) HandleSearchFiles(ctx context.Context, req *mcp.CallToolReques
 	if maxResults <= 0 {
 		maxResults = defaultMaxResults
 	}
-	results, truncated, err := searchFiles(ctx, v.Path, input.Pattern, input.ExcludePatterns, h.ResolvedAllowedDirs(), maxResults)
+	pattern, err := glob.Compile(input.Pattern)
+	if err != nil {
+		return errorResult(err.Error()), SearchFilesOutput{}, nil
+	}
+	exclude, err := glob.CompileSet(input.ExcludePatterns)
+	if err != nil {
+		return errorResult(fmt.Sprintf("invalid excludePatterns: %v", err)), SearchFilesOutput{}, nil
+	}
+	respectIgnoreFiles := input.RespectIgnoreFiles == nil || *input.RespectIgnoreFiles // default: true
+	ignores := h.ignoreMatcher(v.Path, respectIgnoreFiles)
+	results, truncated, err := searchFiles(ctx, v.Path, pattern, exclude, ignores, h.ResolvedAllowedDirs(), maxResults)
 	if err != nil {
 		if err == context.Canceled || err == context.DeadlineExceeded {
 			return errorResult("search cancelled"), SearchFilesOutput{}, nil
@@ -47,8 +59,10 @@ func (h *Handler) HandleSearchFiles(ctx context.Context, req *mcp.CallToolReques
 
 var errMaxResultsReached = errors.New("max results reached")
 
-// searchFiles recursively searches for files matching the pattern
-func searchFiles(ctx context.Context, rootPath, pattern string, excludePatterns, allowedDirs []string, maxResults int) ([]string, bool, error) {
+// searchFiles recursively searches for files matching the pattern. Patterns are
+// matched against paths relative to rootPath; excluded and ignored directories
+// are not entered.
+func searchFiles(ctx context.Context, rootPath string, pattern *glob.Pattern, exclude *glob.Set, ignores *ignore.Matcher, allowedDirs []string, maxResults int) ([]string, bool, error) {
 	var results []string
 	truncated := false
 	err := filepath.WalkDir(rootPath, func(fullPath string, d fs.DirEntry, err error) error {
@@ -74,13 +88,14 @@ func searchFiles(ctx context.Context, rootPath, pattern string, excludePatterns,
 			return nil
 		}
 		relativePathNorm := filepath.ToSlash(relativePath)
-		if shouldExcludePath(relativePathNorm, excludePatterns) {
-			if d.IsDir() {
+		if d.IsDir() {
+			if exclude.MatchDir(relativePathNorm) || ignores.Ignored(fullPath, true) {
 				return filepath.SkipDir
 			}
+		} else if exclude.Match(relativePathNorm) || ignores.Ignored(fullPath, false) {
 			return nil
 		}
-		if matchGlobPattern(relativePathNorm, pattern) {
+		if pattern.Match(relativePathNorm) {
 			results = append(results, fullPath)
 			if len(results) >= maxResults {
 				truncated = true
@@ -97,120 +112,3 @@ func searchFiles(ctx context.Context, rootPath, pattern string, excludePatterns,
 	}
 	return results, truncated, nil
 }
-
-// matchGlobPattern matches a path against a glob pattern, supporting ** for recursive matching
-func matchGlobPattern(path, pattern string) bool {
-	// Normalize pattern to use forward slashes
-	pattern = filepath.ToSlash(pattern)
-
-	// Handle ** patterns (recursive glob)
-	if strings.Contains(pattern, "**") {
-		return matchDoubleStarPattern(path, pattern)
-	}
-
-	// Standard glob match using filepath.Match
-	matched, err := filepath.Match(pattern, path)
-	if err == nil && matched {
-		return true
-	}
-
-	// Also try matching just the filename for patterns without path separators
-	if !strings.Contains(pattern, "/") {
-		filename := filepath.Base(path)
-		matched, err = filepath.Match(pattern, filename)
-		if err == nil && matched {
-			return true
-		}
-	}
-
-	return false
-}
-
-// matchDoubleStarPattern handles patterns containing **
-func matchDoubleStarPattern(path, pattern string) bool {
-	// Split pattern into parts around **
-	parts := strings.Split(pattern, "**")
-
-	if len(parts) == 2 {
-		prefix := strings.TrimSuffix(parts[0], "/")
-		suffix := strings.TrimPrefix(parts[1], "/")
-
-		// Pattern like "**/*.ext" - match suffix against any subpath
-		if prefix == "" {
-			// Try matching the suffix against the path or any part of it
-			if suffix != "" {
-				// Match the suffix pattern against the filename or path ending
-				return matchSuffix(path, suffix)
-			}
-			// Pattern is just "**" - matches everything
-			return true
-		}
-
-		// Pattern like "dir/**" - match prefix then anything
-		if suffix == "" {
-			return strings.HasPrefix(path, prefix+"/") || path == prefix
-		}
-
-		// Pattern like "dir/**/file.ext"
-		if strings.HasPrefix(path, prefix+"/") || prefix == "" {
-			remaining := path
-			if prefix != "" {
-				remaining = strings.TrimPrefix(path, prefix+"/")
-			}
-			return matchSuffix(remaining, suffix)
-		}
-	}
-
-	return false
-}
-
-// matchSuffix checks if the path ends with a pattern match
-func matchSuffix(path, suffixPattern string) bool {
-	// Try matching the entire path
-	matched, err := filepath.Match(suffixPattern, path)
-	if err == nil && matched {
-		return true
-	}
-
-	// Try matching just the filename
-	filename := filepath.Base(path)
-	matched, err = filepath.Match(suffixPattern, filename)
-	if err == nil && matched {
-		return true
-	}
-
-	// Try matching the path with the suffix pattern at any depth
-	parts := strings.Split(path, "/")
-	for i := range parts {
-		subpath := strings.Join(parts[i:], "/")
-		matched, err = filepath.Match(suffixPattern, subpath)
-		if err == nil && matched {
-			return true
-		}
-	}
-
-	return false
-}
-
-// shouldExcludePath checks if a path matches any of the exclude patterns
-func shouldExcludePath(path string, patterns []string) bool {
-	for _, pattern := range patterns {
-		pattern = filepath.ToSlash(pattern)
-
-		// Try glob match
-		if matchGlobPattern(path, pattern) {
-			return true
-		}
-
-		// Also check if the path contains the pattern as a directory component
-		if !containsGlobChars(pattern) {
-			pathParts := strings.Split(path, "/")
-			for _, part := range pathParts {
-				if part == pattern {
-					return true
-				}
-			}
-		}
-	}
-	return false
-}
@@ -160,3 +160,65 @@ func TestHandleSearchFiles_MaxResults(t *testing.T) {
 		t.Error("expected truncated to be true")
 	}
 }
+
+func TestHandleSearchFiles_BracesAndMultipleDoubleStars(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+
+	for _, rel := range []string{
+		"src/main.pas", "src/forms/main.dfm", "src/forms/main.txt", "lib/other.pas",
+		"a/test/b/x.inc", "test/y.inc", "tests/z.inc",
+	} {
+		os.MkdirAll(filepath.Dir(filepath.Join(tempDir, rel)), 0755)
+		os.WriteFile(filepath.Join(tempDir, rel), []byte("x"), 0644)
+	}
+
+	tests := []struct {
+		pattern string
+		exclude []string
+		want    int
+	}{
+		{"src/**/*.{pas,dfm}", nil, 2},
+		{"**/test/**/*.inc", nil, 2},
+		{"*.@(pas|dfm)", nil, 3},
+		{"*.pas", []string{"lib/**"}, 1},
+		{"**/*.{pas,dfm,txt}", []string{"forms", "!forms/*.dfm"}, 2},
+	}
+	for _, tt := range tests {
+		_, output, _ := h.HandleSearchFiles(context.Background(), nil, SearchFilesInput{
+			Path:            tempDir,
+			Pattern:         tt.pattern,
+			ExcludePatterns: tt.exclude,
+		})
+		if len(output.Files) != tt.want {
+			t.Errorf("%s (exclude %v): expected %d files, got %v", tt.pattern, tt.exclude, tt.want, output.Files)
+		}
+	}
+}
+
+func TestHandleSearchFiles_InvalidPattern(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+
+	result, _, _ := h.HandleSearchFiles(context.Background(), nil, SearchFilesInput{Path: tempDir, Pattern: "*.{pas"})
+	if !result.IsError {
+		t.Error("expected error for unclosed brace")
+	}
+}
+
+func TestHandleSearchFiles_RespectIgnoreFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+
+	for _, rel := range []string{"a.txt", "build/b.txt", "docs/c.txt", "docs/d.txt"} {
+		os.MkdirAll(filepath.Dir(filepath.Join(tempDir, rel)), 0755)
+		os.WriteFile(filepath.Join(tempDir, rel), []byte("x"), 0644)
+	}
+	os.WriteFile(filepath.Join(tempDir, ".ignore"), []byte("build/\n"), 0644)
+	os.WriteFile(filepath.Join(tempDir, "docs", ".gitignore"), []byte("d.txt\n"), 0644)
+
+	_, output, _ := h.HandleSearchFiles(context.Background(), nil, SearchFilesInput{Path: tempDir, Pattern: "*.txt"})
+	if len(output.Files) != 2 {
+		t.Errorf("expected a.txt and docs/c.txt, got %v", output.Files)
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"strings"
 
 	"github.com/dimitar-grigorov/mcp-file-tools/internal/encoding"
+	"github.com/dimitar-grigorov/mcp-file-tools/internal/glob"
+	"github.com/dimitar-grigorov/mcp-file-tools/internal/ignore"
 	"github.com/dimitar-grigorov/mcp-file-tools/internal/security"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
@@ -32,11 +34,18 @@ func (h *Handler) HandleTree(ctx context.Context, req *mcp.CallToolRequest, inpu
 	if maxFiles == 0 {
 		maxFiles = defaultMaxFiles
 	}
+	exclude, err := glob.CompileSet(input.Exclude)
+	if err != nil {
+		return errorResult(fmt.Sprintf("invalid exclude: %v", err)), TreeOutput{}, nil
+	}
+	respectIgnoreFiles := input.RespectIgnoreFiles == nil || *input.RespectIgnoreFiles // default: true
 	state := &treeState{
+		root:         v.Path,
 		maxFiles:     maxFiles,
 		maxDepth:     input.MaxDepth,
 		dirsOnly:     input.DirsOnly,
-		exclude:      input.Exclude,
+		exclude:      exclude,
+		ignore:       h.ignoreMatcher(v.Path, respectIgnoreFiles),
 		showEncoding: input.ShowEncoding,
 		allowedDirs:  h.ResolvedAllowedDirs(),
 		fileCount:    0,
@@ -54,10 +63,12 @@ func (h *Handler) HandleTree(ctx context.Context, req *mcp.CallToolRequest, inpu
 }
 
 type treeState struct {
+	root         string
 	maxFiles     int
 	maxDepth     int
 	dirsOnly     bool
-	exclude      []string
+	exclude      *glob.Set
+	ignore       *ignore.Matcher
 	showEncoding bool
 	allowedDirs  []string
 	fileCount    int
@@ -93,7 +104,7 @@ func buildCompactTree(ctx context.Context, sb *strings.Builder, dirPath string,
 			return
 		}
 		name := entry.Name()
-		if shouldExcludeTree(name, state.exclude) {
+		if state.excluded(filepath.Join(dirPath, name), entry.IsDir()) {
 			continue
 		}
 		if entry.IsDir() {
@@ -133,14 +144,19 @@ func detectFileEncoding(path string) string {
 	return result.Charset
 }
 
-func shouldExcludeTree(name string, patterns []string) bool {
-	for _, pattern := range patterns {
-		if name == pattern {
-			return true
-		}
-		if matched, _ := filepath.Match(pattern, name); matched {
-			return true
-		}
+// excluded reports whether a path matches the exclude patterns (relative to the
+// tree root) or an ignore file.
+func (s *treeState) excluded(path string, isDir bool) bool {
+	if s.ignore.Ignored(path, isDir) {
+		return true
+	}
+	rel, err := filepath.Rel(s.root, path)
+	if err != nil {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	if isDir {
+		return s.exclude.MatchDir(rel)
 	}
-	return false
+	return s.exclude.Match(rel)
 }
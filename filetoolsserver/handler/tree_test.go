@@ -152,3 +152,45 @@ func TestHandleTree_ShowEncoding(t *testing.T) {
 		t.Error("expected no encoding annotations when showEncoding=false")
 	}
 }
+
+func TestHandleTree_ExcludePathPattern(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+
+	os.MkdirAll(filepath.Join(tempDir, "src", "gen"), 0755)
+	os.MkdirAll(filepath.Join(tempDir, "gen"), 0755)
+	os.WriteFile(filepath.Join(tempDir, "src", "main.pas"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(tempDir, "src", "main.~pas"), []byte(""), 0644)
+
+	input := TreeInput{Path: tempDir, Exclude: []string{"src/gen", "*.{~pas,bak}"}}
+	_, output, _ := h.HandleTree(context.Background(), nil, input)
+
+	if output.DirCount != 2 || output.FileCount != 1 {
+		t.Errorf("expected 2 dirs and 1 file, got %d dirs and %d files:\n%s", output.DirCount, output.FileCount, output.Tree)
+	}
+	if !strings.Contains(output.Tree, "gen/") || strings.Contains(output.Tree, "~pas") {
+		t.Errorf("unexpected tree:\n%s", output.Tree)
+	}
+}
+
+func TestHandleTree_RespectIgnoreFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	h := NewHandler([]string{tempDir})
+
+	os.MkdirAll(filepath.Join(tempDir, "bin"), 0755)
+	os.MkdirAll(filepath.Join(tempDir, "src"), 0755)
+	os.WriteFile(filepath.Join(tempDir, "src", "main.pas"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(tempDir, "src", "main.dcu"), []byte(""), 0644)
+	os.WriteFile(filepath.Join(tempDir, ".gitignore"), []byte("/bin\n*.dcu\n"), 0644)
+
+	_, output, _ := h.HandleTree(context.Background(), nil, TreeInput{Path: tempDir})
+	if strings.Contains(output.Tree, "bin/") || strings.Contains(output.Tree, "main.dcu") || !strings.Contains(output.Tree, "main.pas") {
+		t.Errorf("ignore files not honored:\n%s", output.Tree)
+	}
+
+	respect := false
+	_, output, _ = h.HandleTree(context.Background(), nil, TreeInput{Path: tempDir, RespectIgnoreFiles: &respect})
+	if !strings.Contains(output.Tree, "bin/") || !strings.Contains(output.Tree, "main.dcu") {
+		t.Errorf("expected ignored entries with respectIgnoreFiles=false:\n%s", output.Tree)
+	}
+}
@@ -1,6 +1,10 @@
 package handler
 
-import "github.com/dimitar-grigorov/mcp-file-tools/internal/encoding"
+import (
+	"encoding/json"
+
+	"github.com/dimitar-grigorov/mcp-file-tools/internal/encoding"
+)
 
 // ReadTextFileInput for reading files with encoding support.
 // Offset/Limit are 1-indexed line numbers for partial reads.
@@ -10,6 +14,8 @@ type ReadTextFileInput struct {
 	Offset        *int   `json:"offset,omitempty"`
 	Limit         *int   `json:"limit,omitempty"`
 	MaxCharacters *int   `json:"maxCharacters,omitempty"`
+	MixedEncoding bool   `json:"mixedEncoding,omitempty"` // decode each encoding region separately
+	Strict        bool   `json:"strict,omitempty"`        // fail on invalid byte sequences
 }
 
 type ReadTextFileOutput struct {
@@ -21,6 +27,33 @@ type ReadTextFileOutput struct {
 	Truncated          bool   `json:"truncated,omitempty"`
 	DetectedEncoding   string `json:"detectedEncoding,omitempty"`
 	EncodingConfidence int    `json:"encodingConfidence,omitempty"`
+
+	EncodingRegions  []EncodingRegion      `json:"encodingRegions,omitempty"` // only with mixedEncoding
+	InvalidSequences *InvalidSequencesInfo `json:"invalidSequences,omitempty"`
+}
+
+// InvalidSequencesInfo reports bytes that are not valid in the file's encoding.
+// Consecutive invalid bytes count as one sequence; only the first few positions are listed.
+type InvalidSequencesInfo struct {
+	Count     int            `json:"count"`
+	Positions []TextPosition `json:"positions"`
+}
+
+// TextPosition is a 1-indexed line and column (in characters).
+type TextPosition struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// EncodingRegion is a range of whole lines sharing one encoding.
+// Byte offsets are 0-indexed (end exclusive), lines 1-indexed (end inclusive).
+type EncodingRegion struct {
+	Encoding   string `json:"encoding"`
+	Confidence int    `json:"confidence"`
+	StartByte  int64  `json:"startByte"`
+	EndByte    int64  `json:"endByte"`
+	StartLine  int    `json:"startLine"`
+	EndLine    int    `json:"endLine"`
 }
 
 // WriteFileInput - encoding defaults to cp1251 for legacy codebases
@@ -49,7 +82,7 @@ type ListEncodingsOutput struct {
 	Encodings []encoding.EncodingListItem `json:"encodings"`
 }
 
-// DetectEncodingInput supports three modes: "sample" (default), "chunked", "full"
+// DetectEncodingInput supports four modes: "sample" (default), "chunked", "full", "regions"
 type DetectEncodingInput struct {
 	Path string `json:"path"`
 	Mode string `json:"mode,omitempty"`
@@ -59,6 +92,8 @@ type DetectEncodingOutput struct {
 	Encoding   string `json:"encoding"`
 	Confidence int    `json:"confidence"`
 	HasBOM     bool   `json:"has_bom"`
+
+	Regions []EncodingRegion `json:"regions,omitempty"` // only in "regions" mode
 }
 
 type ListAllowedDirectoriesInput struct{}
@@ -70,22 +105,99 @@ type ListAllowedDirectoriesOutput struct {
 
 type GetFileInfoInput struct {
 	Path string `json:"path"`
+	// TextStats adds the encoding, BOM, line endings and line count of a text
+	// file, which requires reading it
+	TextStats bool `json:"textStats,omitempty"`
 }
 
 type GetFileInfoOutput struct {
 	Size        int64  `json:"size"`
-	Created     string `json:"created"`
+	Created     string `json:"created,omitempty"` // birth time, empty where the file system does not record it
 	Modified    string `json:"modified"`
 	Accessed    string `json:"accessed"`
+	Changed     string `json:"changed,omitempty"` // last status change (ctime), Unix only
 	IsDirectory bool   `json:"isDirectory"`
 	IsFile      bool   `json:"isFile"`
 	Permissions string `json:"permissions"`
+	// LinkType is "symlink" when the path is a symbolic link, whose target the
+	// other fields describe, or "hardlink" for a file with several hard links
+	LinkType     string `json:"linkType,omitempty"`
+	LinkTarget   string `json:"linkTarget,omitempty"`   // as stored in the link, may be relative
+	ResolvedPath string `json:"resolvedPath,omitempty"` // the file the link resolves to
+	HardLinks    int    `json:"hardLinks,omitempty"`    // files only
+	// Ownership is reported on Unix only; names are empty when not resolvable
+	UID   *int   `json:"uid,omitempty"`
+	GID   *int   `json:"gid,omitempty"`
+	Owner string `json:"owner,omitempty"`
+	Group string `json:"group,omitempty"`
+	// Inode and Device identify the file; on Windows they are the file index
+	// and volume serial number
+	Inode    uint64         `json:"inode,omitempty"`
+	Device   uint64         `json:"device,omitempty"`
+	MimeType string         `json:"mimeType,omitempty"` // sniffed from the content, files only
+	Text     *FileTextStats `json:"text,omitempty"`
+}
+
+// FileTextStats describes the content of a text file. Files larger than the
+// memory threshold get the encoding only, detected from a sample.
+type FileTextStats struct {
+	Encoding    string `json:"encoding,omitempty"`
+	HasBOM      bool   `json:"hasBom"`
+	LineEndings string `json:"lineEndings,omitempty"` // "crlf", "lf", "cr", "mixed" or "none"
+	LineCount   int    `json:"lineCount,omitempty"`
+}
+
+// CreateSymlinkInput creates a symbolic link at Path pointing to Target. A
+// relative Target is relative to the link's directory.
+type CreateSymlinkInput struct {
+	Path   string `json:"path"`
+	Target string `json:"target"`
+}
+
+type CreateSymlinkOutput struct {
+	Message        string `json:"message"`
+	ResolvedTarget string `json:"resolvedTarget"`
+}
+
+type ReadLinkInput struct {
+	Path string `json:"path"`
+}
+
+type ReadLinkOutput struct {
+	Target         string `json:"target"`                   // as stored in the link, may be relative
+	ResolvedTarget string `json:"resolvedTarget,omitempty"` // for a broken link, where the target would be
+	Exists         bool   `json:"exists,omitempty"`
+	// A link resolving outside allowed directories cannot be followed by other
+	// tools; for such a link only Target is set
+	InsideAllowedDirectories bool `json:"insideAllowedDirectories"`
+}
+
+// GlobList is a list of glob patterns (see internal/glob). In JSON it is an array
+// or, for a single pattern, a plain string.
+type GlobList []string
+
+func (g *GlobList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*g = nil
+		if single != "" {
+			*g = GlobList{single}
+		}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*g = list
+	return nil
 }
 
 // DirectoryTreeInput - deprecated, use TreeInput instead
 type DirectoryTreeInput struct {
-	Path            string   `json:"path"`
-	ExcludePatterns []string `json:"excludePatterns,omitempty"`
+	Path               string   `json:"path"`
+	ExcludePatterns    GlobList `json:"excludePatterns,omitempty"`
+	RespectIgnoreFiles *bool    `json:"respectIgnoreFiles,omitempty"` // default: true
 }
 
 type DirectoryTreeOutput struct {
@@ -106,21 +218,29 @@ type CreateDirectoryOutput struct {
 	Message string `json:"message"`
 }
 
+// MoveFileInput moves a file or directory. A destination that is an existing
+// directory means into that directory.
 type MoveFileInput struct {
 	Source      string `json:"source"`
 	Destination string `json:"destination"`
+	Overwrite   string `json:"overwrite,omitempty"` // "fail" (default), "skip", "overwrite", "overwrite-if-newer", "rename-with-suffix" or "backup-existing"
 }
 
 type MoveFileOutput struct {
-	Message string `json:"message"`
+	Message     string `json:"message"`
+	Strategy    string `json:"strategy,omitempty"` // "rename", or "copy" across file systems
+	Destination string `json:"destination,omitempty"`
+	BackupPath  string `json:"backupPath,omitempty"`
+	Skipped     bool   `json:"skipped,omitempty"` // overwrite "skip", or "overwrite-if-newer" and the destination is up to date
 }
 
 // SearchFilesInput - pattern supports *.ext and **/*.ext syntax
 type SearchFilesInput struct {
-	Path            string   `json:"path"`
-	Pattern         string   `json:"pattern"`
-	ExcludePatterns []string `json:"excludePatterns,omitempty"`
-	MaxResults      int      `json:"maxResults,omitempty"`
+	Path               string   `json:"path"`
+	Pattern            string   `json:"pattern"`
+	ExcludePatterns    GlobList `json:"excludePatterns,omitempty"`
+	MaxResults         int      `json:"maxResults,omitempty"`
+	RespectIgnoreFiles *bool    `json:"respectIgnoreFiles,omitempty"` // default: true
 }
 
 type SearchFilesOutput struct {
@@ -128,6 +248,31 @@ type SearchFilesOutput struct {
 	Truncated bool     `json:"truncated,omitempty"`
 }
 
+// ListChangedFilesInput lists changes since a token returned by an earlier call
+// or an RFC 3339 timestamp. Without Since only a token is returned.
+type ListChangedFilesInput struct {
+	Since      string   `json:"since,omitempty"`
+	Paths      []string `json:"paths,omitempty"` // default: all allowed directories
+	MaxResults int      `json:"maxResults,omitempty"`
+}
+
+// ListChangedFilesOutput - Source is "watcher" or "scan" (by modification time,
+// without deletions). Token is passed as Since to continue from here.
+type ListChangedFilesOutput struct {
+	Changes   []FileChange `json:"changes"`
+	Token     string       `json:"token,omitempty"`
+	Source    string       `json:"source"`
+	Truncated bool         `json:"truncated,omitempty"`
+	Message   string       `json:"message,omitempty"`
+}
+
+// FileChange is the net change of one path - Type is "created", "modified" or "deleted".
+type FileChange struct {
+	Path string `json:"path"`
+	Type string `json:"type"`
+	Time string `json:"time"`
+}
+
 type EditOperation struct {
 	OldText string `json:"oldText"`
 	NewText string `json:"newText"`
@@ -148,9 +293,96 @@ type EditFileOutput struct {
 	ReadOnlyCleared bool   `json:"readOnlyCleared,omitempty"` // true if read-only flag was cleared
 }
 
+// ReplaceInFilesInput replaces a pattern in every matching file under paths.
+// DryRun defaults to true: the changes are previewed as a diff and only written
+// when called with dryRun=false.
+type ReplaceInFilesInput struct {
+	Pattern            string   `json:"pattern"`
+	Replacement        string   `json:"replacement"` // $1 and ${name} expand to capture groups unless FixedString
+	Paths              []string `json:"paths"`
+	Include            GlobList `json:"include,omitempty"`
+	Exclude            GlobList `json:"exclude,omitempty"`
+	CaseSensitive      *bool    `json:"caseSensitive,omitempty"` // default: true
+	FixedString        bool     `json:"fixedString,omitempty"`   // pattern and replacement are literal text
+	WordBoundary       bool     `json:"wordBoundary,omitempty"`
+	Multiline          bool     `json:"multiline,omitempty"` // matches may span lines
+	Encoding           string   `json:"encoding,omitempty"`
+	DryRun             *bool    `json:"dryRun,omitempty"`
+	ForceWritable      *bool    `json:"forceWritable,omitempty"`      // default: false - skip read-only files
+	RespectIgnoreFiles *bool    `json:"respectIgnoreFiles,omitempty"` // default: true
+}
+
+type ReplaceInFilesOutput struct {
+	Message           string               `json:"message"`
+	Files             []ReplaceFileResult  `json:"files"`
+	TotalReplacements int                  `json:"totalReplacements"`
+	FilesSearched     int                  `json:"filesSearched"`
+	FilesChanged      int                  `json:"filesChanged"`
+	Skipped           []ReplaceSkippedFile `json:"skipped,omitempty"`
+	Diff              string               `json:"diff,omitempty"`
+	Applied           bool                 `json:"applied"`
+}
+
+// ReplaceFileResult is the number of replacements made (or previewed) in one file.
+type ReplaceFileResult struct {
+	Path         string `json:"path"`
+	Encoding     string `json:"encoding"`
+	Replacements int    `json:"replacements"`
+}
+
+// ReplaceSkippedFile is a file that was not changed, with the reason.
+type ReplaceSkippedFile struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
+// NormalizeTreeInput rewrites every matching file under paths to a target
+// profile. Empty or nil profile fields keep what each file has. DryRun defaults
+// to true: the changes are reported and only written when called with
+// dryRun=false.
+type NormalizeTreeInput struct {
+	Paths                  []string `json:"paths"`
+	Include                GlobList `json:"include,omitempty"`
+	Exclude                GlobList `json:"exclude,omitempty"`
+	From                   string   `json:"from,omitempty"`        // source encoding of non-UTF-8 files, auto-detected if omitted
+	Encoding               string   `json:"encoding,omitempty"`    // target encoding
+	BOM                    *bool    `json:"bom,omitempty"`         // add or strip the BOM of Unicode files
+	LineEndings            string   `json:"lineEndings,omitempty"` // "lf", "crlf" or "cr"
+	TrimTrailingWhitespace bool     `json:"trimTrailingWhitespace,omitempty"`
+	FinalNewline           *bool    `json:"finalNewline,omitempty"` // end with a line ending, or with none
+	DryRun                 *bool    `json:"dryRun,omitempty"`
+	ForceWritable          *bool    `json:"forceWritable,omitempty"`      // default: false - skip read-only files
+	RespectIgnoreFiles     *bool    `json:"respectIgnoreFiles,omitempty"` // default: true
+}
+
+type NormalizeTreeOutput struct {
+	Message      string                 `json:"message"`
+	Files        []NormalizeFileResult  `json:"files"`
+	FilesScanned int                    `json:"filesScanned"`
+	FilesChanged int                    `json:"filesChanged"`
+	Skipped      []NormalizeSkippedFile `json:"skipped,omitempty"`
+	Applied      bool                   `json:"applied"`
+}
+
+// NormalizeFileResult lists what was changed (or would be) in one file, e.g.
+// "encoding windows-1251 -> utf-8" or "trailing whitespace on 3 lines".
+type NormalizeFileResult struct {
+	Path            string   `json:"path"`
+	Encoding        string   `json:"encoding"` // encoding the file was read with
+	Changes         []string `json:"changes"`
+	ReadOnlyCleared bool     `json:"readOnlyCleared,omitempty"` // true if read-only flag was cleared
+}
+
+// NormalizeSkippedFile is a file that was not normalized, with the reason.
+type NormalizeSkippedFile struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+}
+
 type ReadMultipleFilesInput struct {
 	Paths    []string `json:"paths"`
 	Encoding string   `json:"encoding,omitempty"`
+	Strict   bool     `json:"strict,omitempty"` // fail files with invalid byte sequences
 }
 
 // Error codes for programmatic error handling
@@ -173,6 +405,8 @@ type FileReadResult struct {
 	ErrorCode          string `json:"errorCode,omitempty"` // Machine-readable error code
 	DetectedEncoding   string `json:"detectedEncoding,omitempty"`
 	EncodingConfidence int    `json:"encodingConfidence,omitempty"`
+
+	InvalidSequences *InvalidSequencesInfo `json:"invalidSequences,omitempty"`
 }
 
 type ReadMultipleFilesOutput struct {
@@ -184,12 +418,13 @@ type ReadMultipleFilesOutput struct {
 
 // TreeInput for compact tree view. MaxFiles defaults to 1000.
 type TreeInput struct {
-	Path         string   `json:"path"`
-	MaxDepth     int      `json:"maxDepth,omitempty"`
-	MaxFiles     int      `json:"maxFiles,omitempty"`
-	DirsOnly     bool     `json:"dirsOnly,omitempty"`
-	Exclude      []string `json:"exclude,omitempty"`
-	ShowEncoding bool     `json:"showEncoding,omitempty"`
+	Path               string   `json:"path"`
+	MaxDepth           int      `json:"maxDepth,omitempty"`
+	MaxFiles           int      `json:"maxFiles,omitempty"`
+	DirsOnly           bool     `json:"dirsOnly,omitempty"`
+	Exclude            GlobList `json:"exclude,omitempty"`
+	ShowEncoding       bool     `json:"showEncoding,omitempty"`
+	RespectIgnoreFiles *bool    `json:"respectIgnoreFiles,omitempty"` // default: true
 }
 
 type TreeOutput struct {
@@ -207,13 +442,98 @@ type DeleteFileOutput struct {
 	Message string `json:"message"`
 }
 
+// DeleteDirectoryInput deletes a directory. Without Recursive only an empty
+// directory is deleted; MaxFiles guards recursive deletes.
+type DeleteDirectoryInput struct {
+	Path      string `json:"path"`
+	Recursive bool   `json:"recursive,omitempty"`
+	MaxFiles  int    `json:"maxFiles,omitempty"` // default: 1000
+	DryRun    bool   `json:"dryRun,omitempty"`
+}
+
+type DeleteDirectoryOutput struct {
+	Message            string   `json:"message"`
+	FilesDeleted       int      `json:"filesDeleted"`
+	DirectoriesDeleted int      `json:"directoriesDeleted"` // including the directory itself
+	Paths              []string `json:"paths,omitempty"`    // dry run only: everything that would be deleted
+	Applied            bool     `json:"applied"`
+}
+
+// RenameFilesInput renames the files in Path whose names match Pattern, a regex
+// or, with Glob, a glob whose * and ? are the capture groups. DryRun defaults
+// to true.
+type RenameFilesInput struct {
+	Path               string `json:"path"`
+	Pattern            string `json:"pattern"`
+	Replacement        string `json:"replacement"` // $1 and ${name} expand to capture groups
+	Glob               bool   `json:"glob,omitempty"`
+	CaseSensitive      *bool  `json:"caseSensitive,omitempty"` // default: true
+	Recursive          bool   `json:"recursive,omitempty"`     // also files in subdirectories, renamed in place
+	DryRun             *bool  `json:"dryRun,omitempty"`
+	RespectIgnoreFiles *bool  `json:"respectIgnoreFiles,omitempty"` // default: true
+}
+
+type RenameFilesOutput struct {
+	Message      string       `json:"message"`
+	Renames      []FileRename `json:"renames"`
+	FilesMatched int          `json:"filesMatched"`
+	Cycles       int          `json:"cycles,omitempty"` // e.g. two files swapping names
+	Applied      bool         `json:"applied"`
+}
+
+// FileRename is one rename of a plan.
+type FileRename struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// CopyFileInput copies a file. A destination that is an existing directory
+// means into that directory.
 type CopyFileInput struct {
 	Source      string `json:"source"`
 	Destination string `json:"destination"`
+	Overwrite   string `json:"overwrite,omitempty"` // "fail" (default), "skip", "overwrite", "overwrite-if-newer", "rename-with-suffix" or "backup-existing"
 }
 
 type CopyFileOutput struct {
-	Message string `json:"message"`
+	Message     string `json:"message"`
+	Destination string `json:"destination,omitempty"`
+	BackupPath  string `json:"backupPath,omitempty"`
+	Skipped     bool   `json:"skipped,omitempty"` // overwrite "skip", or "overwrite-if-newer" and the destination is up to date
+}
+
+// CopyDirectoryInput copies a directory tree. Overwrite decides what happens to
+// files that already exist in the destination. With TargetEncoding, text files
+// are transcoded while copying.
+type CopyDirectoryInput struct {
+	Source             string   `json:"source"`
+	Destination        string   `json:"destination"`
+	Include            GlobList `json:"include,omitempty"`
+	Exclude            GlobList `json:"exclude,omitempty"`
+	Overwrite          string   `json:"overwrite,omitempty"`      // as for copy_file
+	TargetEncoding     string   `json:"targetEncoding,omitempty"` // transcode text files to this encoding
+	From               string   `json:"from,omitempty"`           // source encoding of non-UTF-8 files, auto-detected if omitted
+	DryRun             bool     `json:"dryRun,omitempty"`
+	RespectIgnoreFiles *bool    `json:"respectIgnoreFiles,omitempty"` // default: true
+}
+
+type CopyDirectoryOutput struct {
+	Message            string            `json:"message"`
+	FilesCopied        int               `json:"filesCopied"`
+	FilesConverted     int               `json:"filesConverted,omitempty"` // of filesCopied, transcoded to targetEncoding
+	DirectoriesCreated int               `json:"directoriesCreated"`
+	Skipped            []CopySkippedFile `json:"skipped,omitempty"`      // not copied
+	NotConverted       []CopySkippedFile `json:"notConverted,omitempty"` // text files copied as is despite targetEncoding
+	Paths              []string          `json:"paths,omitempty"`        // dry run only: destination files that would be written
+	Backups            []string          `json:"backups,omitempty"`      // overwrite "backup-existing": where existing files were moved
+	Applied            bool              `json:"applied"`
+}
+
+// CopySkippedFile is a source file that was not copied, or not converted, with
+// the reason.
+type CopySkippedFile struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
 }
 
 // ConvertEncodingInput converts between encodings. From is auto-detected if empty.
@@ -233,25 +553,41 @@ type ConvertEncodingOutput struct {
 
 // GrepInput for searching file contents with regex
 type GrepInput struct {
-	Pattern       string   `json:"pattern"`
-	Paths         []string `json:"paths"`
-	CaseSensitive *bool    `json:"caseSensitive,omitempty"` // defaults to true
-	ContextBefore int      `json:"contextBefore,omitempty"`
-	ContextAfter  int      `json:"contextAfter,omitempty"`
-	MaxMatches    int      `json:"maxMatches,omitempty"` // defaults to 1000
-	Include       string   `json:"include,omitempty"`
-	Exclude       string   `json:"exclude,omitempty"`
-	Encoding      string   `json:"encoding,omitempty"`
+	Pattern            string   `json:"pattern,omitempty"`
+	Patterns           []string `json:"patterns,omitempty"`     // additional patterns
+	PatternMode        string   `json:"patternMode,omitempty"`  // "any" (default) or "all"
+	Multiline          bool     `json:"multiline,omitempty"`    // match against whole file content
+	FixedString        bool     `json:"fixedString,omitempty"`  // patterns are literal text
+	WordBoundary       bool     `json:"wordBoundary,omitempty"` // match whole words only
+	InvertMatch        bool     `json:"invertMatch,omitempty"`  // select lines matching no pattern
+	OutputMode         string   `json:"outputMode,omitempty"`   // "matches" (default), "filesWithMatches", "count"
+	Paths              []string `json:"paths"`
+	CaseSensitive      *bool    `json:"caseSensitive,omitempty"` // defaults to true
+	ContextBefore      int      `json:"contextBefore,omitempty"`
+	ContextAfter       int      `json:"contextAfter,omitempty"`
+	MaxMatches         int      `json:"maxMatches,omitempty"`        // defaults to 1000; matches mode only
+	MaxMatchesPerFile  int      `json:"maxMatchesPerFile,omitempty"` // matches mode only
+	Format             string   `json:"format,omitempty"`            // "flat" (default), "grouped", "text"
+	Include            GlobList `json:"include,omitempty"`
+	Exclude            GlobList `json:"exclude,omitempty"`
+	Encoding           string   `json:"encoding,omitempty"`
+	Strict             bool     `json:"strict,omitempty"`             // skip files with invalid byte sequences
+	RespectIgnoreFiles *bool    `json:"respectIgnoreFiles,omitempty"` // default: true
 }
 
 type GrepMatch struct {
-	Path     string   `json:"path"`
+	Path     string   `json:"path,omitempty"` // omitted inside groups
 	Line     int      `json:"line"`
 	Column   int      `json:"column"`
 	Text     string   `json:"text"`
 	Before   []string `json:"before,omitempty"`
 	After    []string `json:"after,omitempty"`
 	Encoding string   `json:"encoding,omitempty"`
+	Pattern  string   `json:"pattern,omitempty"` // matching pattern, when several were given
+
+	// Multiline mode only: position of the last character of the match
+	EndLine   int `json:"endLine,omitempty"`
+	EndColumn int `json:"endColumn,omitempty"`
 }
 
 type GrepOutput struct {
@@ -260,6 +596,37 @@ type GrepOutput struct {
 	FilesSearched int         `json:"filesSearched"`
 	FilesMatched  int         `json:"filesMatched"`
 	Truncated     bool        `json:"truncated,omitempty"`
+
+	EncodingIssues []GrepEncodingIssue `json:"encodingIssues,omitempty"`
+	SkippedFiles   []string            `json:"skippedFiles,omitempty"` // too large for multiline mode
+
+	Files  []string        `json:"files,omitempty"`  // filesWithMatches mode
+	Counts []GrepFileCount `json:"counts,omitempty"` // count mode
+	Groups []GrepFileGroup `json:"groups,omitempty"` // grouped format
+}
+
+// GrepFileGroup holds the matches of one file in grouped format.
+// Truncated is set when the file has more matches than returned.
+type GrepFileGroup struct {
+	Path       string      `json:"path"`
+	Encoding   string      `json:"encoding,omitempty"`
+	MatchCount int         `json:"matchCount"`
+	Truncated  bool        `json:"truncated,omitempty"`
+	Matches    []GrepMatch `json:"matches"`
+}
+
+type GrepFileCount struct {
+	Path  string `json:"path"`
+	Count int    `json:"count"`
+}
+
+// GrepEncodingIssue reports a searched file with invalid byte sequences.
+// In strict mode the file is skipped and none of its matches are returned.
+type GrepEncodingIssue struct {
+	Path             string               `json:"path"`
+	Encoding         string               `json:"encoding"`
+	InvalidSequences InvalidSequencesInfo `json:"invalidSequences"`
+	Skipped          bool                 `json:"skipped,omitempty"`
 }
 
 type DetectLineEndingsInput struct {
@@ -267,17 +634,18 @@ type DetectLineEndingsInput struct {
 }
 
 // ChangeLineEndingsInput converts line endings in a file.
-// Style must be "lf" or "crlf".
+// Style must be "lf", "crlf" or "cr".
 type ChangeLineEndingsInput struct {
 	Path  string `json:"path"`
 	Style string `json:"style"`
 }
 
 type ChangeLineEndingsOutput struct {
-	Message       string `json:"message"`
-	OriginalStyle string `json:"originalStyle"`
-	NewStyle      string `json:"newStyle"`
-	LinesChanged  int    `json:"linesChanged"`
+	Message           string `json:"message"`
+	OriginalStyle     string `json:"originalStyle"`
+	NewStyle          string `json:"newStyle"`
+	LinesChanged      int    `json:"linesChanged"`
+	UnicodeSeparators int    `json:"unicodeSeparators,omitempty"` // NEL, LS and PS replaced with line endings
 }
 
 // ManageBomInput manages Unicode BOM (Byte Order Mark) in files.
@@ -297,10 +665,37 @@ type ManageBomOutput struct {
 	Changed  bool   `json:"changed"`
 }
 
-// DetectLineEndingsOutput - Style is "crlf", "lf", "mixed", or "none"
+// DetectLineEndingsOutput - Style is "crlf", "lf", "cr", "mixed", or "none"
 type DetectLineEndingsOutput struct {
-	Style             string `json:"style"`
-	TotalLines        int    `json:"totalLines"`
-	InconsistentLines []int  `json:"inconsistentLines"`
+	Style             string           `json:"style"`
+	TotalLines        int              `json:"totalLines"`
+	InconsistentLines []int            `json:"inconsistentLines"`
+	Counts            LineEndingCounts `json:"counts"`
+}
+
+// LineEndingCounts counts each kind of line ending. Unicode counts NEL (U+0085),
+// LS (U+2028) and PS (U+2029) separators, which do not end lines.
+type LineEndingCounts struct {
+	CRLF    int `json:"crlf"`
+	LF      int `json:"lf"`
+	CR      int `json:"cr"`
+	Unicode int `json:"unicode"`
+}
+
+// FixMojibakeInput repairs double-encoded text. DryRun defaults to true: the
+// repair is previewed as a diff and only written when called with dryRun=false.
+type FixMojibakeInput struct {
+	Path     string `json:"path"`
+	Encoding string `json:"encoding,omitempty"`
+	DryRun   *bool  `json:"dryRun,omitempty"`
 }
 
+type FixMojibakeOutput struct {
+	Message       string   `json:"message"`
+	Detected      bool     `json:"detected"`
+	Patterns      []string `json:"patterns,omitempty"` // e.g. "utf-8 misread as windows-1252", outermost first
+	SegmentsFixed int      `json:"segmentsFixed"`
+	LinesChanged  int      `json:"linesChanged"`
+	Diff          string   `json:"diff,omitempty"`
+	Applied       bool     `json:"applied"`
+}
@@ -1,8 +1,11 @@
 package handler
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
 
+	"github.com/dimitar-grigorov/mcp-file-tools/internal/security"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
@@ -48,6 +51,40 @@ func (h *Handler) ValidatePath(path string) PathValidationResult {
 	return PathValidationResult{Path: validatedPath}
 }
 
+// ValidateLinkPath validates the path of a link itself rather than what it
+// points to: the parent directory is resolved and must be within allowed
+// directories, while the last element is kept, so a symbolic link is not
+// followed.
+func (h *Handler) ValidateLinkPath(path string) PathValidationResult {
+	if path == "" {
+		return PathValidationResult{
+			Result: errorResult(ErrPathRequired.Error()),
+			Err:    ErrPathRequired,
+		}
+	}
+
+	absolute, err := filepath.Abs(security.ExpandHome(path))
+	if err == nil && filepath.Dir(absolute) == absolute {
+		err = fmt.Errorf("%s is not a valid link path", path)
+	}
+	if err != nil {
+		return PathValidationResult{
+			Result: errorResult(err.Error()),
+			Err:    err,
+		}
+	}
+
+	parent, err := h.validatePath(filepath.Dir(absolute))
+	if err != nil {
+		return PathValidationResult{
+			Result: errorResult(err.Error()),
+			Err:    err,
+		}
+	}
+
+	return PathValidationResult{Path: filepath.Join(parent, filepath.Base(absolute))}
+}
+
 // ValidateSourceDest validates both source and destination paths.
 func (h *Handler) ValidateSourceDest(source, destination string) (PathValidationResult, PathValidationResult) {
 	srcResult := h.validateSourcePath(source)
@@ -0,0 +1,88 @@
+package filetoolsserver
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/dimitar-grigorov/mcp-file-tools/filetoolsserver/handler"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// fileURITemplate matches file:// URIs of any path; allowed directories are
+// enforced when reading.
+const fileURITemplate = "file:///{+path}"
+
+// pathToFileURI converts a local filesystem path to a file:// URI.
+func pathToFileURI(path string) string {
+	p := filepath.ToSlash(path)
+	// Windows: C:/path becomes file:///C:/path
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	return (&url.URL{Scheme: "file", Path: p}).String()
+}
+
+// readFileResource reads file:// resources: text files as decoded UTF-8 text,
+// binary files as blobs and directories as a text/uri-list of their entries.
+func readFileResource(h *handler.Handler) mcp.ResourceHandler {
+	return func(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+		uri := req.Params.URI
+		content, err := h.ReadResource(fileURIToPath(uri))
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, mcp.ResourceNotFoundError(uri)
+		}
+		if err != nil {
+			return nil, err
+		}
+		result := &mcp.ResourceContents{URI: uri, MIMEType: content.MIMEType, Text: content.Text, Blob: content.Blob}
+		if content.IsDir {
+			var list strings.Builder
+			for _, entry := range content.Entries {
+				list.WriteString(pathToFileURI(entry.Path))
+				if entry.IsDir {
+					list.WriteString("/")
+				}
+				list.WriteString("\r\n")
+			}
+			result.Text = list.String()
+		}
+		if content.Encoding != "" {
+			result.Meta = mcp.Meta{"encoding": content.Encoding}
+		}
+		return &mcp.ReadResourceResult{Contents: []*mcp.ResourceContents{result}}, nil
+	}
+}
+
+// rootResources lists every allowed directory as a resource, so clients can
+// browse the tree from there by reading directory resources.
+type rootResources struct {
+	h      *handler.Handler
+	server *mcp.Server
+
+	mu   sync.Mutex
+	uris []string
+}
+
+// sync replaces the listed resources with the current allowed directories.
+func (r *rootResources) sync() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.server.RemoveResources(r.uris...)
+	r.uris = nil
+	for _, dir := range r.h.GetAllowedDirectories() {
+		uri := pathToFileURI(dir)
+		r.server.AddResource(&mcp.Resource{
+			URI:         uri,
+			Name:        filepath.Base(dir),
+			Title:       dir,
+			Description: "Allowed directory. Reading it lists the URIs of its entries.",
+			MIMEType:    handler.MIMETypeDirectory,
+		}, readFileResource(r.h))
+		r.uris = append(r.uris, uri)
+	}
+}
@@ -13,7 +13,7 @@ import (
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
-func createInitializedHandler(h *handler.Handler) func(context.Context, *mcp.InitializedRequest) {
+func createInitializedHandler(h *handler.Handler, resources *rootResources) func(context.Context, *mcp.InitializedRequest) {
 	return func(ctx context.Context, req *mcp.InitializedRequest) {
 		// Async update check — runs regardless of roots support.
 		go handler.CheckForUpdatesAsync(req.Session, Version)
@@ -26,6 +26,7 @@ func createInitializedHandler(h *handler.Handler) func(context.Context, *mcp.Ini
 
 		if len(result.Roots) > 0 {
 			updateAllowedDirectoriesFromRoots(h, result.Roots)
+			resources.sync()
 		} else {
 			currentDirs := h.GetAllowedDirectories()
 			if len(currentDirs) == 0 {
@@ -36,7 +37,7 @@ func createInitializedHandler(h *handler.Handler) func(context.Context, *mcp.Ini
 	}
 }
 
-func createRootsListChangedHandler(h *handler.Handler) func(context.Context, *mcp.RootsListChangedRequest) {
+func createRootsListChangedHandler(h *handler.Handler, resources *rootResources) func(context.Context, *mcp.RootsListChangedRequest) {
 	return func(ctx context.Context, req *mcp.RootsListChangedRequest) {
 		result, err := req.Session.ListRoots(ctx, &mcp.ListRootsParams{})
 		if err != nil {
@@ -45,6 +46,7 @@ func createRootsListChangedHandler(h *handler.Handler) func(context.Context, *mc
 		}
 
 		updateAllowedDirectoriesFromRoots(h, result.Roots)
+		resources.sync()
 	}
 }
 
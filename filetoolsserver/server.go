@@ -1,10 +1,15 @@
 package filetoolsserver
 
 import (
+	"context"
+	"fmt"
 	"log/slog"
+	"os"
+	"reflect"
 
 	"github.com/dimitar-grigorov/mcp-file-tools/filetoolsserver/handler"
 	"github.com/dimitar-grigorov/mcp-file-tools/internal/config"
+	"github.com/google/jsonschema-go/jsonschema"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
@@ -12,14 +17,19 @@ import (
 var Version = "dev"
 
 // Server instructions for AI assistants
-const serverInstructions = `MCP filesystem server with non-UTF-8 encoding support (22 encodings: CP1251, KOI8-R, ISO-8859-x, etc).
+const serverInstructions = `MCP filesystem server with non-UTF-8 encoding support (CP1251, KOI8-R, ISO-8859-x, Shift_JIS, GBK, Big5, EUC-KR, CP437/CP850, etc).
 
 PREFER THESE TOOLS over built-in Read/Write/Grep for file operations when encoding matters:
 - read_text_file: auto-detects encoding, returns UTF-8. Use offset/limit for files >2000 lines.
 - write_file: converts UTF-8 content to target encoding (default: cp1251)
 - edit_file: in-place edits with encoding support, returns unified diff. Use dryRun=true to preview changes before applying.
 - grep_text_files: encoding-aware regex search across files
+- list_changed_files: files changed since a token or timestamp (e.g. edits made in the IDE), instead of re-reading files to find out
+- replace_in_files: search and replace across files, preserving encodings. Use dryRun=true (default) to preview.
+- normalize_tree: bring a whole tree to one encoding/BOM/line ending profile in one call. Use dryRun=true (default) to preview.
+- copy_directory: copy a tree, optionally transcoding text files (e.g. a UTF-8 mirror of a cp1251 project)
 - detect_encoding: diagnose encoding issues (garbled text, � characters)
+- fix_mojibake: repair double-encoded text (e.g. "Ð¿Ñ€Ð¸" instead of Cyrillic)
 
 Workflow for non-UTF-8 files:
 1. detect_encoding - identify file encoding
@@ -35,10 +45,42 @@ func boolPtr(b bool) *bool {
 	return &b
 }
 
+// inputSchema infers a tool's input schema. GlobList fields accept a single
+// pattern string as well as an array.
+func inputSchema[T any]() *jsonschema.Schema {
+	schema, err := jsonschema.For[T](&jsonschema.ForOptions{
+		TypeSchemas: map[reflect.Type]*jsonschema.Schema{
+			reflect.TypeFor[handler.GlobList](): {
+				Types: []string{"string", "array"},
+				Items: &jsonschema.Schema{Type: "string"},
+			},
+		},
+	})
+	if err != nil {
+		panic(fmt.Sprintf("failed to infer input schema: %v", err))
+	}
+	return schema
+}
+
 // NewServer creates a new MCP server with all file tools registered.
 // If logger is nil, logging middleware is disabled but recovery is still active.
 // If cfg is nil, configuration is loaded from environment variables.
+// The directory watcher it starts runs for the life of the process; use Run to
+// stop it when the server shuts down.
 func NewServer(allowedDirs []string, logger *slog.Logger, cfg *config.Config) *mcp.Server {
+	server, _ := newServer(allowedDirs, logger, cfg)
+	return server
+}
+
+// Run creates a server like NewServer and serves transport until the client
+// disconnects or ctx is cancelled, then stops watching the allowed directories.
+func Run(ctx context.Context, transport mcp.Transport, allowedDirs []string, logger *slog.Logger, cfg *config.Config) error {
+	server, h := newServer(allowedDirs, logger, cfg)
+	defer h.StopWatching()
+	return server.Run(ctx, transport)
+}
+
+func newServer(allowedDirs []string, logger *slog.Logger, cfg *config.Config) (*mcp.Server, *handler.Handler) {
 	var handlerOpts []handler.Option
 	if cfg != nil {
 		handlerOpts = append(handlerOpts, handler.WithConfig(cfg))
@@ -50,21 +92,41 @@ func NewServer(allowedDirs []string, logger *slog.Logger, cfg *config.Config) *m
 		Version: Version,
 	}
 
+	subs := newSubscriptions(h)
+	resources := &rootResources{h: h}
 	serverOpts := &mcp.ServerOptions{
 		Instructions:            serverInstructions,
 		Logger:                  logger,
-		InitializedHandler:      createInitializedHandler(h),
-		RootsListChangedHandler: createRootsListChangedHandler(h),
+		InitializedHandler:      createInitializedHandler(h, resources),
+		RootsListChangedHandler: createRootsListChangedHandler(h, resources),
+		SubscribeHandler:        subs.subscribe,
+		UnsubscribeHandler:      subs.unsubscribe,
 	}
 	server := mcp.NewServer(impl, serverOpts)
 
+	// Files are resources too: allowed directories are listed, any file below
+	// them can be read (decoded to UTF-8) and subscribed to by URI
+	server.AddResourceTemplate(&mcp.ResourceTemplate{
+		URITemplate: fileURITemplate,
+		Name:        "file",
+		Title:       "Files in allowed directories",
+		Description: "A file read as UTF-8 text (encoding auto-detected, reported in _meta.encoding), a binary file as a blob, or a directory as a text/uri-list of its entries.",
+	}, readFileResource(h))
+	resources.server = server
+	resources.sync()
+
+	// Changes in allowed directories back list_changed_files and resource notifications
+	if err := h.StartWatching(subs.notifier(server)); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to watch for file changes: %v\n", err)
+	}
+
 	// Register all tools using the new AddTool API with annotations
 	// All handlers are wrapped with recovery middleware (and logging if logger is provided)
 
 	// Read-only tools
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "read_text_file",
-		Description: "Read file with encoding auto-detection, converts to UTF-8. PREFER THIS over built-in Read for non-UTF-8 files (Cyrillic, legacy codebases). For files >2000 lines, use offset/limit to paginate. Returns totalLines and fileSizeBytes for planning subsequent reads. Use maxCharacters to cap output size and prevent token overflow. Parameters: path (required), encoding (optional, auto-detected), offset (1-indexed start line), limit (max lines to return), maxCharacters (optional, truncates content).",
+		Description: "Read file with encoding auto-detection, converts to UTF-8. PREFER THIS over built-in Read for non-UTF-8 files (Cyrillic, legacy codebases). For files >2000 lines, use offset/limit to paginate. Returns totalLines and fileSizeBytes for planning subsequent reads. Use maxCharacters to cap output size and prevent token overflow. Parameters: path (required), encoding (optional, auto-detected), offset (1-indexed start line), limit (max lines to return), maxCharacters (optional, truncates content), mixedEncoding (optional, decodes UTF-8 and legacy sections of one file separately), strict (optional, fail on invalid byte sequences; otherwise they are reported in invalidSequences).",
 		Annotations: &mcp.ToolAnnotations{
 			Title:         "Read Text File",
 			ReadOnlyHint:  true,
@@ -74,7 +136,7 @@ func NewServer(allowedDirs []string, logger *slog.Logger, cfg *config.Config) *m
 
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "read_multiple_files",
-		Description: "Read multiple files concurrently with encoding support. PREFER THIS when reading several non-UTF-8 files at once. Individual failures don't stop the batch — partial results are returned. Parameters: paths (required array), encoding (optional, auto-detected per file).",
+		Description: "Read multiple files concurrently with encoding support. PREFER THIS when reading several non-UTF-8 files at once. Individual failures don't stop the batch — partial results are returned. Parameters: paths (required array), encoding (optional, auto-detected per file), strict (optional, fail files with invalid byte sequences).",
 		Annotations: &mcp.ToolAnnotations{
 			Title:         "Read Multiple Files",
 			ReadOnlyHint:  true,
@@ -94,7 +156,7 @@ func NewServer(allowedDirs []string, logger *slog.Logger, cfg *config.Config) *m
 
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "list_encodings",
-		Description: "List all 22 supported encodings with name, aliases, and description. Use this to find the correct encoding name for read/write/convert operations.",
+		Description: "List all supported encodings, including registered custom code pages, with name, aliases, and description. Use this to find the correct encoding name for read/write/convert operations.",
 		Annotations: &mcp.ToolAnnotations{
 			Title:         "List Encodings",
 			ReadOnlyHint:  true,
@@ -104,7 +166,7 @@ func NewServer(allowedDirs []string, logger *slog.Logger, cfg *config.Config) *m
 
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "detect_encoding",
-		Description: "Auto-detect file encoding with confidence score (0-100) and BOM detection. ALWAYS use this first when encountering garbled text or � characters. Use before read_text_file to determine the correct encoding. Parameters: path (required), mode (sample=fast default, chunked=thorough, full=entire file).",
+		Description: "Auto-detect file encoding with confidence score (0-100) and BOM detection. ALWAYS use this first when encountering garbled text or � characters. Use before read_text_file to determine the correct encoding. Parameters: path (required), mode (sample=fast default, chunked=thorough, full=entire file, regions=per-region encodings for mixed files).",
 		Annotations: &mcp.ToolAnnotations{
 			Title:         "Detect Encoding",
 			ReadOnlyHint:  true,
@@ -114,7 +176,8 @@ func NewServer(allowedDirs []string, logger *slog.Logger, cfg *config.Config) *m
 
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "grep_text_files",
-		Description: "Regex search in file contents with encoding support. PREFER THIS over built-in Grep when searching non-UTF-8 files or when encoding-aware matching is needed. Parameters: pattern (required regex), paths (required array of files/dirs), caseSensitive (default: true), contextBefore/After (lines), maxMatches (default 1000), include/exclude (globs), encoding.",
+		Description: "Regex search in file contents with encoding support. PREFER THIS over built-in Grep when searching non-UTF-8 files or when encoding-aware matching is needed. Parameters: pattern (required regex), patterns (optional extra regexes) with patternMode (any=default, all=every pattern must match in the file), multiline (match across lines, reports endLine/endColumn), paths (required array of files/dirs), caseSensitive (default: true), fixedString (literal text), wordBoundary (whole words), invertMatch (non-matching lines), outputMode (matches=default, filesWithMatches, count - use the latter two to save tokens), contextBefore/After (lines), maxMatches (default 1000) and maxMatchesPerFile (matches mode only; counts are exact), format (flat=default, grouped=matches per file, text=compact rg-style output), include/exclude (glob string or array, e.g. src/**/*.{pas,dfm}), encoding, strict (skip files with invalid byte sequences; they are listed in encodingIssues), respectIgnoreFiles (default: true, skips .gitignore/.ignore/.mcpignore matches).",
+		InputSchema: inputSchema[handler.GrepInput](),
 		Annotations: &mcp.ToolAnnotations{
 			Title:         "Grep Text Files",
 			ReadOnlyHint:  true,
@@ -134,7 +197,7 @@ func NewServer(allowedDirs []string, logger *slog.Logger, cfg *config.Config) *m
 
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "get_file_info",
-		Description: "Get file/directory metadata: size, timestamps, permissions, type. Use this to check file size before reading large files with read_text_file. Parameter: path (required).",
+		Description: "Get file/directory metadata: size, timestamps (birth time where available, ctime as changed), permissions, owner/group, inode, type, symbolic/hard link details and content-sniffed MIME type. Use this to check file size before reading large files with read_text_file. Parameters: path (required), textStats (optional, adds detected encoding, BOM, line endings and line count of a text file).",
 		Annotations: &mcp.ToolAnnotations{
 			Title:         "Get File Info",
 			ReadOnlyHint:  true,
@@ -142,9 +205,20 @@ func NewServer(allowedDirs []string, logger *slog.Logger, cfg *config.Config) *m
 		},
 	}, handler.Wrap(logger, "get_file_info", h.HandleGetFileInfo))
 
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "read_link",
+		Description: "Read the target of a symbolic link without following it, also for broken links and links leading outside allowed directories (which other tools refuse; for those only the stored target is returned). Parameter: path (required).",
+		Annotations: &mcp.ToolAnnotations{
+			Title:         "Read Link",
+			ReadOnlyHint:  true,
+			OpenWorldHint: boolPtr(false),
+		},
+	}, handler.Wrap(logger, "read_link", h.HandleReadLink))
+
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "directory_tree",
 		Description: "DEPRECATED: Use 'tree' instead (85% fewer tokens). Returns JSON tree structure for compatibility with mcp-js-servers. Parameters: path (required), excludePatterns (optional).",
+		InputSchema: inputSchema[handler.DirectoryTreeInput](),
 		Annotations: &mcp.ToolAnnotations{
 			Title:         "Directory Tree (JSON)",
 			ReadOnlyHint:  true,
@@ -154,7 +228,8 @@ func NewServer(allowedDirs []string, logger *slog.Logger, cfg *config.Config) *m
 
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "tree",
-		Description: "Compact indented tree view of directory structure. Uses 85% fewer tokens than directory_tree — PREFER THIS for directory visualization. Set showEncoding=true to detect and display file encodings (e.g., for auditing legacy codebases). Parameters: path (required), maxDepth (0=unlimited), maxFiles (default 1000), dirsOnly (bool), exclude (array of patterns), showEncoding (bool, shows detected encoding per file).",
+		Description: "Compact indented tree view of directory structure. Uses 85% fewer tokens than directory_tree — PREFER THIS for directory visualization. Set showEncoding=true to detect and display file encodings (e.g., for auditing legacy codebases). Parameters: path (required), maxDepth (0=unlimited), maxFiles (default 1000), dirsOnly (bool), exclude (array of patterns), showEncoding (bool, shows detected encoding per file), respectIgnoreFiles (default: true, skips .gitignore/.ignore/.mcpignore matches).",
+		InputSchema: inputSchema[handler.TreeInput](),
 		Annotations: &mcp.ToolAnnotations{
 			Title:         "Tree (Compact)",
 			ReadOnlyHint:  true,
@@ -164,7 +239,8 @@ func NewServer(allowedDirs []string, logger *slog.Logger, cfg *config.Config) *m
 
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "search_files",
-		Description: "Recursively search for files matching a glob pattern (*.ext or **/*.ext). Returns full paths. Parameters: path (required), pattern (required), excludePatterns, maxResults (default 10000).",
+		Description: "Recursively search for files matching a glob pattern (*.ext, src/**/*.{pas,dfm}, !(*_test).go; supports multiple **, braces, extglob and ! negation). Returns full paths. Parameters: path (required), pattern (required), excludePatterns, maxResults (default 10000), respectIgnoreFiles (default: true, skips .gitignore/.ignore/.mcpignore matches).",
+		InputSchema: inputSchema[handler.SearchFilesInput](),
 		Annotations: &mcp.ToolAnnotations{
 			Title:         "Search Files",
 			ReadOnlyHint:  true,
@@ -172,9 +248,19 @@ func NewServer(allowedDirs []string, logger *slog.Logger, cfg *config.Config) *m
 		},
 	}, handler.Wrap(logger, "search_files", h.HandleSearchFiles))
 
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "list_changed_files",
+		Description: "List files created, modified or deleted since a token or timestamp, e.g. edits made in the IDE, without re-reading files. Call without since to get a token, then pass it as since later. Parameters: since (token from a previous call or RFC 3339 timestamp; times before the server started are answered by scanning modification times, without deletions), paths (optional, limit to these files/dirs), maxResults (default 1000).",
+		Annotations: &mcp.ToolAnnotations{
+			Title:         "List Changed Files",
+			ReadOnlyHint:  true,
+			OpenWorldHint: boolPtr(false),
+		},
+	}, handler.Wrap(logger, "list_changed_files", h.HandleListChangedFiles))
+
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "detect_line_endings",
-		Description: "Detect line ending style (crlf/lf/mixed/none) and find inconsistent lines. Useful for diagnosing mixed line ending issues in cross-platform legacy codebases. Returns dominant style, total lines, and line numbers with minority endings. Parameter: path (required).",
+		Description: "Detect line ending style (crlf/lf/cr/mixed/none) and find inconsistent lines. Useful for diagnosing mixed line ending issues in cross-platform legacy codebases. Returns dominant style, total lines, line numbers with minority endings or Unicode separators (NEL/LS/PS), and counts per style. Parameter: path (required).",
 		Annotations: &mcp.ToolAnnotations{
 			Title:         "Detect Line Endings",
 			ReadOnlyHint:  true,
@@ -197,7 +283,7 @@ func NewServer(allowedDirs []string, logger *slog.Logger, cfg *config.Config) *m
 
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "change_line_endings",
-		Description: "Convert line endings in a file to LF or CRLF. Use after detect_line_endings to fix mixed or wrong line endings. Returns original style, new style, and number of lines changed. No-op if file already uses the target style. Parameters: path (required), style (required: \"lf\" or \"crlf\").",
+		Description: "Convert line endings in a file to LF, CRLF or CR, replacing Unicode separators (NEL/LS/PS) too. Use after detect_line_endings to fix mixed or wrong line endings. Returns original style, new style, and number of lines changed. No-op if file already uses the target style. UTF-16 files are converted as text, keeping their BOM. Parameters: path (required), style (required: \"lf\", \"crlf\" or \"cr\").",
 		Annotations: &mcp.ToolAnnotations{
 			Title:           "Change Line Endings",
 			ReadOnlyHint:    false,
@@ -219,6 +305,18 @@ func NewServer(allowedDirs []string, logger *slog.Logger, cfg *config.Config) *m
 		},
 	}, handler.Wrap(logger, "create_directory", h.HandleCreateDirectory))
 
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "create_symlink",
+		Description: "Create a symbolic link. The target must exist inside allowed directories; a relative target is kept relative and resolved from the link's directory. Parameters: path (required, the link), target (required).",
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Create Symbolic Link",
+			ReadOnlyHint:    false,
+			IdempotentHint:  false,
+			DestructiveHint: boolPtr(false),
+			OpenWorldHint:   boolPtr(false),
+		},
+	}, handler.Wrap(logger, "create_symlink", h.HandleCreateSymlink))
+
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "write_file",
 		Description: "Write file with encoding conversion from UTF-8. PREFER THIS over built-in Write for non-UTF-8 files — converts UTF-8 content to target encoding, preserving legacy compatibility. Parameters: path (required), content (required), encoding (default: cp1251). Use after read_text_file to preserve original encoding.",
@@ -233,31 +331,56 @@ func NewServer(allowedDirs []string, logger *slog.Logger, cfg *config.Config) *m
 
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "move_file",
-		Description: "Move or rename files/directories. Fails if destination exists. Parameters: source (required), destination (required).",
+		Description: "Move or rename files/directories. A destination that is an existing directory means into it. Fails if the destination exists unless overwrite is set. Works across file systems by copying, verifying, then deleting the source. Parameters: source (required), destination (required), overwrite (\"fail\" default, \"skip\", \"overwrite\", \"overwrite-if-newer\", \"rename-with-suffix\" to add a _1 suffix, \"backup-existing\" to keep the existing file as .bak).",
 		Annotations: &mcp.ToolAnnotations{
 			Title:           "Move File",
 			ReadOnlyHint:    false,
 			IdempotentHint:  false,
-			DestructiveHint: boolPtr(false),
+			DestructiveHint: boolPtr(true),
 			OpenWorldHint:   boolPtr(false),
 		},
 	}, handler.Wrap(logger, "move_file", h.HandleMoveFile))
 
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "rename_files",
+		Description: "Rename many files at once by pattern, e.g. frmXxx.pas to XxxForm.pas. PREFER THIS over calling move_file per file. Defaults to dryRun=true: returns the full rename plan; show it to the user, then call again with dryRun=false. Collisions refuse the whole plan; chains and swaps are handled; a failed rename rolls back the others. Parameters: path (required directory), pattern (required regex matched against file names, or a glob with glob=true supporting only * and ?, which are capture groups), replacement ($1/${name} capture groups; write ${1}Form, not $1Form), glob, caseSensitive (default: true), recursive (default: false, files are renamed in their own directory), dryRun (default: true), respectIgnoreFiles (default: true).",
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Rename Files",
+			ReadOnlyHint:    false,
+			IdempotentHint:  false,
+			DestructiveHint: boolPtr(false),
+			OpenWorldHint:   boolPtr(false),
+		},
+	}, handler.Wrap(logger, "rename_files", h.HandleRenameFiles))
+
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "copy_file",
-		Description: "Copy a file. Fails if destination exists. Parameters: source (required), destination (required).",
+		Description: "Copy a file. A destination that is an existing directory means into it. Fails if the destination exists unless overwrite is set; overwrites are atomic. Parameters: source (required), destination (required), overwrite (\"fail\" default, \"skip\", \"overwrite\", \"overwrite-if-newer\", \"rename-with-suffix\" to add a _1 suffix, \"backup-existing\" to keep the existing file as .bak).",
 		Annotations: &mcp.ToolAnnotations{
 			Title:           "Copy File",
 			ReadOnlyHint:    false,
-			IdempotentHint:  true,
-			DestructiveHint: boolPtr(false),
+			IdempotentHint:  false,
+			DestructiveHint: boolPtr(true),
 			OpenWorldHint:   boolPtr(false),
 		},
 	}, handler.Wrap(logger, "copy_file", h.HandleCopyFile))
 
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "copy_directory",
+		Description: "Copy a directory tree, keeping file modes and modification times. PREFER THIS over shell commands like cp -r. With targetEncoding, text files are transcoded while copying (e.g. a UTF-8 mirror of a cp1251 project); binary files and text that cannot be converted are copied as is and reported. Existing files are a conflict unless overwrite says otherwise; conflicts are checked before anything is copied. Parameters: source (required), destination (required), include/exclude (glob string or array), overwrite (\"fail\" default, \"skip\", \"overwrite\", \"overwrite-if-newer\", \"rename-with-suffix\" or \"backup-existing\"), targetEncoding, from (source encoding, auto-detected), dryRun (default: false), respectIgnoreFiles (default: true).",
+		InputSchema: inputSchema[handler.CopyDirectoryInput](),
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Copy Directory",
+			ReadOnlyHint:    false,
+			IdempotentHint:  false,
+			DestructiveHint: boolPtr(true),
+			OpenWorldHint:   boolPtr(false),
+		},
+	}, handler.Wrap(logger, "copy_directory", h.HandleCopyDirectory))
+
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "delete_file",
-		Description: "Delete a file. Does not delete directories. Parameter: path (required).",
+		Description: "Delete a file. Does not delete directories (use delete_directory). Parameter: path (required).",
 		Annotations: &mcp.ToolAnnotations{
 			Title:           "Delete File",
 			ReadOnlyHint:    false,
@@ -267,6 +390,18 @@ func NewServer(allowedDirs []string, logger *slog.Logger, cfg *config.Config) *m
 		},
 	}, handler.Wrap(logger, "delete_file", h.HandleDeleteFile))
 
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "delete_directory",
+		Description: "Delete a directory. PREFER THIS over shell commands like rm -rf. Only empty directories unless recursive=true; recursive deletes are refused above maxFiles files. Allowed directories themselves are never deleted. Use dryRun=true to list what would be deleted and show it to the user first. Parameters: path (required), recursive (default: false), maxFiles (default: 1000), dryRun (default: false).",
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Delete Directory",
+			ReadOnlyHint:    false,
+			IdempotentHint:  false,
+			DestructiveHint: boolPtr(true),
+			OpenWorldHint:   boolPtr(false),
+		},
+	}, handler.Wrap(logger, "delete_directory", h.HandleDeleteDirectory))
+
 	// WrapContentOnly: returns readable diff text instead of StructuredContent JSON.
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "edit_file",
@@ -283,6 +418,32 @@ func NewServer(allowedDirs []string, logger *slog.Logger, cfg *config.Config) *m
 		},
 	}, handler.WrapContentOnly(logger, "edit_file", h.HandleEditFile))
 
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "replace_in_files",
+		Description: "Search and replace across files, preserving each file's encoding (e.g. cp1251) and line endings. PREFER THIS over editing files one by one for renames. Defaults to dryRun=true: returns per-file counts and a combined unified diff; show it to the user, then call again with dryRun=false to apply. All files are prepared before writing; writes are atomic and rolled back if one fails. Parameters: pattern (required regex), replacement ($1/${name} capture groups), paths (required array of files/dirs), include/exclude (glob string or array), caseSensitive (default: true), fixedString (literal pattern and replacement), wordBoundary (whole words), multiline (matches may span lines), encoding (auto), dryRun (default: true), forceWritable (default: false, read-only files are skipped).",
+		InputSchema: inputSchema[handler.ReplaceInFilesInput](),
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Replace in Files",
+			ReadOnlyHint:    false,
+			IdempotentHint:  false,
+			DestructiveHint: boolPtr(true),
+			OpenWorldHint:   boolPtr(false),
+		},
+	}, handler.Wrap(logger, "replace_in_files", h.HandleReplaceInFiles))
+
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "normalize_tree",
+		Description: "Normalize many files to one profile in a single call instead of per-file convert_encoding/manage_bom/change_line_endings calls. Omitted profile fields keep each file's value. Defaults to dryRun=true: returns the changes per file; show them to the user, then call again with dryRun=false to apply. Files are prepared concurrently; writes are atomic and rolled back if one fails. Parameters: paths (required array of files/dirs), include/exclude (glob string or array), from (source encoding of non-UTF-8 files, auto), encoding (target), bom (true adds, false strips), lineEndings (\"lf\", \"crlf\" or \"cr\"), trimTrailingWhitespace, finalNewline (true ensures one, false removes), dryRun (default: true), forceWritable (default: false, read-only files are skipped).",
+		InputSchema: inputSchema[handler.NormalizeTreeInput](),
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Normalize Tree",
+			ReadOnlyHint:    false,
+			IdempotentHint:  true,
+			DestructiveHint: boolPtr(true),
+			OpenWorldHint:   boolPtr(false),
+		},
+	}, handler.Wrap(logger, "normalize_tree", h.HandleNormalizeTree))
+
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "convert_encoding",
 		Description: "Convert file from one encoding to another. Use after detect_encoding to identify the source. Parameters: path (required), from (source encoding, auto-detected if omitted), to (target encoding, required), backup (create .bak file before converting, default: false). IMPORTANT: Use backup=true for irreversible conversions.",
@@ -295,6 +456,18 @@ func NewServer(allowedDirs []string, logger *slog.Logger, cfg *config.Config) *m
 		},
 	}, handler.Wrap(logger, "convert_encoding", h.HandleConvertEncoding))
 
+	mcp.AddTool(server, &mcp.Tool{
+		Name:        "fix_mojibake",
+		Description: "Detect and repair mojibake (double-encoded text), e.g. UTF-8 decoded as cp1251/cp1252 and re-saved (\"Ð¿Ñ€Ð¸\"). Returns the detected pattern and a unified diff of the repair. Defaults to dryRun=true: show the diff to the user, then call again with dryRun=false to apply. Preserves the file's encoding and line endings; writes atomically. Parameters: path (required), encoding (optional, auto-detected), dryRun (default: true).",
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Fix Mojibake",
+			ReadOnlyHint:    false,
+			IdempotentHint:  true,
+			DestructiveHint: boolPtr(true),
+			OpenWorldHint:   boolPtr(false),
+		},
+	}, handler.Wrap(logger, "fix_mojibake", h.HandleFixMojibake))
+
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "check_for_updates",
 		Description: "Check if a newer version of mcp-file-tools is available. Returns current version, latest version, and update instructions if outdated. Uses cached result (max 1 GitHub API call per 2h). Call once at the start of each session.",
@@ -304,5 +477,5 @@ func NewServer(allowedDirs []string, logger *slog.Logger, cfg *config.Config) *m
 		},
 	}, handler.Wrap(logger, "check_for_updates", handler.NewCheckUpdateHandler(Version)))
 
-	return server
+	return server, h
 }
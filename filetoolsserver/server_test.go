@@ -0,0 +1,192 @@
+package filetoolsserver
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+func TestGlobListAcceptsStringOrArray(t *testing.T) {
+	tempDir := t.TempDir()
+	os.WriteFile(filepath.Join(tempDir, "a.pas"), []byte("findme\n"), 0644)
+	os.WriteFile(filepath.Join(tempDir, "a.dfm"), []byte("findme\n"), 0644)
+	os.WriteFile(filepath.Join(tempDir, "a.txt"), []byte("findme\n"), 0644)
+
+	ctx := context.Background()
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	serverSession, err := NewServer([]string{tempDir}, nil, nil).Connect(ctx, serverTransport, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer serverSession.Close()
+	client := mcp.NewClient(&mcp.Implementation{Name: "test", Version: "v0.0.1"}, nil)
+	session, err := client.Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	tests := []struct {
+		name    string
+		include any
+		want    float64
+	}{
+		{"string", "*.pas", 1},
+		{"array", []string{"*.pas", "*.dfm"}, 2},
+		{"braces", "*.{pas,dfm,txt}", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := session.CallTool(ctx, &mcp.CallToolParams{
+				Name: "grep_text_files",
+				Arguments: map[string]any{
+					"pattern": "findme",
+					"paths":   []string{tempDir},
+					"include": tt.include,
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.IsError {
+				t.Fatalf("unexpected error: %+v", result.Content)
+			}
+			output, ok := result.StructuredContent.(map[string]any)
+			if !ok {
+				t.Fatalf("unexpected structured content: %#v", result.StructuredContent)
+			}
+			if got := output["filesMatched"]; got != tt.want {
+				t.Errorf("filesMatched = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResourceSubscriptionNotifiesChanges(t *testing.T) {
+	tempDir := t.TempDir()
+	watched := filepath.Join(tempDir, "main.pas")
+	os.WriteFile(watched, []byte("unit Main;\n"), 0644)
+
+	ctx := context.Background()
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	serverSession, err := NewServer([]string{tempDir}, nil, nil).Connect(ctx, serverTransport, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer serverSession.Close()
+	updated := make(chan string, 10)
+	client := mcp.NewClient(&mcp.Implementation{Name: "test", Version: "v0.0.1"}, &mcp.ClientOptions{
+		ResourceUpdatedHandler: func(ctx context.Context, req *mcp.ResourceUpdatedNotificationRequest) {
+			updated <- req.Params.URI
+		},
+	})
+	session, err := client.Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer session.Close()
+
+	uri := pathToFileURI(watched)
+	if err := session.Subscribe(ctx, &mcp.SubscribeParams{URI: uri}); err != nil {
+		t.Fatal(err)
+	}
+	if err := session.Subscribe(ctx, &mcp.SubscribeParams{URI: "file:///outside/allowed.txt"}); err == nil {
+		t.Error("expected subscribing outside allowed directories to fail")
+	}
+
+	os.WriteFile(watched, []byte("unit Main; // edited\n"), 0644)
+	select {
+	case got := <-updated:
+		if got != uri {
+			t.Errorf("expected notification for %s, got %s", uri, got)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for resource updated notification")
+	}
+}
+
+// connect starts a server for dir and returns a connected client session.
+func connect(t *testing.T, dir string) *mcp.ClientSession {
+	t.Helper()
+	ctx := context.Background()
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	serverSession, err := NewServer([]string{dir}, nil, nil).Connect(ctx, serverTransport, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { serverSession.Close() })
+	client := mcp.NewClient(&mcp.Implementation{Name: "test", Version: "v0.0.1"}, nil)
+	session, err := client.Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { session.Close() })
+	return session
+}
+
+func TestFileResources(t *testing.T) {
+	tempDir := t.TempDir()
+	cp1251 := []byte{0xcf, 0xf0, 0xe8, 0xe2, 0xe5, 0xf2, 0x2c, 0x20, 0xec, 0xe8, 0xf0, 0x21, 0x0d, 0x0a} // "Привет, мир!\r\n"
+	os.WriteFile(filepath.Join(tempDir, "hello.txt"), cp1251, 0644)
+	os.WriteFile(filepath.Join(tempDir, "data.bin"), []byte{0x00, 0x01, 0x02}, 0644)
+	os.Mkdir(filepath.Join(tempDir, "src"), 0755)
+	os.Mkdir(filepath.Join(tempDir, "node_modules"), 0755)
+	os.WriteFile(filepath.Join(tempDir, ".gitignore"), []byte("node_modules/\n"), 0644)
+	session := connect(t, tempDir)
+	ctx := context.Background()
+
+	list, err := session.ListResources(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootURI := pathToFileURI(tempDir)
+	if len(list.Resources) != 1 || list.Resources[0].URI != rootURI {
+		t.Fatalf("expected the allowed directory as the only resource, got %+v", list.Resources)
+	}
+	templates, err := session.ListResourceTemplates(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(templates.ResourceTemplates) != 1 || templates.ResourceTemplates[0].URITemplate != fileURITemplate {
+		t.Fatalf("unexpected templates %+v", templates.ResourceTemplates)
+	}
+
+	// Directories list their entries, honoring ignore files
+	dir, err := session.ReadResource(ctx, &mcp.ReadResourceParams{URI: rootURI})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := pathToFileURI(filepath.Join(tempDir, "src")) + "/\r\n" +
+		pathToFileURI(filepath.Join(tempDir, ".gitignore")) + "\r\n" +
+		pathToFileURI(filepath.Join(tempDir, "data.bin")) + "\r\n" +
+		pathToFileURI(filepath.Join(tempDir, "hello.txt")) + "\r\n"
+	if got := dir.Contents[0]; got.MIMEType != "text/uri-list" || got.Text != want {
+		t.Errorf("unexpected directory listing %q (%s), want %q", got.Text, got.MIMEType, want)
+	}
+
+	text, err := session.ReadResource(ctx, &mcp.ReadResourceParams{URI: pathToFileURI(filepath.Join(tempDir, "hello.txt"))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := text.Contents[0]; got.Text != "Привет, мир!\r\n" || got.MIMEType != "text/plain" || got.Meta["encoding"] == "utf-8" {
+		t.Errorf("unexpected text resource %+v", got)
+	}
+
+	blob, err := session.ReadResource(ctx, &mcp.ReadResourceParams{URI: pathToFileURI(filepath.Join(tempDir, "data.bin"))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := blob.Contents[0]; len(got.Blob) != 3 || got.Text != "" {
+		t.Errorf("unexpected binary resource %+v", got)
+	}
+
+	for _, path := range []string{filepath.Join(tempDir, "missing.txt"), filepath.Join(t.TempDir(), "outside.txt")} {
+		if _, err := session.ReadResource(ctx, &mcp.ReadResourceParams{URI: pathToFileURI(path)}); err == nil {
+			t.Errorf("expected error reading %s", path)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package filetoolsserver
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/dimitar-grigorov/mcp-file-tools/filetoolsserver/handler"
+	"github.com/dimitar-grigorov/mcp-file-tools/internal/security"
+	"github.com/dimitar-grigorov/mcp-file-tools/internal/watch"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// subscriptions tracks the file:// resources clients subscribed to, so watcher
+// changes are only turned into notifications when someone listens. The SDK
+// keeps track of which session subscribed to what.
+type subscriptions struct {
+	h *handler.Handler
+
+	mu   sync.Mutex
+	uris map[string]*subscription
+}
+
+type subscription struct {
+	path  string // validated path the URI refers to
+	count int    // sessions subscribed
+}
+
+func newSubscriptions(h *handler.Handler) *subscriptions {
+	return &subscriptions{h: h, uris: make(map[string]*subscription)}
+}
+
+// subscribe validates the URI of a resources/subscribe request.
+func (s *subscriptions) subscribe(ctx context.Context, req *mcp.SubscribeRequest) error {
+	uri := req.Params.URI
+	if !strings.HasPrefix(uri, "file://") {
+		return fmt.Errorf("unsupported resource URI %q: only file:// URIs can be subscribed to", uri)
+	}
+	v := s.h.ValidatePath(fileURIToPath(uri))
+	if !v.Ok() {
+		return v.Err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sub, ok := s.uris[uri]; ok {
+		sub.count++
+	} else {
+		s.uris[uri] = &subscription{path: v.Path, count: 1}
+	}
+	return nil
+}
+
+func (s *subscriptions) unsubscribe(ctx context.Context, req *mcp.UnsubscribeRequest) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sub, ok := s.uris[req.Params.URI]; ok {
+		if sub.count--; sub.count <= 0 {
+			delete(s.uris, req.Params.URI)
+		}
+	}
+	return nil
+}
+
+// notifier returns a watcher callback that sends resource updated notifications
+// for subscribed files and for subscribed directories containing the change.
+func (s *subscriptions) notifier(server *mcp.Server) func(watch.Change) {
+	return func(c watch.Change) {
+		s.mu.Lock()
+		var uris []string
+		for uri, sub := range s.uris {
+			if security.IsPathWithinAllowedDirectories(c.Path, []string{sub.path}) {
+				uris = append(uris, uri)
+			}
+		}
+		s.mu.Unlock()
+		// Sending may block on a slow client; the watcher must not
+		for _, uri := range uris {
+			go server.ResourceUpdated(context.Background(), &mcp.ResourceUpdatedNotificationParams{URI: uri})
+		}
+	}
+}
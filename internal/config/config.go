@@ -4,15 +4,21 @@ package config
 import (
 	"log/slog"
 	"os"
+	"path/filepath"
 	"strconv"
 
 	"github.com/dimitar-grigorov/mcp-file-tools/internal/encoding"
+	"github.com/dimitar-grigorov/mcp-file-tools/internal/watch"
 )
 
 const (
 	// Environment variable names
 	EnvDefaultEncoding = "MCP_DEFAULT_ENCODING"
 	EnvMemoryThreshold = "MCP_MEMORY_THRESHOLD"
+	EnvCustomEncodings = "MCP_CUSTOM_ENCODINGS"
+	EnvContentIndex    = "MCP_CONTENT_INDEX"
+	EnvIndexDir        = "MCP_INDEX_DIR"
+	EnvWatch           = "MCP_WATCH"
 
 	// Default values
 	DefaultEncoding = "cp1251"
@@ -33,6 +39,28 @@ type Config struct {
 	// Set via MCP_MEMORY_THRESHOLD environment variable.
 	// Default: 67108864 (64MB)
 	MemoryThreshold int64
+
+	// CustomEncodings lists the names of custom code pages registered from mapping files.
+	// Set via MCP_CUSTOM_ENCODINGS environment variable (paths separated by the OS list
+	// separator: ":" on Unix, ";" on Windows). Each file defines one single-byte encoding.
+	CustomEncodings []string
+
+	// ContentIndex enables the trigram index that grep_text_files uses to skip
+	// files that cannot match. Set via MCP_CONTENT_INDEX environment variable.
+	// Default: false
+	ContentIndex bool
+
+	// IndexDir is where content index files are stored, one per allowed directory.
+	// Set via MCP_INDEX_DIR environment variable.
+	// Default: "mcp-file-tools/index" in the user cache directory
+	IndexDir string
+
+	// Watch enables watching the allowed directories for changes, which backs
+	// list_changed_files and resource update notifications.
+	// Set via MCP_WATCH environment variable.
+	// Default: true where the platform has native notifications (Linux), false
+	// where watching would poll the directories every 2 seconds
+	Watch bool
 }
 
 // Load reads configuration from environment variables with sensible defaults.
@@ -40,6 +68,22 @@ func Load() *Config {
 	cfg := &Config{
 		DefaultEncoding: DefaultEncoding,
 		MemoryThreshold: DefaultMaxSize,
+		Watch:           watch.Native,
+	}
+
+	// Register custom code pages first so MCP_DEFAULT_ENCODING may reference them
+	if paths := os.Getenv(EnvCustomEncodings); paths != "" {
+		for _, path := range filepath.SplitList(paths) {
+			if path == "" {
+				continue
+			}
+			cp, err := encoding.LoadCodePageFile(path)
+			if err != nil {
+				slog.Warn("failed to load custom encoding", "path", path, "error", err)
+				continue
+			}
+			cfg.CustomEncodings = append(cfg.CustomEncodings, cp.Name)
+		}
 	}
 
 	// Load default encoding from environment
@@ -58,5 +102,23 @@ func Load() *Config {
 		}
 	}
 
+	// Load content index settings from environment
+	if enabled, err := strconv.ParseBool(os.Getenv(EnvContentIndex)); err == nil {
+		cfg.ContentIndex = enabled
+	}
+	cfg.IndexDir = os.Getenv(EnvIndexDir)
+	if cfg.IndexDir == "" {
+		if cacheDir, err := os.UserCacheDir(); err == nil {
+			cfg.IndexDir = filepath.Join(cacheDir, "mcp-file-tools", "index")
+		} else {
+			cfg.IndexDir = filepath.Join(os.TempDir(), "mcp-file-tools", "index")
+		}
+	}
+
+	// Load watch setting from environment
+	if enabled, err := strconv.ParseBool(os.Getenv(EnvWatch)); err == nil {
+		cfg.Watch = enabled
+	}
+
 	return cfg
 }
@@ -2,7 +2,10 @@ package config
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
+
+	"github.com/dimitar-grigorov/mcp-file-tools/internal/watch"
 )
 
 func TestLoad_Defaults(t *testing.T) {
@@ -78,3 +81,69 @@ func TestLoad_NegativeMemoryThreshold(t *testing.T) {
 		t.Errorf("expected fallback to %d for negative threshold, got %d", DefaultMaxSize, cfg.MemoryThreshold)
 	}
 }
+
+func TestLoad_CustomEncodingFiles(t *testing.T) {
+	dir := t.TempDir()
+	mapping := "# name: test-mik\n# aliases: testmik\n0x41\t0x0041\n0x80\t0x0410\n"
+	path := filepath.Join(dir, "mik.txt")
+	if err := os.WriteFile(path, []byte(mapping), 0644); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(dir, "missing.txt")
+
+	os.Setenv(EnvCustomEncodings, path+string(os.PathListSeparator)+missing)
+	os.Setenv(EnvDefaultEncoding, "testmik")
+	defer os.Unsetenv(EnvCustomEncodings)
+	defer os.Unsetenv(EnvDefaultEncoding)
+
+	cfg := Load()
+
+	if len(cfg.CustomEncodings) != 1 || cfg.CustomEncodings[0] != "test-mik" {
+		t.Errorf("expected [test-mik], got %v", cfg.CustomEncodings)
+	}
+	// Custom encodings are registered before the default is validated
+	if cfg.DefaultEncoding != "testmik" {
+		t.Errorf("expected default encoding testmik, got %q", cfg.DefaultEncoding)
+	}
+}
+
+func TestLoad_ContentIndex(t *testing.T) {
+	os.Unsetenv(EnvContentIndex)
+	os.Unsetenv(EnvIndexDir)
+	cfg := Load()
+	if cfg.ContentIndex {
+		t.Error("expected content index to be disabled by default")
+	}
+	if cfg.IndexDir == "" {
+		t.Error("expected a default index directory")
+	}
+
+	dir := t.TempDir()
+	os.Setenv(EnvContentIndex, "1")
+	os.Setenv(EnvIndexDir, dir)
+	defer os.Unsetenv(EnvContentIndex)
+	defer os.Unsetenv(EnvIndexDir)
+
+	cfg = Load()
+	if !cfg.ContentIndex || cfg.IndexDir != dir {
+		t.Errorf("expected content index in %q, got enabled=%v dir=%q", dir, cfg.ContentIndex, cfg.IndexDir)
+	}
+}
+
+func TestLoad_Watch(t *testing.T) {
+	os.Unsetenv(EnvWatch)
+	if cfg := Load(); cfg.Watch != watch.Native {
+		t.Errorf("expected watching to default to %v on this platform", watch.Native)
+	}
+
+	os.Setenv(EnvWatch, "false")
+	defer os.Unsetenv(EnvWatch)
+	if cfg := Load(); cfg.Watch {
+		t.Error("expected MCP_WATCH=false to disable watching")
+	}
+
+	os.Setenv(EnvWatch, "true")
+	if cfg := Load(); !cfg.Watch {
+		t.Error("expected MCP_WATCH=true to enable watching")
+	}
+}
@@ -0,0 +1,213 @@
+package encoding
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/transform"
+)
+
+// errUnsupportedRune is returned when a rune has no mapping in a custom code page.
+var errUnsupportedRune = errors.New("encoding: rune not supported by encoding")
+
+// CodePage is a user-defined single-byte encoding loaded from a mapping file.
+// It implements encoding.Encoding so it can be used anywhere a built-in charmap is.
+type CodePage struct {
+	Name        string
+	DisplayName string
+	Aliases     []string
+	Description string
+
+	decode [256]rune
+	encode map[rune]byte
+}
+
+// NewDecoder returns a decoder that maps each byte through the code page table.
+// Unmapped bytes decode to U+FFFD.
+func (c *CodePage) NewDecoder() *encoding.Decoder {
+	return &encoding.Decoder{Transformer: codePageDecoder{c}}
+}
+
+// NewEncoder returns an encoder that fails on runes missing from the table.
+func (c *CodePage) NewEncoder() *encoding.Encoder {
+	return &encoding.Encoder{Transformer: codePageEncoder{c}}
+}
+
+type codePageDecoder struct{ cp *CodePage }
+
+func (d codePageDecoder) Reset() {}
+
+func (d codePageDecoder) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	for nSrc < len(src) {
+		r := d.cp.decode[src[nSrc]]
+		size := utf8.RuneLen(r)
+		if nDst+size > len(dst) {
+			return nDst, nSrc, transform.ErrShortDst
+		}
+		nDst += utf8.EncodeRune(dst[nDst:], r)
+		nSrc++
+	}
+	return nDst, nSrc, nil
+}
+
+type codePageEncoder struct{ cp *CodePage }
+
+func (e codePageEncoder) Reset() {}
+
+func (e codePageEncoder) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	for nSrc < len(src) {
+		if nDst >= len(dst) {
+			return nDst, nSrc, transform.ErrShortDst
+		}
+		r, size := utf8.DecodeRune(src[nSrc:])
+		if r == utf8.RuneError && size <= 1 {
+			if !atEOF && !utf8.FullRune(src[nSrc:]) {
+				return nDst, nSrc, transform.ErrShortSrc
+			}
+			// Invalid UTF-8 is replaced with the ASCII substitute, like charmap does.
+			dst[nDst] = encoding.ASCIISub
+			nDst++
+			nSrc += size
+			continue
+		}
+		b, ok := e.cp.encode[r]
+		if !ok {
+			return nDst, nSrc, errUnsupportedRune
+		}
+		dst[nDst] = b
+		nDst++
+		nSrc += size
+	}
+	return nDst, nSrc, nil
+}
+
+// ParseCodePage reads a single-byte mapping in the unicode.org format:
+//
+//	# name: mik
+//	# aliases: bulgarian-mik, dos-mik
+//	# description: Bulgarian DOS (MIK)
+//	0x80	0x0410	# CYRILLIC CAPITAL LETTER A
+//
+// Each data line maps a byte (0x00-0xFF) to a Unicode code point. Bytes that are
+// not listed (or marked #UNDEFINED) decode to U+FFFD. The "name" directive is
+// optional and defaults to defaultName.
+func ParseCodePage(r io.Reader, defaultName string) (*CodePage, error) {
+	cp := &CodePage{
+		Name:   strings.ToLower(defaultName),
+		encode: make(map[rune]byte),
+	}
+	for i := range cp.decode {
+		cp.decode[i] = utf8.RuneError
+	}
+
+	var defined [256]bool
+	mapped := 0
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			parseCodePageDirective(cp, strings.TrimSpace(line[1:]))
+			continue
+		}
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			line = line[:idx]
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			// Lines like "0x81" (no target) mark undefined bytes.
+			continue
+		}
+		b, err := strconv.ParseUint(fields[0], 0, 8)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid byte value %q", lineNum, fields[0])
+		}
+		cpoint, err := strconv.ParseUint(fields[1], 0, 32)
+		if err != nil || cpoint > utf8.MaxRune {
+			return nil, fmt.Errorf("line %d: invalid code point %q", lineNum, fields[1])
+		}
+		if defined[b] {
+			return nil, fmt.Errorf("line %d: byte 0x%02X mapped more than once", lineNum, b)
+		}
+		defined[b] = true
+		r := rune(cpoint)
+		cp.decode[b] = r
+		// First byte wins when several bytes map to the same code point.
+		if _, exists := cp.encode[r]; !exists {
+			cp.encode[r] = byte(b)
+		}
+		mapped++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if mapped == 0 {
+		return nil, errors.New("mapping contains no byte definitions")
+	}
+	if cp.Name == "" {
+		return nil, errors.New("code page name is required")
+	}
+	if cp.DisplayName == "" {
+		cp.DisplayName = strings.ToUpper(cp.Name)
+	}
+	if cp.Description == "" {
+		cp.Description = "Custom single-byte code page"
+	}
+	return cp, nil
+}
+
+// parseCodePageDirective applies a "key: value" comment line to the code page.
+// Unknown keys are ignored so ordinary comments are allowed.
+func parseCodePageDirective(cp *CodePage, directive string) {
+	key, value, ok := strings.Cut(directive, ":")
+	if !ok {
+		return
+	}
+	value = strings.TrimSpace(value)
+	switch strings.ToLower(strings.TrimSpace(key)) {
+	case "name":
+		cp.Name = strings.ToLower(value)
+	case "display":
+		cp.DisplayName = value
+	case "description":
+		cp.Description = value
+	case "aliases":
+		for _, alias := range strings.Split(value, ",") {
+			if alias = strings.ToLower(strings.TrimSpace(alias)); alias != "" {
+				cp.Aliases = append(cp.Aliases, alias)
+			}
+		}
+	}
+}
+
+// LoadCodePageFile parses a mapping file and registers the code page.
+// The file name without extension is used as the name if no "name" directive is present.
+func LoadCodePageFile(path string) (*CodePage, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open mapping file: %w", err)
+	}
+	defer f.Close()
+
+	base := filepath.Base(path)
+	cp, err := ParseCodePage(f, strings.TrimSuffix(base, filepath.Ext(base)))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := RegisterCodePage(cp); err != nil {
+		return nil, err
+	}
+	return cp, nil
+}
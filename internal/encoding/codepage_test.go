@@ -0,0 +1,188 @@
+package encoding
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// mikSample is a partial MIK (Bulgarian DOS) table: ASCII letters plus "Аа".
+const mikSample = `# name: mik-test
+# display: MIK (test)
+# aliases: mik-test-alias, dos-mik-test
+# description: Bulgarian DOS (MIK)
+0x20	0x0020	# SPACE
+0x41	0x0041	# LATIN CAPITAL LETTER A
+0x61	0x0061	# LATIN SMALL LETTER A
+0x80	0x0410	# CYRILLIC CAPITAL LETTER A
+0xA0	0x0430	# CYRILLIC SMALL LETTER A
+0x81		#UNDEFINED
+`
+
+// unregisterCodePage removes a custom code page so tests don't leak registry state.
+func unregisterCodePage(name string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if info, ok := encodings[name]; ok {
+		for _, alias := range info.Aliases {
+			delete(registry, alias)
+		}
+	}
+	delete(encodings, name)
+	delete(registry, name)
+}
+
+func TestParseCodePage(t *testing.T) {
+	cp, err := ParseCodePage(strings.NewReader(mikSample), "ignored")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cp.Name != "mik-test" {
+		t.Errorf("Name = %q, want mik-test", cp.Name)
+	}
+	if cp.DisplayName != "MIK (test)" {
+		t.Errorf("DisplayName = %q, want MIK (test)", cp.DisplayName)
+	}
+	if len(cp.Aliases) != 2 || cp.Aliases[0] != "mik-test-alias" {
+		t.Errorf("Aliases = %v", cp.Aliases)
+	}
+
+	decoded, err := cp.NewDecoder().String("A\x80 a\xA0\x81")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded != "AА aа�" {
+		t.Errorf("decoded = %q", decoded)
+	}
+
+	encoded, err := cp.NewEncoder().String("Аа A")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if encoded != "\x80\xA0 A" {
+		t.Errorf("encoded = %q", encoded)
+	}
+
+	if _, err := cp.NewEncoder().String("Б"); err == nil {
+		t.Error("expected error encoding unmapped rune")
+	}
+}
+
+func TestParseCodePage_DefaultName(t *testing.T) {
+	cp, err := ParseCodePage(strings.NewReader("0x41 0x0041\n"), "MyTable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cp.Name != "mytable" {
+		t.Errorf("Name = %q, want mytable", cp.Name)
+	}
+	if cp.DisplayName != "MYTABLE" {
+		t.Errorf("DisplayName = %q, want MYTABLE", cp.DisplayName)
+	}
+}
+
+func TestParseCodePage_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"empty", "# only comments\n"},
+		{"bad byte", "0x100 0x0041\n"},
+		{"bad code point", "0x41 zzz\n"},
+		{"duplicate byte", "0x41 0x0041\n0x41 0x0042\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseCodePage(strings.NewReader(tt.input), "x"); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestLoadCodePageFile_Registers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mik.txt")
+	if err := os.WriteFile(path, []byte(mikSample), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { unregisterCodePage("mik-test") })
+
+	if _, err := LoadCodePageFile(path); err != nil {
+		t.Fatal(err)
+	}
+	// A name is never taken over, not even by the same file loaded again
+	if _, err := LoadCodePageFile(path); err == nil {
+		t.Error("expected loading the same code page twice to fail")
+	}
+
+	for _, name := range []string{"mik-test", "MIK-TEST-ALIAS", "dos-mik-test"} {
+		enc, ok := Get(name)
+		if !ok || enc == nil {
+			t.Errorf("Get(%q) not found after registration", name)
+		}
+	}
+
+	found := false
+	for _, item := range ListEncodings() {
+		if item.Name == "mik-test" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("custom code page missing from ListEncodings")
+	}
+}
+
+func TestRegisterCodePage_BuiltinConflict(t *testing.T) {
+	cp, err := ParseCodePage(strings.NewReader("# aliases: cp1251\n0x41 0x0041\n"), "conflict-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterCodePage(cp); err == nil {
+		t.Error("expected error when alias conflicts with built-in encoding")
+	}
+	if _, ok := Get("conflict-test"); ok {
+		t.Error("conflicting code page should not be registered")
+	}
+}
+
+func TestRegisterCodePage_CustomConflict(t *testing.T) {
+	first, err := ParseCodePage(strings.NewReader("# aliases: dup-alias-test\n0x41 0x0041\n"), "dup-first-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterCodePage(first); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { unregisterCodePage("dup-first-test") })
+
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"name taken by an alias", "# name: dup-alias-test\n0x41 0x0041\n"},
+		{"alias taken by a name", "# name: dup-second-test\n# aliases: dup-first-test\n0x41 0x0041\n"},
+		{"alias taken by an alias", "# name: dup-second-test\n# aliases: dup-alias-test\n0x41 0x0041\n"},
+		{"alias repeats the name", "# name: dup-second-test\n# aliases: dup-second-test\n0x41 0x0041\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cp, err := ParseCodePage(strings.NewReader(tt.input), "x")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := RegisterCodePage(cp); err == nil {
+				unregisterCodePage(cp.Name)
+				t.Fatal("expected duplicate name or alias to be rejected")
+			}
+			if enc, _ := Get("dup-alias-test"); enc != first {
+				t.Error("the first code page lost its alias")
+			}
+			if _, ok := Get("dup-second-test"); ok {
+				t.Error("rejected code page was registered")
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package encoding
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxInvalidPositions limits how many invalid sequence positions are recorded.
+const MaxInvalidPositions = 10
+
+// Position is a 1-indexed line and column (in characters) within decoded text.
+type Position struct {
+	Line   int
+	Column int
+}
+
+// InvalidSequences summarizes byte sequences that did not decode to valid characters.
+type InvalidSequences struct {
+	Count     int        // Number of invalid sequences (consecutive bad bytes count once)
+	Positions []Position // First MaxInvalidPositions occurrences
+}
+
+// FindInvalidSequences scans decoded text for invalid byte sequences. Invalid
+// UTF-8 bytes left in the text are always counted. For non-UTF-8 charsets the
+// decoder has already replaced bad bytes with U+FFFD, so replacement characters
+// are counted too; in UTF-8 sources a literal U+FFFD is valid text.
+func FindInvalidSequences(text, charset string) InvalidSequences {
+	var result InvalidSequences
+	for lineNum := 1; ; lineNum++ {
+		line, rest, found := strings.Cut(text, "\n")
+		result.AddLine(line, lineNum, charset)
+		if !found {
+			return result
+		}
+		text = rest
+	}
+}
+
+// AddLine scans a single line (without its "\n") for invalid sequences, for callers
+// that read text line by line. See FindInvalidSequences for what is counted.
+func (s *InvalidSequences) AddLine(line string, lineNum int, charset string) {
+	countReplacement := !IsUTF8(charset)
+	// Fast path: nothing to report
+	if countReplacement {
+		if utf8.ValidString(line) && !strings.ContainsRune(line, utf8.RuneError) {
+			return
+		}
+	} else if utf8.ValidString(line) {
+		return
+	}
+
+	column := 0
+	inRun := false
+	for i := 0; i < len(line); {
+		r, size := utf8.DecodeRuneInString(line[i:])
+		i += size
+		column++
+		invalid := r == utf8.RuneError && (size == 1 || countReplacement)
+		if !invalid {
+			inRun = false
+			continue
+		}
+		if inRun {
+			continue
+		}
+		inRun = true
+		s.Count++
+		if len(s.Positions) < MaxInvalidPositions {
+			s.Positions = append(s.Positions, Position{Line: lineNum, Column: column})
+		}
+	}
+}
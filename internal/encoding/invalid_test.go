@@ -0,0 +1,48 @@
+package encoding
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindInvalidSequences(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		charset   string
+		count     int
+		positions []Position
+	}{
+		{"valid utf-8", "hello\nПривет", "utf-8", 0, nil},
+		{"literal replacement in utf-8", "a�b", "utf-8", 0, nil},
+		{"invalid byte", "ab\xffc", "utf-8", 1, []Position{{1, 3}}},
+		{"consecutive bytes count once", "a\xe2\x82\nb", "utf-8", 1, []Position{{1, 2}}},
+		{"column counts characters", "ok\nПри\xffвет", "utf-8", 1, []Position{{2, 4}}},
+		{"replacement in decoded text", "x�\ny�", "windows-1251", 2, []Position{{1, 2}, {2, 2}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FindInvalidSequences(tt.text, tt.charset)
+			if got.Count != tt.count {
+				t.Errorf("count = %d, want %d", got.Count, tt.count)
+			}
+			if !reflect.DeepEqual(got.Positions, tt.positions) {
+				t.Errorf("positions = %v, want %v", got.Positions, tt.positions)
+			}
+		})
+	}
+}
+
+func TestFindInvalidSequences_LimitsPositions(t *testing.T) {
+	text := ""
+	for i := 0; i < MaxInvalidPositions+5; i++ {
+		text += "a\xff\n"
+	}
+	got := FindInvalidSequences(text, "utf-8")
+	if got.Count != MaxInvalidPositions+5 {
+		t.Errorf("count = %d, want %d", got.Count, MaxInvalidPositions+5)
+	}
+	if len(got.Positions) != MaxInvalidPositions {
+		t.Errorf("expected %d positions, got %d", MaxInvalidPositions, len(got.Positions))
+	}
+}
@@ -0,0 +1,285 @@
+package encoding
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Mojibake repair constants
+const (
+	maxMojibakePasses = 3 // Text double-encoded more than this many times is not repaired
+	minMojibakeGain   = 2 // Minimum total score improvement before a pattern is trusted
+)
+
+// mojibakeMisread lists single-byte encodings that text is commonly (wrongly) decoded with.
+var mojibakeMisread = []string{
+	"windows-1252", "iso-8859-1", "windows-1251", "windows-1250", "iso-8859-2", "iso-8859-15",
+	"koi8-r", "ibm866", "ibm437", "ibm850", "windows-1253", "windows-1254",
+}
+
+// mojibakeOriginal lists encodings the bytes were actually written in, most likely first.
+// Order breaks ties between patterns that repair equally well.
+var mojibakeOriginal = []string{
+	"utf-8", "windows-1251", "koi8-r", "ibm866", "iso-8859-5", "windows-1250", "iso-8859-2",
+	"windows-1252", "windows-1253", "windows-1254", "windows-1255", "windows-1256",
+	"shift_jis", "gbk", "big5", "euc-kr",
+}
+
+// MojibakePattern describes one layer of double-encoding: bytes in Original were decoded as Misread.
+type MojibakePattern struct {
+	Original string `json:"original"`
+	Misread  string `json:"misread"`
+}
+
+func (p MojibakePattern) String() string {
+	return fmt.Sprintf("%s misread as %s", p.Original, p.Misread)
+}
+
+// MojibakeRepair is the result of RepairMojibake.
+type MojibakeRepair struct {
+	Text     string            // Repaired text
+	Patterns []MojibakePattern // One per repaired layer, outermost first
+	Segments int               // Number of text segments changed across all passes
+}
+
+// MojibakeScore returns a heuristic count of mojibake symptoms in s: replacement
+// characters, C1 control codes, and adjacent non-ASCII runes that rarely occur
+// together in real text (mixed scripts, accented Latin pairs, letter/symbol mixes,
+// lower-to-upper case flips). Zero means the text looks clean.
+func MojibakeScore(s string) int {
+	score := 0
+	var prev rune
+	for _, r := range s {
+		if r == utf8.RuneError || (r >= 0x80 && r <= 0x9F) {
+			score += 3
+		}
+		if prev >= 0x80 && r >= 0x80 {
+			score += suspiciousPair(prev, r)
+		}
+		prev = r
+	}
+	return score
+}
+
+// suspiciousPair scores two adjacent non-ASCII runes.
+func suspiciousPair(a, b rune) int {
+	aLetter, bLetter := unicode.IsLetter(a), unicode.IsLetter(b)
+	switch {
+	case aLetter && bLetter:
+		if isAccentedLatin(a) && isAccentedLatin(b) {
+			return 1
+		}
+		if scriptOf(a) != scriptOf(b) {
+			return 2
+		}
+		if unicode.IsLower(a) && unicode.IsUpper(b) {
+			return 1
+		}
+		return 0
+	case aLetter != bLetter:
+		return 1
+	default:
+		// Two symbols: runs from one block (box drawing, «») are fine, mixes are not
+		if a>>8 != b>>8 {
+			return 1
+		}
+		return 0
+	}
+}
+
+// hasHardSymptoms reports whether s contains replacement characters or C1 control
+// codes, which correctly decoded text does not.
+func hasHardSymptoms(s string) bool {
+	for _, r := range s {
+		if r == utf8.RuneError || (r >= 0x80 && r <= 0x9F) {
+			return true
+		}
+	}
+	return false
+}
+
+func isAccentedLatin(r rune) bool {
+	return r >= 0xC0 && r <= 0x24F
+}
+
+func scriptOf(r rune) string {
+	for _, script := range []string{"Latin", "Cyrillic", "Greek", "Han", "Hiragana", "Katakana", "Hangul", "Arabic", "Hebrew", "Thai"} {
+		if unicode.Is(unicode.Scripts[script], r) {
+			return script
+		}
+	}
+	return ""
+}
+
+// RepairMojibake detects and reverses double-encoding in text. Each pass picks the
+// pattern that repairs the most suspicious segments (runs of non-ASCII runes) and
+// applies it only where it improves the segment, so correctly encoded text in the
+// same file is left alone. Returns false if no mojibake was found.
+func RepairMojibake(text string) (MojibakeRepair, bool) {
+	result := MojibakeRepair{Text: text}
+	for pass := 0; pass < maxMojibakePasses; pass++ {
+		segments := suspiciousSegments(result.Text)
+		if len(segments) == 0 {
+			break
+		}
+		pattern, ok := bestMojibakePattern(result.Text, segments)
+		if !ok {
+			break
+		}
+		var sb strings.Builder
+		last := 0
+		fixed := 0
+		for _, seg := range segments {
+			repaired, ok := repairSegment(result.Text[seg[0]:seg[1]], pattern)
+			if !ok {
+				continue
+			}
+			sb.WriteString(result.Text[last:seg[0]])
+			sb.WriteString(repaired)
+			last = seg[1]
+			fixed++
+		}
+		sb.WriteString(result.Text[last:])
+		result.Text = sb.String()
+		result.Patterns = append(result.Patterns, pattern)
+		result.Segments += fixed
+	}
+	return result, len(result.Patterns) > 0
+}
+
+// suspiciousSegments returns [start, end) byte offsets of maximal non-ASCII runs
+// that have a non-zero MojibakeScore.
+func suspiciousSegments(text string) [][2]int {
+	var segments [][2]int
+	start := -1
+	flush := func(end int) {
+		if start >= 0 && MojibakeScore(text[start:end]) > 0 {
+			segments = append(segments, [2]int{start, end})
+		}
+		start = -1
+	}
+	for i, r := range text {
+		if r < utf8.RuneSelf {
+			flush(i)
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	flush(len(text))
+	return segments
+}
+
+// bestMojibakePattern returns the pattern with the largest total score improvement.
+func bestMojibakePattern(text string, segments [][2]int) (MojibakePattern, bool) {
+	var best MojibakePattern
+	bestGain := 0
+	for _, misread := range mojibakeMisread {
+		for _, original := range mojibakeOriginal {
+			if misread == original {
+				continue
+			}
+			pattern := MojibakePattern{Original: original, Misread: misread}
+			gain := 0
+			for _, seg := range segments {
+				s := text[seg[0]:seg[1]]
+				if repaired, ok := repairSegment(s, pattern); ok {
+					gain += MojibakeScore(s) - MojibakeScore(repaired)
+				}
+			}
+			if gain > bestGain {
+				best, bestGain = pattern, gain
+			}
+		}
+	}
+	return best, bestGain >= minMojibakeGain
+}
+
+var (
+	misreadTablesOnce sync.Once
+	misreadTables     map[string]map[rune]byte
+)
+
+// misreadTable returns a rune-to-byte table for a single-byte misread encoding.
+// Bytes the encoding leaves undefined map to the matching C1 control code, since
+// Windows decoders pass them through that way and such text ends up in files.
+func misreadTable(name string) map[rune]byte {
+	misreadTablesOnce.Do(func() {
+		misreadTables = make(map[string]map[rune]byte, len(mojibakeMisread))
+		for _, name := range mojibakeMisread {
+			enc, ok := Get(name)
+			if !ok || enc == nil {
+				continue
+			}
+			table := make(map[rune]byte, 256)
+			decoder := enc.NewDecoder()
+			for b := 0; b < 256; b++ {
+				decoded, err := decoder.String(string([]byte{byte(b)}))
+				r, _ := utf8.DecodeRuneInString(decoded)
+				if err != nil || r == utf8.RuneError {
+					r = rune(b)
+				}
+				if _, exists := table[r]; !exists {
+					table[r] = byte(b)
+				}
+			}
+			misreadTables[name] = table
+		}
+	})
+	return misreadTables[name]
+}
+
+// repairSegment re-encodes s with the misread encoding and decodes the bytes with
+// the original one. Fails if s can't be encoded, the bytes aren't valid in the
+// original encoding, or the result doesn't score better than s.
+func repairSegment(s string, pattern MojibakePattern) (string, bool) {
+	table := misreadTable(pattern.Misread)
+	if table == nil {
+		return "", false
+	}
+	buf := make([]byte, 0, len(s))
+	for _, r := range s {
+		b, ok := table[r]
+		if !ok {
+			return "", false
+		}
+		buf = append(buf, b)
+	}
+	raw := string(buf)
+
+	// Accented Latin pairs alone also describe correct Polish, Czech or Danish text,
+	// so a candidate needs harder evidence: bytes that form valid multi-byte UTF-8,
+	// or C1 controls / replacement characters already in the segment.
+	var repaired string
+	if IsUTF8(pattern.Original) {
+		if !utf8.ValidString(raw) || utf8.RuneCountInString(raw) == len(raw) {
+			return "", false
+		}
+		repaired = raw
+	} else {
+		if !hasHardSymptoms(s) {
+			return "", false
+		}
+		original, ok := Get(pattern.Original)
+		if !ok || original == nil {
+			return "", false
+		}
+		var err error
+		repaired, err = original.NewDecoder().String(raw)
+		if err != nil {
+			return "", false
+		}
+	}
+
+	if repaired == s || strings.ContainsRune(repaired, utf8.RuneError) {
+		return "", false
+	}
+	if MojibakeScore(repaired) >= MojibakeScore(s) {
+		return "", false
+	}
+	return repaired, true
+}
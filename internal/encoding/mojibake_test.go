@@ -0,0 +1,139 @@
+package encoding
+
+import (
+	"strings"
+	"testing"
+)
+
+// garble simulates mojibake: text saved in original, then decoded as misread
+// byte by byte, passing undefined bytes through as C1 controls like Windows does.
+func garble(t *testing.T, text, original, misread string) string {
+	t.Helper()
+	raw := text
+	if !IsUTF8(original) {
+		enc, _ := Get(original)
+		var err error
+		if raw, err = enc.NewEncoder().String(text); err != nil {
+			t.Fatalf("encode %s: %v", original, err)
+		}
+	}
+	dec, _ := Get(misread)
+	var sb strings.Builder
+	for i := 0; i < len(raw); i++ {
+		r, err := dec.NewDecoder().String(raw[i : i+1])
+		if err != nil || r == "\uFFFD" {
+			r = string(rune(raw[i]))
+		}
+		sb.WriteString(r)
+	}
+	return sb.String()
+}
+
+func TestRepairMojibake(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		original string
+		misread  string
+	}{
+		{"utf-8 as cp1252", "Caption = 'Настройки программы'", "utf-8", "windows-1252"},
+		{"utf-8 as latin1", "Привет, мир", "utf-8", "iso-8859-1"},
+		{"utf-8 as cp1251", "Настройки программы", "utf-8", "windows-1251"},
+		{"utf-8 accents as cp1252", "Café crème brûlée", "utf-8", "windows-1252"},
+		{"cp1251 as cp1252", "Caption := 'Ђурђевдан';", "windows-1251", "windows-1252"},
+		{"utf-8 chinese as cp1252", "配置文件 = 设置", "utf-8", "windows-1252"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			garbled := garble(t, tt.text, tt.original, tt.misread)
+			result, found := RepairMojibake(garbled)
+			if !found {
+				t.Fatalf("mojibake not detected in %q", garbled)
+			}
+			if result.Text != tt.text {
+				t.Errorf("repaired = %q, want %q", result.Text, tt.text)
+			}
+			if result.Patterns[0].Original != tt.original {
+				t.Errorf("pattern = %s, want original %s", result.Patterns[0], tt.original)
+			}
+		})
+	}
+}
+
+func TestRepairMojibake_Examples(t *testing.T) {
+	tests := []struct {
+		garbled string
+		want    string
+	}{
+		{"Ð\u009fÑ\u0080Ð¸Ð²ÐµÑ\u0082", "Привет"},
+		{"€óð\u0090åâäàí", "Ђурђевдан"},
+	}
+	for _, tt := range tests {
+		result, found := RepairMojibake(tt.garbled)
+		if !found || result.Text != tt.want {
+			t.Errorf("RepairMojibake(%q) = %q, %v; want %q", tt.garbled, result.Text, found, tt.want)
+		}
+	}
+}
+
+func TestRepairMojibake_DoubleEncoded(t *testing.T) {
+	text := "Настройки программы"
+	once := garble(t, text, "utf-8", "windows-1252")
+	twice := garble(t, once, "utf-8", "windows-1252")
+
+	result, found := RepairMojibake(twice)
+	if !found {
+		t.Fatal("double mojibake not detected")
+	}
+	if result.Text != text {
+		t.Errorf("repaired = %q, want %q", result.Text, text)
+	}
+	if len(result.Patterns) != 2 {
+		t.Errorf("expected 2 passes, got %d", len(result.Patterns))
+	}
+}
+
+func TestRepairMojibake_PartialFile(t *testing.T) {
+	good := "Заголовок: Настройки\n"
+	bad := garble(t, "Параметры окна", "utf-8", "windows-1252")
+	result, found := RepairMojibake(good + bad + "\n")
+	if !found {
+		t.Fatal("mojibake not detected")
+	}
+	if result.Text != good+"Параметры окна\n" {
+		t.Errorf("repaired = %q", result.Text)
+	}
+}
+
+func TestRepairMojibake_CleanText(t *testing.T) {
+	clean := []string{
+		"Hello, World!",
+		"Настройки программы",
+		"Grüße aus München, Straße",
+		"Café crème brûlée",
+		"╔═══╗ box ║ drawing ╚═══╝",
+		"配置文件",
+		"Ελληνικά κείμενο",
+		"Zażółć gęślą jaźń",
+		"Příliš žluťoučký kůň úpěl ďábelské ódy",
+		"ÆØÅ æøå",
+		"Dzień dobry, Łódź",
+	}
+	for _, text := range clean {
+		if result, found := RepairMojibake(text); found {
+			t.Errorf("false positive on %q: %q (%v)", text, result.Text, result.Patterns)
+		}
+	}
+}
+
+func TestMojibakeScore(t *testing.T) {
+	if MojibakeScore("Настройки") != 0 {
+		t.Errorf("clean Cyrillic should score 0, got %d", MojibakeScore("Настройки"))
+	}
+	if MojibakeScore("Íàñòðîéêè") == 0 {
+		t.Error("cp1251-as-cp1252 text should score > 0")
+	}
+	if MojibakeScore("Ã©") == 0 {
+		t.Error("utf-8-as-cp1252 text should score > 0")
+	}
+}
@@ -0,0 +1,175 @@
+package encoding
+
+import (
+	"bytes"
+	"fmt"
+	"unicode/utf8"
+)
+
+// Region is a contiguous range of whole lines decoded with a single encoding.
+type Region struct {
+	Charset    string
+	Confidence int
+	StartByte  int64 // inclusive
+	EndByte    int64 // exclusive
+	StartLine  int   // 1-indexed, inclusive
+	EndLine    int   // 1-indexed, inclusive
+}
+
+// line classes used while splitting data into regions
+const (
+	lineASCII  = iota // pure ASCII, valid in every supported single-byte encoding
+	lineUTF8          // valid UTF-8 with multi-byte sequences
+	lineLegacy        // invalid UTF-8, assumed to be a legacy code page
+)
+
+// DetectRegions splits data into line-aligned regions by encoding. Lines that are
+// valid UTF-8 form UTF-8 regions; runs of other lines are detected separately with
+// chardet. Pure ASCII lines join the surrounding region. A file with a BOM is a
+// single region. fallback is used for legacy regions whose detection is not trusted.
+func DetectRegions(data []byte, fallback string) []Region {
+	if bom, ok := DetectBOM(data); ok {
+		return []Region{{
+			Charset:    bom.Charset,
+			Confidence: bom.Confidence,
+			EndByte:    int64(len(data)),
+			StartLine:  1,
+			EndLine:    bytes.Count(data, []byte{'\n'}) + 1,
+		}}
+	}
+
+	// Classify each line, then group consecutive lines of the same class.
+	// ASCII lines adopt the class of the run they sit in.
+	type run struct {
+		class            int
+		start, end       int
+		startLine, lines int
+	}
+	var runs []run
+	pendingASCII := 0 // leading ASCII lines not yet assigned to a run
+	lineNum := 0
+	for offset := 0; offset < len(data) || lineNum == 0; {
+		lineNum++
+		end := len(data)
+		if idx := bytes.IndexByte(data[offset:], '\n'); idx >= 0 {
+			end = offset + idx + 1
+		}
+		class := classifyLine(data[offset:end])
+
+		switch {
+		case class == lineASCII && len(runs) == 0:
+			pendingASCII++
+		case class == lineASCII || (len(runs) > 0 && runs[len(runs)-1].class == class):
+			runs[len(runs)-1].end = end
+			runs[len(runs)-1].lines++
+		case len(runs) == 0:
+			runs = append(runs, run{class: class, start: 0, end: end, startLine: 1, lines: lineNum})
+			pendingASCII = 0
+		default:
+			runs = append(runs, run{class: class, start: offset, end: end, startLine: lineNum, lines: 1})
+		}
+		offset = end
+		if end == len(data) {
+			break
+		}
+	}
+	if len(runs) == 0 {
+		// Entirely ASCII (or empty)
+		return []Region{{Charset: "utf-8", Confidence: 100, EndByte: int64(len(data)), StartLine: 1, EndLine: max(lineNum, pendingASCII, 1)}}
+	}
+
+	// Detect all legacy bytes together once, as a fallback for short regions
+	var legacyAll []byte
+	for _, r := range runs {
+		if r.class == lineLegacy {
+			legacyAll = append(legacyAll, data[r.start:r.end]...)
+		}
+	}
+	combined := DetectionResult{Charset: fallback}
+	if len(legacyAll) > 0 {
+		if detected := Detect(legacyAll); isTrustedLegacy(detected) {
+			combined = detected
+		}
+	}
+
+	regions := make([]Region, 0, len(runs))
+	for _, r := range runs {
+		region := Region{
+			Charset:    "utf-8",
+			Confidence: 100,
+			StartByte:  int64(r.start),
+			EndByte:    int64(r.end),
+			StartLine:  r.startLine,
+			EndLine:    r.startLine + r.lines - 1,
+		}
+		if r.class == lineLegacy {
+			detected := Detect(data[r.start:r.end])
+			if !isTrustedLegacy(detected) {
+				detected = combined
+			}
+			region.Charset = detected.Charset
+			region.Confidence = detected.Confidence
+		}
+		// Merge with the previous region if detection gave the same charset
+		if n := len(regions); n > 0 && regions[n-1].Charset == region.Charset {
+			regions[n-1].EndByte = region.EndByte
+			regions[n-1].EndLine = region.EndLine
+			regions[n-1].Confidence = min(regions[n-1].Confidence, region.Confidence)
+			continue
+		}
+		regions = append(regions, region)
+	}
+	return regions
+}
+
+// isTrustedLegacy reports whether a detection result names a supported non-UTF-8 encoding
+// with enough confidence.
+func isTrustedLegacy(result DetectionResult) bool {
+	if result.Confidence < MinConfidenceThreshold || IsUTF8(result.Charset) {
+		return false
+	}
+	_, ok := Get(result.Charset)
+	return ok
+}
+
+// classifyLine returns whether a line is pure ASCII, valid UTF-8, or legacy bytes.
+func classifyLine(line []byte) int {
+	ascii := true
+	for _, b := range line {
+		if b >= utf8.RuneSelf {
+			ascii = false
+			break
+		}
+	}
+	switch {
+	case ascii:
+		return lineASCII
+	case utf8.Valid(line):
+		return lineUTF8
+	default:
+		return lineLegacy
+	}
+}
+
+// DecodeRegions decodes each region of data with its own charset and joins the results.
+func DecodeRegions(data []byte, regions []Region) (string, error) {
+	var sb bytes.Buffer
+	sb.Grow(len(data))
+	for _, r := range regions {
+		chunk := data[r.StartByte:r.EndByte]
+		if IsUTF8(r.Charset) {
+			sb.Write(chunk)
+			continue
+		}
+		enc, ok := Get(r.Charset)
+		if !ok || enc == nil {
+			return "", fmt.Errorf("unsupported encoding %q for lines %d-%d", r.Charset, r.StartLine, r.EndLine)
+		}
+		decoded, err := enc.NewDecoder().Bytes(chunk)
+		if err != nil {
+			return "", fmt.Errorf("failed to decode lines %d-%d as %s: %w", r.StartLine, r.EndLine, r.Charset, err)
+		}
+		sb.Write(decoded)
+	}
+	return sb.String(), nil
+}
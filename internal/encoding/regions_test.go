@@ -0,0 +1,95 @@
+package encoding
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// mixedSample returns a UTF-8 section followed by a cp1251 section.
+func mixedSample(t *testing.T) ([]byte, string) {
+	t.Helper()
+	utf8Part := "-- Section 1\nINSERT INTO t VALUES ('Привет, мир');\nINSERT INTO t VALUES ('Добрый день');\n"
+	cp1251Text := "-- Section 2\nINSERT INTO t VALUES ('Съешь же ещё этих мягких французских булок, да выпей чаю');\nINSERT INTO t VALUES ('Широкая электрификация южных губерний даст мощный толчок подъёму сельского хозяйства');\n"
+	cp1251Part, err := charmap.Windows1251.NewEncoder().String(cp1251Text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return []byte(utf8Part + cp1251Part), utf8Part + cp1251Text
+}
+
+func TestDetectRegions_Mixed(t *testing.T) {
+	data, _ := mixedSample(t)
+	regions := DetectRegions(data, "windows-1251")
+	if len(regions) != 2 {
+		t.Fatalf("expected 2 regions, got %d: %+v", len(regions), regions)
+	}
+	// The ASCII "-- Section 2" line stays with the preceding region
+	if regions[0].Charset != "utf-8" || regions[0].StartLine != 1 || regions[0].EndLine != 4 {
+		t.Errorf("unexpected first region: %+v", regions[0])
+	}
+	if regions[1].Charset != "windows-1251" || regions[1].StartLine != 5 || regions[1].EndLine != 6 {
+		t.Errorf("unexpected second region: %+v", regions[1])
+	}
+	if regions[0].EndByte != regions[1].StartByte || regions[1].EndByte != int64(len(data)) {
+		t.Errorf("regions should be contiguous and cover the file: %+v", regions)
+	}
+}
+
+func TestDetectRegions_SingleEncoding(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		charset string
+		lines   int
+	}{
+		{"empty", nil, "utf-8", 1},
+		{"ascii", []byte("a\nb\nc"), "utf-8", 3},
+		{"utf-8", []byte("a\nПривет\nb\n"), "utf-8", 3},
+		{"bom", []byte("\xEF\xBB\xBFa\nb"), "utf-8", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			regions := DetectRegions(tt.data, "windows-1251")
+			if len(regions) != 1 {
+				t.Fatalf("expected 1 region, got %+v", regions)
+			}
+			r := regions[0]
+			if r.Charset != tt.charset || r.StartLine != 1 || r.EndLine != tt.lines || r.EndByte != int64(len(tt.data)) {
+				t.Errorf("unexpected region: %+v", r)
+			}
+		})
+	}
+}
+
+func TestDetectRegions_FallbackForShortLegacy(t *testing.T) {
+	// A single short cp1251 line is too little for chardet; fallback is used.
+	data := []byte("Привет\n\xcf\xf0\xe8\n")
+	regions := DetectRegions(data, "windows-1251")
+	if len(regions) != 2 || regions[1].Charset != "windows-1251" {
+		t.Fatalf("expected fallback windows-1251 region, got %+v", regions)
+	}
+}
+
+func TestDecodeRegions(t *testing.T) {
+	data, want := mixedSample(t)
+	got, err := DecodeRegions(data, DetectRegions(data, "windows-1251"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("decoded text mismatch:\ngot:  %q\nwant: %q", got, want)
+	}
+	if strings.ContainsRune(got, '�') {
+		t.Error("decoded text contains replacement characters")
+	}
+}
+
+func TestDecodeRegions_UnsupportedEncoding(t *testing.T) {
+	data := []byte("abc")
+	_, err := DecodeRegions(data, []Region{{Charset: "no-such", EndByte: 3, StartLine: 1, EndLine: 1}})
+	if err == nil {
+		t.Fatal("expected error for unsupported encoding")
+	}
+}
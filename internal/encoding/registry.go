@@ -1,11 +1,17 @@
 package encoding
 
 import (
+	"fmt"
 	"sort"
 	"strings"
+	"sync"
 
 	"golang.org/x/text/encoding"
 	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
 	"golang.org/x/text/encoding/unicode"
 )
 
@@ -51,11 +57,39 @@ var encodings = map[string]EncodingInfo{
 	"windows-1257": {charmap.Windows1257, "Windows-1257", []string{"cp1257"}, "Windows Baltic"},
 	"windows-1258": {charmap.Windows1258, "Windows-1258", []string{"cp1258"}, "Windows Vietnamese"},
 	"windows-874":  {charmap.Windows874, "Windows-874", []string{"cp874", "tis-620"}, "Windows Thai"},
+
+	// Japanese
+	"shift_jis":   {japanese.ShiftJIS, "Shift_JIS", []string{"shift-jis", "sjis", "cp932", "windows-31j"}, "Japanese (Windows/DOS)"},
+	"euc-jp":      {japanese.EUCJP, "EUC-JP", []string{"eucjp"}, "Japanese (Unix/Linux)"},
+	"iso-2022-jp": {japanese.ISO2022JP, "ISO-2022-JP", []string{"iso2022jp", "jis"}, "Japanese (email, 7-bit)"},
+
+	// Chinese
+	"gbk":        {simplifiedchinese.GBK, "GBK", []string{"cp936", "gb2312", "windows-936"}, "Simplified Chinese (Windows)"},
+	"gb18030":    {simplifiedchinese.GB18030, "GB18030", []string{"gb-18030"}, "Simplified Chinese (full Unicode coverage)"},
+	"hz-gb-2312": {simplifiedchinese.HZGB2312, "HZ-GB-2312", []string{"hz"}, "Simplified Chinese (7-bit)"},
+	"big5":       {traditionalchinese.Big5, "Big5", []string{"big-5", "cp950"}, "Traditional Chinese"},
+
+	// Korean
+	"euc-kr": {korean.EUCKR, "EUC-KR", []string{"euckr", "cp949", "uhc"}, "Korean"},
+
+	// DOS (OEM) code pages
+	"ibm437": {charmap.CodePage437, "CP437", []string{"cp437", "dos-437"}, "DOS United States"},
+	"ibm850": {charmap.CodePage850, "CP850", []string{"cp850", "dos-850"}, "DOS Western European"},
+	"ibm852": {charmap.CodePage852, "CP852", []string{"cp852", "dos-852"}, "DOS Central European"},
+	"ibm855": {charmap.CodePage855, "CP855", []string{"cp855", "dos-855"}, "DOS Cyrillic (IBM)"},
+	"ibm858": {charmap.CodePage858, "CP858", []string{"cp858", "dos-858"}, "DOS Western European (Euro)"},
+	"ibm860": {charmap.CodePage860, "CP860", []string{"cp860", "dos-860"}, "DOS Portuguese"},
+	"ibm862": {charmap.CodePage862, "CP862", []string{"cp862", "dos-862"}, "DOS Hebrew"},
+	"ibm863": {charmap.CodePage863, "CP863", []string{"cp863", "dos-863"}, "DOS Canadian French"},
+	"ibm865": {charmap.CodePage865, "CP865", []string{"cp865", "dos-865"}, "DOS Nordic"},
 }
 
 // registry maps all names (canonical + aliases) to EncodingInfo for fast lookup.
 var registry map[string]*EncodingInfo
 
+// registryMu guards encodings and registry against concurrent registration.
+var registryMu sync.RWMutex
+
 func init() {
 	registry = make(map[string]*EncodingInfo)
 	for canonical, info := range encodings {
@@ -68,6 +102,8 @@ func init() {
 }
 
 func Get(name string) (encoding.Encoding, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
 	info, ok := registry[strings.ToLower(name)]
 	if !ok {
 		return nil, false
@@ -75,6 +111,25 @@ func Get(name string) (encoding.Encoding, bool) {
 	return info.Encoding, true
 }
 
+// CanonicalName returns the registered name of an encoding given its name or
+// one of its aliases.
+func CanonicalName(name string) (string, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	lower := strings.ToLower(name)
+	if _, ok := encodings[lower]; ok {
+		return lower, true
+	}
+	for canonical, info := range encodings {
+		for _, alias := range info.Aliases {
+			if alias == lower {
+				return canonical, true
+			}
+		}
+	}
+	return "", false
+}
+
 func IsUTF8(name string) bool {
 	lower := strings.ToLower(name)
 	return lower == "utf-8" || lower == "utf8" || lower == "ascii"
@@ -88,6 +143,8 @@ type EncodingListItem struct {
 }
 
 func ListEncodings() []EncodingListItem {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
 	var items []EncodingListItem
 	for canonical, info := range encodings {
 		items = append(items, EncodingListItem{
@@ -101,4 +158,42 @@ func ListEncodings() []EncodingListItem {
 		return items[i].DisplayName < items[j].DisplayName
 	})
 	return items
-}
\ No newline at end of file
+}
+
+// RegisterCodePage adds a custom code page to the registry under its name and aliases.
+// A name or alias already taken, by a built-in encoding or by an earlier custom
+// code page, is rejected, so each name always refers to one encoding.
+func RegisterCodePage(cp *CodePage) error {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	names := append([]string{cp.Name}, cp.Aliases...)
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		if seen[name] {
+			return fmt.Errorf("encoding %q is listed twice in custom encoding %s", name, cp.DisplayName)
+		}
+		seen[name] = true
+		existing, ok := registry[name]
+		if !ok {
+			continue
+		}
+		if isCodePage(existing) {
+			return fmt.Errorf("encoding %q is already registered by custom encoding %s", name, existing.DisplayName)
+		}
+		return fmt.Errorf("encoding %q conflicts with built-in encoding %s", name, existing.DisplayName)
+	}
+
+	info := EncodingInfo{cp, cp.DisplayName, cp.Aliases, cp.Description}
+	encodings[cp.Name] = info
+	for _, name := range names {
+		registry[name] = &info
+	}
+	return nil
+}
+
+// isCodePage reports whether info was added via RegisterCodePage.
+func isCodePage(info *EncodingInfo) bool {
+	_, ok := info.Encoding.(*CodePage)
+	return ok
+}
@@ -21,6 +21,20 @@ func TestGet(t *testing.T) {
 		{"utf-16-be", true, false},
 		{"utf16le", true, false},
 		{"utf16be", true, false},
+		{"shift_jis", true, false},
+		{"sjis", true, false},
+		{"cp932", true, false},
+		{"euc-jp", true, false},
+		{"iso-2022-jp", true, false},
+		{"gbk", true, false},
+		{"gb2312", true, false},
+		{"gb18030", true, false},
+		{"big5", true, false},
+		{"euc-kr", true, false},
+		{"cp949", true, false},
+		{"cp437", true, false},
+		{"CP850", true, false},
+		{"cp852", true, false},
 		{"invalid", false, false},
 	}
 
@@ -75,8 +89,76 @@ func TestListEncodings(t *testing.T) {
 		}
 	}
 
-	// Verify we have the expected number of encodings (22)
-	if len(items) != 22 {
-		t.Errorf("ListEncodings() returned %d items, want 22", len(items))
+	// Verify we have the expected number of encodings (39)
+	if len(items) != 39 {
+		t.Errorf("ListEncodings() returned %d items, want 39", len(items))
+	}
+}
+
+// TestGet_DetectorNames verifies that charset names reported by chardet
+// for East Asian and DOS encodings resolve to a registered encoding.
+func TestGet_DetectorNames(t *testing.T) {
+	for _, name := range []string{"shift_jis", "cp932", "euc-jp", "iso-2022-jp", "gb2312", "hz-gb-2312", "big5", "euc-kr", "cp949", "ibm855", "ibm866"} {
+		if _, ok := Get(name); !ok {
+			t.Errorf("Get(%q) not found", name)
+		}
+	}
+}
+
+func TestGet_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+	}{
+		{"shift_jis", "設定ファイル"},
+		{"euc-jp", "設定ファイル"},
+		{"iso-2022-jp", "設定ファイル"},
+		{"gbk", "配置文件"},
+		{"gb18030", "配置文件"},
+		{"big5", "設定檔案"},
+		{"euc-kr", "설정 파일"},
+		{"cp437", "Übersicht ░▒▓"},
+		{"cp850", "Configuración"},
+		{"cp852", "Nastavení"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			enc, ok := Get(tt.name)
+			if !ok {
+				t.Fatalf("Get(%q) not found", tt.name)
+			}
+			encoded, err := enc.NewEncoder().String(tt.text)
+			if err != nil {
+				t.Fatalf("encode failed: %v", err)
+			}
+			if encoded == tt.text {
+				t.Errorf("encoded text should differ from UTF-8 input")
+			}
+			decoded, err := enc.NewDecoder().String(encoded)
+			if err != nil {
+				t.Fatalf("decode failed: %v", err)
+			}
+			if decoded != tt.text {
+				t.Errorf("round trip = %q, want %q", decoded, tt.text)
+			}
+		})
+	}
+}
+
+func TestCanonicalName(t *testing.T) {
+	tests := map[string]string{
+		"cp1251":       "windows-1251",
+		"Windows-1251": "windows-1251",
+		"ASCII":        "utf-8",
+		"utf16le":      "utf-16-le",
+	}
+	for name, want := range tests {
+		if got, ok := CanonicalName(name); !ok || got != want {
+			t.Errorf("CanonicalName(%q) = %q, %v; want %q", name, got, ok, want)
+		}
+	}
+	if _, ok := CanonicalName("no-such-encoding"); ok {
+		t.Error("expected unknown encoding to fail")
 	}
 }
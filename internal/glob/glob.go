@@ -0,0 +1,631 @@
+// Package glob matches slash-separated paths against shell-style glob patterns.
+//
+// Supported syntax:
+//   - * matches any run of characters within a path segment, ? a single character
+//   - [abc], [a-z], [!a-z] (or [^a-z]) match one character from a class
+//   - ** as a whole segment matches zero or more segments, any number of times
+//   - {a,b} alternation, which may be nested and may contain slashes
+//   - extglob groups ?(a|b), *(a|b), +(a|b), @(a|b) and !(a|b) within a segment
+//   - a leading ! negates the whole pattern
+//   - \ escapes the next character (on Windows \ is a path separator instead)
+//
+// A pattern without a slash is matched against the last path segment only, so
+// *.pas matches src/main.pas. A leading / (or ./) anchors a pattern to the root:
+// /build matches build but not src/build.
+package glob
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxExpansions limits how many patterns brace alternation may expand to.
+const maxExpansions = 1024
+
+// Pattern is a compiled glob pattern.
+type Pattern struct {
+	source string
+	negate bool
+	alts   []alternative
+}
+
+// alternative is one brace expansion of a pattern.
+type alternative struct {
+	segments []segment
+	baseName bool // no slash in the pattern: match the last path segment
+}
+
+// segment matches one path segment, or any number of them for **.
+type segment struct {
+	doubleStar bool
+	tokens     []token
+}
+
+type tokenKind int
+
+const (
+	tokenLiteral tokenKind = iota
+	tokenStar              // *
+	tokenAny               // ?
+	tokenClass             // [...]
+	tokenGroup             // extglob group such as @(a|b)
+)
+
+type token struct {
+	kind    tokenKind
+	literal string
+	class   *charClass
+	op      byte      // group operator: ? * + @ !
+	alts    [][]token // group alternatives
+}
+
+// charClass is a bracket expression as a list of inclusive rune ranges.
+type charClass struct {
+	negate bool
+	ranges [][2]rune
+}
+
+func (c *charClass) matches(r rune) bool {
+	for _, rg := range c.ranges {
+		if r >= rg[0] && r <= rg[1] {
+			return !c.negate
+		}
+	}
+	return c.negate
+}
+
+// Compile parses a glob pattern.
+func Compile(pattern string) (*Pattern, error) {
+	p := &Pattern{source: pattern}
+	pattern = filepath.ToSlash(pattern)
+	if strings.HasPrefix(pattern, "!") && !strings.HasPrefix(pattern, "!(") {
+		p.negate = true
+		pattern = pattern[1:]
+	}
+	if pattern == "" {
+		return nil, fmt.Errorf("invalid pattern %q: empty", p.source)
+	}
+	expanded, err := expandBraces(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", p.source, err)
+	}
+	for _, exp := range expanded {
+		alt, err := parseAlternative(exp)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", p.source, err)
+		}
+		p.alts = append(p.alts, alt)
+	}
+	return p, nil
+}
+
+// String returns the pattern as given to Compile.
+func (p *Pattern) String() string {
+	return p.source
+}
+
+// Negated reports whether the pattern starts with !.
+func (p *Pattern) Negated() bool {
+	return p.negate
+}
+
+// Match reports whether a slash-separated path matches the pattern.
+// A negated pattern matches the paths its positive form does not.
+func (p *Pattern) Match(name string) bool {
+	return p.matches(name) != p.negate
+}
+
+// matches reports whether name matches the pattern, ignoring negation.
+func (p *Pattern) matches(name string) bool {
+	name = strings.TrimPrefix(name, "./")
+	parts := strings.Split(name, "/")
+	for _, alt := range p.alts {
+		if alt.baseName {
+			if matchTokens(alt.segments[0].tokens, path.Base(name)) {
+				return true
+			}
+			continue
+		}
+		if matchSegments(alt.segments, parts) {
+			return true
+		}
+	}
+	return false
+}
+
+// Set is an ordered list of patterns where the last matching pattern decides,
+// as in .gitignore: a path matches if the last pattern it matches is not negated.
+// A set of only negated patterns matches everything they do not exclude.
+type Set struct {
+	patterns []*Pattern
+}
+
+// CompileSet compiles a list of patterns. Empty patterns are ignored.
+func CompileSet(patterns []string) (*Set, error) {
+	s := &Set{}
+	for _, pattern := range patterns {
+		if pattern == "" {
+			continue
+		}
+		p, err := Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		s.patterns = append(s.patterns, p)
+	}
+	return s, nil
+}
+
+// Empty reports whether the set has no patterns. A nil set is empty.
+func (s *Set) Empty() bool {
+	return s == nil || len(s.patterns) == 0
+}
+
+// Match reports whether name matches the set. An empty set matches nothing.
+func (s *Set) Match(name string) bool {
+	if s.Empty() {
+		return false
+	}
+	result := true
+	for _, p := range s.patterns {
+		if !p.negate {
+			result = false
+			break
+		}
+	}
+	for _, p := range s.patterns {
+		if p.matches(name) {
+			result = !p.negate
+		}
+	}
+	return result
+}
+
+// MatchDir reports whether a directory matches, for skipping it during a walk.
+// Unlike Match, a set of only negated patterns does not match directories it
+// does not mention.
+func (s *Set) MatchDir(name string) bool {
+	if s.Empty() {
+		return false
+	}
+	result := false
+	for _, p := range s.patterns {
+		if p.matches(name) {
+			result = !p.negate
+		}
+	}
+	return result
+}
+
+// expandBraces expands {a,b} alternation into separate patterns. Braces without a
+// top-level comma are kept literally.
+func expandBraces(pattern string) ([]string, error) {
+	open := -1
+	depth := 0
+	for i := 0; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '\\':
+			i++
+		case '{':
+			if depth == 0 {
+				open = i
+			}
+			depth++
+		case '}':
+			if depth == 0 {
+				continue
+			}
+			depth--
+			if depth > 0 {
+				continue
+			}
+			options := splitTopLevel(pattern[open+1:i], ',')
+			if len(options) < 2 {
+				// Literal braces: keep them and continue after the group
+				rest, err := expandBraces(pattern[i+1:])
+				if err != nil {
+					return nil, err
+				}
+				return prefixAll(pattern[:i+1], rest), nil
+			}
+			prefix, suffix := pattern[:open], pattern[i+1:]
+			var result []string
+			for _, option := range options {
+				expanded, err := expandBraces(prefix + option + suffix)
+				if err != nil {
+					return nil, err
+				}
+				result = append(result, expanded...)
+				if len(result) > maxExpansions {
+					return nil, fmt.Errorf("braces expand to more than %d patterns", maxExpansions)
+				}
+			}
+			return result, nil
+		}
+	}
+	if depth > 0 {
+		return nil, fmt.Errorf("unclosed {")
+	}
+	return []string{pattern}, nil
+}
+
+func prefixAll(prefix string, items []string) []string {
+	for i := range items {
+		items[i] = prefix + items[i]
+	}
+	return items
+}
+
+// splitTopLevel splits s on sep outside of braces, parentheses and escapes.
+func splitTopLevel(s string, sep byte) []string {
+	var parts []string
+	depth := 0
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '{', '(':
+			depth++
+		case '}', ')':
+			depth--
+		case sep:
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// parseAlternative splits a brace-free pattern into segments.
+func parseAlternative(pattern string) (alternative, error) {
+	anchored := strings.HasPrefix(pattern, "/") || strings.HasPrefix(pattern, "./")
+	pattern = strings.TrimPrefix(pattern, "./")
+	pattern = strings.TrimPrefix(pattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/")
+	rawSegments := splitTopLevel(pattern, '/')
+	alt := alternative{baseName: len(rawSegments) == 1 && !anchored}
+	for _, raw := range rawSegments {
+		if raw == "**" {
+			// Consecutive ** are equivalent to one
+			if n := len(alt.segments); n > 0 && alt.segments[n-1].doubleStar {
+				continue
+			}
+			alt.segments = append(alt.segments, segment{doubleStar: true})
+			alt.baseName = false
+			continue
+		}
+		tokens, err := parseTokens(raw)
+		if err != nil {
+			return alt, err
+		}
+		alt.segments = append(alt.segments, segment{tokens: tokens})
+	}
+	return alt, nil
+}
+
+// parseTokens parses the glob syntax of a single segment.
+func parseTokens(s string) ([]token, error) {
+	var tokens []token
+	var lit strings.Builder
+	flush := func() {
+		if lit.Len() > 0 {
+			tokens = append(tokens, token{kind: tokenLiteral, literal: lit.String()})
+			lit.Reset()
+		}
+	}
+	for i := 0; i < len(s); {
+		c := s[i]
+		if strings.IndexByte("?*+@!", c) >= 0 && i+1 < len(s) && s[i+1] == '(' {
+			end, err := closingParen(s, i+1)
+			if err != nil {
+				return nil, err
+			}
+			var alts [][]token
+			for _, option := range splitTopLevel(s[i+2:end], '|') {
+				altTokens, err := parseTokens(option)
+				if err != nil {
+					return nil, err
+				}
+				alts = append(alts, altTokens)
+			}
+			flush()
+			tokens = append(tokens, token{kind: tokenGroup, op: c, alts: alts})
+			i = end + 1
+			continue
+		}
+		switch c {
+		case '\\':
+			if i+1 >= len(s) {
+				return nil, fmt.Errorf("trailing \\")
+			}
+			_, size := utf8.DecodeRuneInString(s[i+1:])
+			lit.WriteString(s[i+1 : i+1+size])
+			i += 1 + size
+		case '*':
+			flush()
+			if n := len(tokens); n == 0 || tokens[n-1].kind != tokenStar {
+				tokens = append(tokens, token{kind: tokenStar})
+			}
+			i++
+		case '?':
+			flush()
+			tokens = append(tokens, token{kind: tokenAny})
+			i++
+		case '[':
+			class, end, err := parseClass(s, i)
+			if err != nil {
+				return nil, err
+			}
+			flush()
+			tokens = append(tokens, token{kind: tokenClass, class: class})
+			i = end
+		default:
+			_, size := utf8.DecodeRuneInString(s[i:])
+			lit.WriteString(s[i : i+size])
+			i += size
+		}
+	}
+	flush()
+	return tokens, nil
+}
+
+// closingParen returns the index of the ) matching the ( at open.
+func closingParen(s string, open int) (int, error) {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unclosed (")
+}
+
+// parseClass parses a bracket expression starting at s[start] == '[' and returns
+// it with the index just past the closing ].
+func parseClass(s string, start int) (*charClass, int, error) {
+	class := &charClass{}
+	i := start + 1
+	if i < len(s) && (s[i] == '!' || s[i] == '^') {
+		class.negate = true
+		i++
+	}
+	first := true
+	for i < len(s) {
+		if s[i] == ']' && !first {
+			if len(class.ranges) == 0 {
+				return nil, 0, fmt.Errorf("empty character class")
+			}
+			return class, i + 1, nil
+		}
+		first = false
+		lo, size, err := classRune(s, i)
+		if err != nil {
+			return nil, 0, err
+		}
+		i += size
+		hi := lo
+		if i+1 < len(s) && s[i] == '-' && s[i+1] != ']' {
+			hi, size, err = classRune(s, i+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			i += 1 + size
+			if hi < lo {
+				return nil, 0, fmt.Errorf("invalid character range %c-%c", lo, hi)
+			}
+		}
+		class.ranges = append(class.ranges, [2]rune{lo, hi})
+	}
+	return nil, 0, fmt.Errorf("unclosed [")
+}
+
+// classRune reads one possibly escaped rune of a bracket expression.
+func classRune(s string, i int) (rune, int, error) {
+	if s[i] == '\\' {
+		if i+1 >= len(s) {
+			return 0, 0, fmt.Errorf("unclosed [")
+		}
+		r, size := utf8.DecodeRuneInString(s[i+1:])
+		return r, 1 + size, nil
+	}
+	r, size := utf8.DecodeRuneInString(s[i:])
+	return r, size, nil
+}
+
+// matchSegments matches path parts against segments, with ** spanning any number of parts.
+func matchSegments(segments []segment, parts []string) bool {
+	if len(segments) == 0 {
+		return len(parts) == 0
+	}
+	if segments[0].doubleStar {
+		for i := 0; i <= len(parts); i++ {
+			if matchSegments(segments[1:], parts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	return len(parts) > 0 && matchTokens(segments[0].tokens, parts[0]) && matchSegments(segments[1:], parts[1:])
+}
+
+// matchTokens reports whether tokens match all of s. Without extglob groups it
+// runs in linear time per star; with them, results are memoized.
+func matchTokens(tokens []token, s string) bool {
+	for _, t := range tokens {
+		if t.kind == tokenGroup {
+			m := &groupMatcher{memo: make(map[matchKey]bool)}
+			return m.match(tokens, s)
+		}
+	}
+	return matchFixed(tokens, s)
+}
+
+// matchFixed matches tokens that each match a fixed string, rune or class, plus
+// stars. As in path.Match, only the last star is backtracked to: matching more
+// with an earlier star never helps a later one.
+func matchFixed(tokens []token, s string) bool {
+	ti, si := 0, 0
+	star, starS := -1, 0
+	for ti < len(tokens) || si < len(s) {
+		if ti < len(tokens) {
+			switch t := tokens[ti]; t.kind {
+			case tokenStar:
+				star, starS = ti, si
+				ti++
+				continue
+			case tokenLiteral:
+				if strings.HasPrefix(s[si:], t.literal) {
+					ti, si = ti+1, si+len(t.literal)
+					continue
+				}
+			case tokenAny, tokenClass:
+				r, size := utf8.DecodeRuneInString(s[si:])
+				if size > 0 && (t.kind == tokenAny || t.class.matches(r)) {
+					ti, si = ti+1, si+size
+					continue
+				}
+			}
+		}
+		// Let the last star take one more rune and retry from there
+		if star < 0 || starS == len(s) {
+			return false
+		}
+		_, size := utf8.DecodeRuneInString(s[starS:])
+		starS += size
+		ti, si = star+1, starS
+	}
+	return true
+}
+
+// matchKey identifies a token list by its first token and length, as every list
+// matched is a tail of a parsed one. group is set for the repetitions of a *
+// group followed by the tokens.
+type matchKey struct {
+	first *token
+	n     int
+	group *[]token
+	s     string
+}
+
+// groupMatcher matches tokens containing extglob groups, backtracking over
+// wildcards and memoizing results so nested repetitions stay polynomial.
+type groupMatcher struct {
+	memo map[matchKey]bool
+}
+
+func (m *groupMatcher) match(tokens []token, s string) bool {
+	if len(tokens) == 0 {
+		return s == ""
+	}
+	key := matchKey{first: &tokens[0], n: len(tokens), s: s}
+	if matched, ok := m.memo[key]; ok {
+		return matched
+	}
+	matched := m.matchUncached(tokens, s)
+	m.memo[key] = matched
+	return matched
+}
+
+func (m *groupMatcher) matchUncached(tokens []token, s string) bool {
+	t, rest := tokens[0], tokens[1:]
+	switch t.kind {
+	case tokenLiteral:
+		return strings.HasPrefix(s, t.literal) && m.match(rest, s[len(t.literal):])
+	case tokenAny, tokenClass:
+		r, size := utf8.DecodeRuneInString(s)
+		if size == 0 || (t.kind == tokenClass && !t.class.matches(r)) {
+			return false
+		}
+		return m.match(rest, s[size:])
+	case tokenStar:
+		if len(rest) == 0 {
+			return true
+		}
+		return anySplit(s, 0, func(head, tail string) bool { return m.match(rest, tail) })
+	}
+
+	// Extglob group
+	switch t.op {
+	case '@':
+		return m.matchOnce(t.alts, rest, s)
+	case '?':
+		return m.match(rest, s) || m.matchOnce(t.alts, rest, s)
+	case '*':
+		return m.matchRepeated(t.alts, rest, s)
+	case '+':
+		return anySplit(s, 0, func(head, tail string) bool {
+			return m.altMatches(t.alts, head) && m.matchRepeated(t.alts, rest, tail)
+		})
+	case '!':
+		return anySplit(s, 0, func(head, tail string) bool {
+			return !m.altMatches(t.alts, head) && m.match(rest, tail)
+		})
+	}
+	return false
+}
+
+// matchOnce reports whether one of the alternatives followed by rest matches s.
+func (m *groupMatcher) matchOnce(alts [][]token, rest []token, s string) bool {
+	return anySplit(s, 0, func(head, tail string) bool {
+		return m.altMatches(alts, head) && m.match(rest, tail)
+	})
+}
+
+// matchRepeated reports whether zero or more non-empty repetitions of the
+// alternatives followed by rest match s.
+func (m *groupMatcher) matchRepeated(alts [][]token, rest []token, s string) bool {
+	if m.match(rest, s) {
+		return true
+	}
+	key := matchKey{group: &alts[0], n: len(rest), s: s}
+	if len(rest) > 0 {
+		key.first = &rest[0]
+	}
+	if matched, ok := m.memo[key]; ok {
+		return matched
+	}
+	matched := anySplit(s, 1, func(head, tail string) bool {
+		return m.altMatches(alts, head) && m.matchRepeated(alts, rest, tail)
+	})
+	m.memo[key] = matched
+	return matched
+}
+
+// altMatches reports whether one of the alternatives matches all of s.
+func (m *groupMatcher) altMatches(alts [][]token, s string) bool {
+	for _, alt := range alts {
+		if m.match(alt, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// anySplit calls fn for every split of s at a rune boundary with a head of at
+// least minHead bytes, and reports whether any call returned true.
+func anySplit(s string, minHead int, fn func(head, tail string) bool) bool {
+	for i := 0; ; {
+		if i >= minHead && fn(s[:i], s[i:]) {
+			return true
+		}
+		if i == len(s) {
+			return false
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+	}
+}
@@ -0,0 +1,152 @@
+package glob
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPatternMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		// Base name matching for patterns without a slash
+		{"*.pas", "main.pas", true},
+		{"*.pas", "src/forms/main.pas", true},
+		{"*.pas", "main.dfm", false},
+		{"main.?as", "main.pas", true},
+		{"node_modules", "a/node_modules", true},
+
+		// Paths and multiple **
+		{"src/*.pas", "src/main.pas", true},
+		{"src/*.pas", "src/forms/main.pas", false},
+		{"src/**/*.pas", "src/main.pas", true},
+		{"src/**/*.pas", "src/a/b/c/main.pas", true},
+		{"src/**/*.pas", "lib/main.pas", false},
+		{"**/test/**/*.inc", "test/x.inc", true},
+		{"**/test/**/*.inc", "a/b/test/c/d/x.inc", true},
+		{"**/test/**/*.inc", "a/tests/x.inc", false},
+		{"a/**/b/**/c", "a/x/b/y/z/c", true},
+		{"a/**/b/**/c", "a/x/y/z/c", false},
+		{"dir/**", "dir/a/b.txt", true},
+		{"**", "anything/at/all", true},
+		{"./src/*.go", "src/a.go", true},
+		{"/build", "build", true},
+		{"/build", "src/build", false},
+		{"./build", "src/build", false},
+
+		// Braces, nested and across segments
+		{"*.{pas,dfm}", "src/unit1.dfm", true},
+		{"*.{pas,dfm}", "unit1.inc", false},
+		{"src/**/*.{pas,dfm}", "src/a/unit1.pas", true},
+		{"{src,lib}/**/*.{p{as,p},inc}", "lib/x/y.pp", true},
+		{"{src/*.pas,*.inc}", "deep/dir/x.inc", true},
+		{"{a}.txt", "{a}.txt", true},
+
+		// Character classes
+		{"file[0-9].txt", "file7.txt", true},
+		{"file[0-9].txt", "filex.txt", false},
+		{"file[!0-9].txt", "filex.txt", true},
+		{"file[^0-9].txt", "file1.txt", false},
+		{"[]a].txt", "].txt", true},
+		{"[а-я]*.txt", "файл.txt", true},
+		{`\*.txt`, "*.txt", true},
+		{`\*.txt`, "a.txt", false},
+
+		// Extglob
+		{"@(main|unit1).pas", "unit1.pas", true},
+		{"@(main|unit1).pas", "unit2.pas", false},
+		{"*.@(pas|dpr)", "project.dpr", true},
+		{"unit?(1).pas", "unit.pas", true},
+		{"unit?(1).pas", "unit1.pas", true},
+		{"unit?(1).pas", "unit11.pas", false},
+		{"unit*(1).pas", "unit111.pas", true},
+		{"unit+(1).pas", "unit.pas", false},
+		{"unit+(1|2).pas", "unit1212.pas", true},
+		{"!(*_test).go", "main.go", true},
+		{"!(*_test).go", "main_test.go", false},
+		{"src/!(vendor)/*.go", "src/app/main.go", true},
+		{"src/!(vendor)/*.go", "src/vendor/main.go", false},
+
+		// Backtracking over several stars
+		{"*a*b*c", "xaybzc", true},
+		{"*a*b*c", "xaybzcb", false},
+		{"*ab?d", "abaabcd", true},
+		{"a*[0-9]", "a1b2", true},
+		{"a*[0-9]", "a1b", false},
+
+		// Negation
+		{"!*.bak", "a.txt", true},
+		{"!*.bak", "a.bak", false},
+	}
+	for _, tt := range tests {
+		p, err := Compile(tt.pattern)
+		if err != nil {
+			t.Errorf("Compile(%q) error: %v", tt.pattern, err)
+			continue
+		}
+		if got := p.Match(tt.path); got != tt.want {
+			t.Errorf("%q.Match(%q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestPatternMatch_NoExponentialBacktracking(t *testing.T) {
+	name := strings.Repeat("a", 4096)
+	for _, pattern := range []string{"*a*a*a*a*a*a*a*a*b", "+(a|aa)+(a|aa)*(a)b", "*(*a)b"} {
+		p, err := Compile(pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Match(name[:512]) {
+			t.Errorf("%q should not match", pattern)
+		}
+	}
+	if p, _ := Compile("*a*a*a*a*a*a*a*a*b"); !p.Match(name + "b") {
+		t.Error("expected a match")
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, pattern := range []string{"", "!", "[abc", "*.{pas,dfm", "@(a|b", `abc\`, "[z-a]"} {
+		if _, err := Compile(pattern); err == nil {
+			t.Errorf("Compile(%q) expected error", pattern)
+		}
+	}
+}
+
+func TestCompileExpansionLimit(t *testing.T) {
+	pattern := "{a,b}{a,b}{a,b}{a,b}{a,b}{a,b}{a,b}{a,b}{a,b}{a,b}{a,b}"
+	if _, err := Compile(pattern); err == nil {
+		t.Error("expected error for too many brace expansions")
+	}
+}
+
+func TestSetMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		path     string
+		want     bool
+	}{
+		{"empty set", nil, "a.txt", false},
+		{"any pattern", []string{"*.pas", "*.dfm"}, "src/a.dfm", true},
+		{"last match wins", []string{"*.pas", "!test_*"}, "test_a.pas", false},
+		{"re-included", []string{"*.bak", "!keep.bak", "keep.*"}, "keep.bak", true},
+		{"only negated matches the rest", []string{"!*.bak"}, "a.txt", true},
+		{"only negated excludes", []string{"!*.bak"}, "a.bak", false},
+		{"empty patterns ignored", []string{"", "*.go"}, "a.go", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := CompileSet(tt.patterns)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := s.Match(tt.path); got != tt.want {
+				t.Errorf("Match(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+// Package ignore evaluates .gitignore-style ignore files during directory walks.
+package ignore
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dimitar-grigorov/mcp-file-tools/internal/glob"
+)
+
+// ServerFileName is the ignore file read only by this server, for paths that
+// should stay out of tool results but not out of git.
+const ServerFileName = ".mcpignore"
+
+// FileNames lists the ignore files read in every directory, lowest precedence first.
+var FileNames = []string{".gitignore", ".ignore", ServerFileName}
+
+// rule is one line of an ignore file.
+type rule struct {
+	pattern *glob.Pattern
+	negate  bool // line starts with !: re-include
+	dirOnly bool // line ends with /: only matches directories
+}
+
+// Matcher decides whether paths below a base directory are ignored. Ignore files
+// are read lazily from every directory between the base and the path, and the
+// last matching rule wins, with deeper files taking precedence, as in git.
+// The .git directory is always ignored. A Matcher is not safe for concurrent use.
+type Matcher struct {
+	base  string
+	rules map[string][]rule // directory -> rules of its ignore files
+}
+
+// NewMatcher returns a matcher that honors ignore files in base and below.
+// Ignore files above base are not read.
+func NewMatcher(base string) *Matcher {
+	return &Matcher{base: filepath.Clean(base), rules: make(map[string][]rule)}
+}
+
+// Ignored reports whether path (absolute, below the base) is ignored. Walkers
+// should skip ignored directories: like git, a file inside an ignored directory
+// cannot be re-included. A nil Matcher ignores nothing.
+func (m *Matcher) Ignored(path string, isDir bool) bool {
+	if m == nil {
+		return false
+	}
+	path = filepath.Clean(path)
+	if isDir && filepath.Base(path) == ".git" {
+		return true
+	}
+	rel, err := filepath.Rel(m.base, path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false
+	}
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+
+	ignored := false
+	dir := m.base
+	for i := range parts {
+		// Rules of dir apply to the path relative to dir
+		relToDir := strings.Join(parts[i:], "/")
+		for _, r := range m.dirRules(dir) {
+			if r.dirOnly && !isDir {
+				continue
+			}
+			if r.pattern.Match(relToDir) {
+				ignored = !r.negate
+			}
+		}
+		dir = filepath.Join(dir, parts[i])
+	}
+	return ignored
+}
+
+// dirRules returns the rules of the ignore files in dir, reading them once.
+func (m *Matcher) dirRules(dir string) []rule {
+	if rules, ok := m.rules[dir]; ok {
+		return rules
+	}
+	var rules []rule
+	for _, name := range FileNames {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		rules = append(rules, parseRules(data)...)
+	}
+	m.rules[dir] = rules
+	return rules
+}
+
+// parseRules parses the content of an ignore file. Invalid patterns are skipped.
+func parseRules(data []byte) []rule {
+	var rules []rule
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if r, ok := parseLine(scanner.Text()); ok {
+			rules = append(rules, r)
+		}
+	}
+	return rules
+}
+
+// parseLine parses one ignore file line following gitignore syntax.
+func parseLine(line string) (rule, bool) {
+	line = strings.TrimSuffix(line, "\r")
+	line = trimTrailingSpaces(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return rule{}, false
+	}
+	var r rule
+	if strings.HasPrefix(line, "!") {
+		r.negate = true
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		r.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return rule{}, false
+	}
+	// A slash at the start or in the middle anchors the pattern to the ignore
+	// file's directory; otherwise it matches a name at any depth
+	if strings.Contains(line, "/") && !strings.HasPrefix(line, "/") {
+		line = "/" + line
+	}
+	// A remaining ! would read as glob negation
+	if strings.HasPrefix(line, "!") {
+		line = `\` + line
+	}
+	pattern, err := glob.Compile(line)
+	if err != nil {
+		return rule{}, false
+	}
+	r.pattern = pattern
+	return r, true
+}
+
+// trimTrailingSpaces removes trailing spaces unless escaped with a backslash.
+func trimTrailingSpaces(line string) string {
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+	return line
+}
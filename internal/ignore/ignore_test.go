@@ -0,0 +1,108 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMatcherIgnored(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ".gitignore"), "# build output\r\nbuild/\r\n/dist\r\n*.log\r\n!keep.log\r\ndoc/*.tmp\r\n__history \r\n")
+	writeFile(t, filepath.Join(root, "src", ".gitignore"), "*.gen.pas\n!important.log\n")
+	writeFile(t, filepath.Join(root, "src", ".ignore"), "secret.txt\n")
+	writeFile(t, filepath.Join(root, ServerFileName), "*.dcu\n")
+
+	m := NewMatcher(root)
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"build", true, true},
+		{"src/build", true, true},
+		{"build", false, false}, // directory-only rule
+		{"dist", true, true},
+		{"src/dist", true, false}, // anchored to the root
+		{"app.log", false, true},
+		{"src/deep/app.log", false, true},
+		{"keep.log", false, false},
+		{"src/important.log", false, false}, // re-included by a deeper file
+		{"important.log", false, true},
+		{"doc/a.tmp", false, true},
+		{"src/doc/a.tmp", false, false},
+		{"__history", true, true},
+		{"src/unit.gen.pas", false, true},
+		{"unit.gen.pas", false, false},
+		{"src/secret.txt", false, true},
+		{"main.dcu", false, true},
+		{"src/main.pas", false, false},
+		{".git", true, true},
+	}
+	for _, tt := range tests {
+		if got := m.Ignored(filepath.Join(root, filepath.FromSlash(tt.path)), tt.isDir); got != tt.want {
+			t.Errorf("Ignored(%q, dir=%v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestMatcherOutsideBaseAndNil(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "sub", ".gitignore"), "*.log\n")
+
+	// Ignore files above the base are not read
+	m := NewMatcher(filepath.Join(root, "sub", "inner"))
+	if m.Ignored(filepath.Join(root, "sub", "inner", "a.log"), false) {
+		t.Error("ignore file above the base should not apply")
+	}
+	if m.Ignored(filepath.Join(root, "other.log"), false) {
+		t.Error("paths outside the base are never ignored")
+	}
+	var nilMatcher *Matcher
+	if nilMatcher.Ignored(filepath.Join(root, "a.log"), false) {
+		t.Error("nil matcher should ignore nothing")
+	}
+}
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		line    string
+		ok      bool
+		negate  bool
+		dirOnly bool
+	}{
+		{"", false, false, false},
+		{"# comment", false, false, false},
+		{`\#file`, true, false, false},
+		{"!keep", true, true, false},
+		{`\!bang`, true, false, false},
+		{"out/", true, false, true},
+		{"/", false, false, false},
+		{"[abc", false, false, false},
+	}
+	for _, tt := range tests {
+		r, ok := parseLine(tt.line)
+		if ok != tt.ok || r.negate != tt.negate || r.dirOnly != tt.dirOnly {
+			t.Errorf("parseLine(%q) = %+v, %v", tt.line, r, ok)
+		}
+	}
+	if r, _ := parseLine(`\#file`); !r.pattern.Match("#file") {
+		t.Error(`\#file should match "#file"`)
+	}
+	if r, _ := parseLine(`\!bang`); !r.pattern.Match("!bang") {
+		t.Error(`\!bang should match "!bang"`)
+	}
+	if r, _ := parseLine(`trailing\ `); !r.pattern.Match("trailing ") {
+		t.Error("escaped trailing space should be kept")
+	}
+}
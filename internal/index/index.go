@@ -0,0 +1,365 @@
+// Package index maintains on-disk trigram indexes of directory trees, so content
+// searches can skip files that cannot match before reading them.
+//
+// Each root directory gets one index file in the cache directory. Files are
+// indexed by the trigrams of their decoded, case-folded UTF-8 text, so the index
+// works for any encoding and for case-insensitive queries. Entries record the
+// size and modification time of the file; a changed file is always searched and
+// triggers a background refresh, so the index never hides a match.
+package index
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/dimitar-grigorov/mcp-file-tools/internal/encoding"
+	"github.com/dimitar-grigorov/mcp-file-tools/internal/ignore"
+)
+
+// formatVersion changes whenever the on-disk format or the trigram scheme does.
+const formatVersion = 1
+
+// binaryCheckSize matches the prefix searched for null bytes by grep.
+const binaryCheckSize = 8192
+
+// entry describes one indexed file.
+type entry struct {
+	ModTime   int64 // UnixNano
+	Size      int64
+	Encoding  string // detected encoding the text was decoded with
+	Unindexed bool   // binary, too large or with invalid sequences: always searched
+	Trigrams  []uint32
+}
+
+// snapshot is the content of an index file.
+type snapshot struct {
+	Version int
+	Root    string
+	Built   int64             // UnixNano when the walk started
+	Files   map[string]*entry // slash-separated path relative to the root
+}
+
+// rootIndex is the index of one root directory.
+type rootIndex struct {
+	root string
+	path string // index file
+
+	mu      sync.RWMutex
+	snap    *snapshot
+	running bool // a build is in progress
+	again   bool // another build was requested while running
+}
+
+// Manager keeps the indexes of a set of root directories fresh.
+type Manager struct {
+	dir     string // where index files are stored
+	maxSize int64  // larger files are not indexed
+
+	mu    sync.Mutex
+	roots map[string]*rootIndex
+	wg    sync.WaitGroup
+}
+
+// NewManager returns a manager that stores index files in dir and does not
+// index files larger than maxSize bytes.
+func NewManager(dir string, maxSize int64) *Manager {
+	return &Manager{dir: dir, maxSize: maxSize, roots: make(map[string]*rootIndex)}
+}
+
+// Sync starts indexing every root that is not indexed yet. A previous index
+// file is loaded first and updated incrementally in the background. Roots that
+// are no longer listed are dropped from memory.
+func (m *Manager) Sync(roots []string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	keep := make(map[string]bool, len(roots))
+	for _, root := range roots {
+		root = filepath.Clean(root)
+		keep[root] = true
+		if _, ok := m.roots[root]; ok {
+			continue
+		}
+		sum := sha256.Sum256([]byte(root))
+		ri := &rootIndex{root: root, path: filepath.Join(m.dir, hex.EncodeToString(sum[:8])+".idx")}
+		m.roots[root] = ri
+		m.refresh(ri)
+	}
+	for root := range m.roots {
+		if !keep[root] {
+			delete(m.roots, root)
+		}
+	}
+}
+
+// Wait blocks until no build is running.
+func (m *Manager) Wait() {
+	if m != nil {
+		m.wg.Wait()
+	}
+}
+
+// Candidate reports whether the file at path may match q and must be searched.
+// Files outside the indexed roots, not indexed yet, changed since indexing or
+// decoded with a different encoding than forcedEncoding are always candidates.
+// A changed or new file schedules a refresh of its root.
+func (m *Manager) Candidate(path, forcedEncoding string, q *Query) bool {
+	if m == nil || q == nil {
+		return true
+	}
+	ri, rel := m.lookup(path)
+	if ri == nil {
+		return true
+	}
+	ri.mu.RLock()
+	snap := ri.snap
+	ri.mu.RUnlock()
+	if snap == nil {
+		return true
+	}
+	e := snap.Files[rel]
+	if e != nil && e.Unindexed {
+		return true
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return true
+	}
+	if e == nil || e.ModTime != info.ModTime().UnixNano() || e.Size != info.Size() {
+		// Files older than the walk are left out on purpose (e.g. ignored)
+		if e != nil || info.ModTime().UnixNano() >= snap.Built {
+			m.mu.Lock()
+			m.refresh(ri)
+			m.mu.Unlock()
+		}
+		return true
+	}
+	if forcedEncoding != "" && !strings.EqualFold(forcedEncoding, e.Encoding) {
+		return true
+	}
+	return q.matches(e.Trigrams)
+}
+
+// lookup returns the index of the innermost root containing path and the
+// path relative to it.
+func (m *Manager) lookup(path string) (*rootIndex, string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var best *rootIndex
+	var bestRel string
+	for root, ri := range m.roots {
+		rel, err := filepath.Rel(root, path)
+		if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if best == nil || len(root) > len(best.root) {
+			best, bestRel = ri, filepath.ToSlash(rel)
+		}
+	}
+	return best, bestRel
+}
+
+// refresh starts a background build of ri, or queues one if a build is running.
+// The caller holds m.mu.
+func (m *Manager) refresh(ri *rootIndex) {
+	ri.mu.Lock()
+	defer ri.mu.Unlock()
+	if ri.running {
+		ri.again = true
+		return
+	}
+	ri.running = true
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		for {
+			m.build(ri)
+			ri.mu.Lock()
+			if !ri.again {
+				ri.running = false
+				ri.mu.Unlock()
+				return
+			}
+			ri.again = false
+			ri.mu.Unlock()
+		}
+	}()
+}
+
+// build walks the root, reusing entries of unchanged files, then publishes and
+// saves the new snapshot.
+func (m *Manager) build(ri *rootIndex) {
+	ri.mu.RLock()
+	prev := ri.snap
+	ri.mu.RUnlock()
+	if prev == nil {
+		prev = load(ri.path, ri.root)
+		if prev != nil {
+			ri.mu.Lock()
+			ri.snap = prev
+			ri.mu.Unlock()
+		}
+	}
+
+	snap := &snapshot{
+		Version: formatVersion,
+		Root:    ri.root,
+		Built:   time.Now().UnixNano(),
+		Files:   make(map[string]*entry),
+	}
+	type job struct {
+		rel, path string
+		info      fs.FileInfo
+	}
+	type result struct {
+		rel string
+		e   *entry
+	}
+	jobs := make(chan job)
+	results := make(chan result)
+	var workers sync.WaitGroup
+	for i := 0; i < runtime.NumCPU(); i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for j := range jobs {
+				results <- result{j.rel, m.indexFile(j.path, j.info)}
+			}
+		}()
+	}
+	go func() {
+		workers.Wait()
+		close(results)
+	}()
+	go func() {
+		defer close(jobs)
+		ignores := ignore.NewMatcher(ri.root)
+		filepath.WalkDir(ri.root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if d.IsDir() {
+				if p != ri.root && ignores.Ignored(p, true) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !d.Type().IsRegular() || ignores.Ignored(p, false) {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			rel, err := filepath.Rel(ri.root, p)
+			if err != nil {
+				return nil
+			}
+			rel = filepath.ToSlash(rel)
+			if prev != nil {
+				if e := prev.Files[rel]; e != nil && e.ModTime == info.ModTime().UnixNano() && e.Size == info.Size() {
+					snap.Files[rel] = e
+					return nil
+				}
+			}
+			jobs <- job{rel, p, info}
+			return nil
+		})
+	}()
+	// The walker owns snap.Files until results are closed
+	var fresh []result
+	for r := range results {
+		fresh = append(fresh, r)
+	}
+	for _, r := range fresh {
+		snap.Files[r.rel] = r.e
+	}
+
+	ri.mu.Lock()
+	ri.snap = snap
+	ri.mu.Unlock()
+	if err := save(ri.path, snap); err != nil {
+		slog.Debug("failed to save content index", "root", ri.root, "error", err)
+	}
+}
+
+// indexFile reads and decodes one file the way grep does and returns its entry.
+func (m *Manager) indexFile(path string, info fs.FileInfo) *entry {
+	e := &entry{ModTime: info.ModTime().UnixNano(), Size: info.Size(), Unindexed: true}
+	if info.Size() > m.maxSize {
+		return e
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || bytes.IndexByte(data[:min(len(data), binaryCheckSize)], 0) >= 0 {
+		return e
+	}
+	e.Encoding = "utf-8"
+	if detection, err := encoding.DetectFromFile(path, "sample"); err == nil && detection.Charset != "" {
+		e.Encoding = strings.ToLower(detection.Charset)
+	}
+	text := data
+	if !encoding.IsUTF8(e.Encoding) {
+		if enc, ok := encoding.Get(e.Encoding); ok && enc != nil {
+			if text, err = io.ReadAll(enc.NewDecoder().Reader(bytes.NewReader(data))); err != nil {
+				return e
+			}
+		} else {
+			e.Encoding = "utf-8"
+		}
+	}
+	// Files with invalid sequences are reported by grep even without matches
+	if !utf8.Valid(text) || bytes.ContainsRune(text, utf8.RuneError) {
+		return e
+	}
+	e.Unindexed = false
+	e.Trigrams = trigrams(text)
+	return e
+}
+
+// load reads an index file, returning nil if it is missing, stale or corrupt.
+func load(path, root string) *snapshot {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	var snap snapshot
+	if err := gob.NewDecoder(f).Decode(&snap); err != nil || snap.Version != formatVersion || snap.Root != root {
+		return nil
+	}
+	return &snap
+}
+
+// save writes an index file atomically.
+func save(path string, snap *snapshot) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".idx-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := gob.NewEncoder(tmp).Encode(snap); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,124 @@
+package index
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+func TestNewQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		exprs []string
+		all   bool
+		text  string
+		want  bool
+	}{
+		{"literal present", []string{"TForm1"}, false, "type TForm1 = class", true},
+		{"literal absent", []string{"TForm1"}, false, "type TForm2 = class", false},
+		{"case folded", []string{"(?i)tform1"}, false, "TFORM1", true},
+		{"concat with class", []string{`Create\(\w+, nil\)`}, false, "Create(Self, nil)", true},
+		{"concat with class absent", []string{`Create\(\w+, nil\)`}, false, "Create(Self)", false},
+		{"required plus", []string{`(abc)+x`}, false, "zabcabc", true},
+		{"any of several", []string{"alpha", "beta"}, false, "only beta here", true},
+		{"all of several", []string{"alpha", "beta"}, true, "only beta here", false},
+		{"cyrillic folded", []string{"(?i)главная"}, false, "ГЛАВНАЯ форма", true},
+		{"literal split at newline", []string{`(?m)end;\nbegin`}, false, "end;\r\nbegin", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := NewQuery(tt.exprs, tt.all)
+			if q == nil {
+				t.Fatal("expected a query")
+			}
+			if got := q.matches(trigrams([]byte(tt.text))); got != tt.want {
+				t.Errorf("matches(%q) = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+
+	// Expressions without required literals cannot narrow the candidates
+	for _, exprs := range [][]string{{`\w+`}, {"ab"}, {"foo|bar"}, {"(foo)?"}, {"alpha", `\d+`}} {
+		if NewQuery(exprs, false) != nil {
+			t.Errorf("NewQuery(%q) expected nil", exprs)
+		}
+	}
+}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestManagerCandidate(t *testing.T) {
+	root := t.TempDir()
+	cacheDir := t.TempDir()
+	cp1251, _ := charmap.Windows1251.NewEncoder().String("// Главная форма приложения\r\nunit Main;\r\n")
+	mainFile := filepath.Join(root, "src", "main.pas")
+	writeFile(t, mainFile, []byte(cp1251))
+	otherFile := filepath.Join(root, "other.txt")
+	writeFile(t, otherFile, []byte("nothing to see\n"))
+	binFile := filepath.Join(root, "data.bin")
+	writeFile(t, binFile, []byte("Главная\x00"))
+
+	m := NewManager(cacheDir, 1<<20)
+	m.Sync([]string{root})
+	m.Wait()
+
+	q := NewQuery([]string{"(?i)главная ФОРМА"}, false)
+	if !m.Candidate(mainFile, "", q) {
+		t.Error("decoded cp1251 file must be a candidate")
+	}
+	if m.Candidate(otherFile, "", q) {
+		t.Error("file without the literal must not be a candidate")
+	}
+	if !m.Candidate(binFile, "", q) {
+		t.Error("unindexed files must always be candidates")
+	}
+	if !m.Candidate(mainFile, "utf-8", NewQuery([]string{"missing"}, false)) {
+		t.Error("files indexed with another encoding must be candidates")
+	}
+	if !m.Candidate(filepath.Join(t.TempDir(), "a.txt"), "", q) {
+		t.Error("files outside the roots must be candidates")
+	}
+
+	// A changed file is searched and re-indexed in the background
+	writeFile(t, otherFile, []byte("главная форма\n"))
+	os.Chtimes(otherFile, time.Now(), time.Now().Add(time.Second))
+	if !m.Candidate(otherFile, "", q) {
+		t.Error("changed file must be a candidate")
+	}
+	m.Wait()
+	if !m.Candidate(otherFile, "", q) {
+		t.Error("re-indexed file must be a candidate")
+	}
+
+	// The index is saved for the next start
+	snap := load(m.roots[filepath.Clean(root)].path, filepath.Clean(root))
+	if snap == nil || snap.Files["src/main.pas"] == nil || snap.Files["other.txt"] == nil {
+		t.Fatalf("expected saved index with both files, got %+v", snap)
+	}
+}
+
+func TestManagerRespectsIgnoreFiles(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, ".gitignore"), []byte("build/\n"))
+	ignored := filepath.Join(root, "build", "out.txt")
+	writeFile(t, ignored, []byte("target text\n"))
+
+	m := NewManager(t.TempDir(), 1<<20)
+	m.Sync([]string{root})
+	m.Wait()
+
+	if !m.Candidate(ignored, "", NewQuery([]string{"missing"}, false)) {
+		t.Error("files left out of the index must be candidates")
+	}
+}
@@ -0,0 +1,151 @@
+package index
+
+import (
+	"regexp/syntax"
+	"sort"
+	"unicode"
+	"unicode/utf8"
+)
+
+// foldRune maps a rune to the smallest rune of its case folding orbit, so text
+// and patterns index the same trigrams regardless of case. Go's (?i) matching
+// uses the same simple folding, which keeps case-insensitive queries exact.
+func foldRune(r rune) rune {
+	if r < utf8.RuneSelf {
+		if 'a' <= r && r <= 'z' {
+			return r - 'a' + 'A'
+		}
+		return r
+	}
+	min := r
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		if f < min {
+			min = f
+		}
+	}
+	return min
+}
+
+// trigrams returns the sorted, distinct byte trigrams of the case-folded text.
+// Trigrams never span line breaks or invalid sequences; queries never ask for them.
+func trigrams(text []byte) []uint32 {
+	set := make(map[uint32]struct{})
+	var folded []byte
+	flush := func() {
+		for i := 0; i+3 <= len(folded); i++ {
+			set[uint32(folded[i])<<16|uint32(folded[i+1])<<8|uint32(folded[i+2])] = struct{}{}
+		}
+		folded = folded[:0]
+	}
+	for len(text) > 0 {
+		r, size := utf8.DecodeRune(text)
+		text = text[size:]
+		if r == '\n' || r == '\r' || r == utf8.RuneError {
+			flush()
+			continue
+		}
+		folded = utf8.AppendRune(folded, foldRune(r))
+	}
+	flush()
+	out := make([]uint32, 0, len(set))
+	for t := range set {
+		out = append(out, t)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+// Query holds the trigrams that a file's text must contain to possibly match.
+type Query struct {
+	all  bool       // every pattern must match; otherwise any
+	sets [][]uint32 // required trigrams per pattern
+}
+
+// NewQuery builds a query for regular expressions in Go syntax. With all set, a
+// file must be able to match every expression; otherwise at least one. Returns
+// nil when the expressions cannot narrow the candidates, e.g. when one of several
+// alternatives requires no literal text.
+func NewQuery(exprs []string, all bool) *Query {
+	q := &Query{all: all}
+	for _, expr := range exprs {
+		re, err := syntax.Parse(expr, syntax.Perl)
+		if err != nil {
+			return nil
+		}
+		var set []uint32
+		for _, lit := range requiredLiterals(re.Simplify()) {
+			set = append(set, trigrams([]byte(lit))...)
+		}
+		if len(set) == 0 {
+			if !all {
+				return nil
+			}
+			continue
+		}
+		q.sets = append(q.sets, set)
+	}
+	if len(q.sets) == 0 {
+		return nil
+	}
+	return q
+}
+
+// matches reports whether a file with the given sorted trigrams may match.
+func (q *Query) matches(have []uint32) bool {
+	for _, set := range q.sets {
+		ok := containsAll(have, set)
+		if ok && !q.all {
+			return true
+		}
+		if !ok && q.all {
+			return false
+		}
+	}
+	return q.all
+}
+
+// containsAll reports whether the sorted slice have contains every trigram of want.
+func containsAll(have, want []uint32) bool {
+	for _, t := range want {
+		i := sort.Search(len(have), func(i int) bool { return have[i] >= t })
+		if i == len(have) || have[i] != t {
+			return false
+		}
+	}
+	return true
+}
+
+// requiredLiterals returns literal strings that every match of re contains.
+// It is conservative: anything it cannot reason about requires nothing.
+func requiredLiterals(re *syntax.Regexp) []string {
+	switch re.Op {
+	case syntax.OpLiteral:
+		return []string{string(re.Rune)}
+	case syntax.OpCapture, syntax.OpPlus:
+		return requiredLiterals(re.Sub[0])
+	case syntax.OpRepeat:
+		if re.Min >= 1 {
+			return requiredLiterals(re.Sub[0])
+		}
+	case syntax.OpConcat:
+		// Adjacent literals form one longer literal
+		var out []string
+		var run []rune
+		for _, sub := range re.Sub {
+			if sub.Op == syntax.OpLiteral {
+				run = append(run, sub.Rune...)
+				continue
+			}
+			if len(run) > 0 {
+				out = append(out, string(run))
+				run = nil
+			}
+			out = append(out, requiredLiterals(sub)...)
+		}
+		if len(run) > 0 {
+			out = append(out, string(run))
+		}
+		return out
+	}
+	return nil
+}
@@ -0,0 +1,260 @@
+// Package watch reports changes to files below a set of root directories and
+// keeps a journal of them, so clients can ask what changed since a point in time.
+//
+// Linux uses inotify; other platforms poll modification times. Paths excluded by
+// ignore files (see internal/ignore) are not watched, and edits to ignore files
+// take effect while watching.
+package watch
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dimitar-grigorov/mcp-file-tools/internal/ignore"
+)
+
+// Op is the kind of a change.
+type Op string
+
+const (
+	Created  Op = "created"
+	Modified Op = "modified"
+	Deleted  Op = "deleted"
+)
+
+// Native reports whether the platform has native change notifications. Without
+// them the watcher polls, which costs a scan of every root each interval.
+const Native = native
+
+// maxJournal is the number of changes kept; older ones are dropped.
+const maxJournal = 10000
+
+var (
+	// ErrInvalidToken is returned for a malformed token.
+	ErrInvalidToken = errors.New("invalid token")
+	// ErrTokenExpired is returned for a token from an earlier run, or one older
+	// than the oldest change kept in the journal.
+	ErrTokenExpired = errors.New("token expired")
+)
+
+// Change is one change to a file or directory.
+type Change struct {
+	Seq  uint64
+	Path string
+	Op   Op
+	Time time.Time
+}
+
+// backend delivers filesystem events to a Watcher.
+type backend interface {
+	add(root string)
+	remove(root string)
+	// refresh picks up directories of root that the ignore files no longer exclude.
+	refresh(root string)
+	close() error
+}
+
+// Watcher watches root directories recursively.
+type Watcher struct {
+	onChange func(Change)
+	backend  backend
+	started  time.Time
+
+	rootsMu sync.Mutex
+	roots   map[string]*ignore.Matcher
+
+	mu       sync.Mutex
+	journal  []Change
+	seq      uint64
+	dropped  time.Time // time of the newest change dropped from the journal
+	hasDrops bool
+}
+
+// New starts a watcher without roots. onChange, if not nil, is called for every
+// change from the watcher's goroutine and must not block.
+func New(onChange func(Change)) (*Watcher, error) {
+	w := &Watcher{
+		onChange: onChange,
+		started:  time.Now(),
+		roots:    make(map[string]*ignore.Matcher),
+	}
+	b, err := newBackend(w)
+	if err != nil {
+		return nil, err
+	}
+	w.backend = b
+	return w, nil
+}
+
+// Sync watches exactly the given roots. Roots inside another root are covered
+// by it and not watched separately.
+func (w *Watcher) Sync(roots []string) {
+	if w == nil {
+		return
+	}
+	want := make(map[string]bool)
+	for _, root := range roots {
+		root = filepath.Clean(root)
+		covered := false
+		for _, other := range roots {
+			other = filepath.Clean(other)
+			if other != root && within(other, root) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			want[root] = true
+		}
+	}
+
+	w.rootsMu.Lock()
+	var added, removed []string
+	for root := range w.roots {
+		if !want[root] {
+			delete(w.roots, root)
+			removed = append(removed, root)
+		}
+	}
+	for root := range want {
+		if _, ok := w.roots[root]; !ok {
+			w.roots[root] = ignore.NewMatcher(root)
+			added = append(added, root)
+		}
+	}
+	w.rootsMu.Unlock()
+
+	for _, root := range removed {
+		w.backend.remove(root)
+	}
+	for _, root := range added {
+		w.backend.add(root)
+	}
+}
+
+// Close stops watching.
+func (w *Watcher) Close() error {
+	if w == nil {
+		return nil
+	}
+	return w.backend.close()
+}
+
+// Started returns when the watcher started. Changes before it are unknown.
+func (w *Watcher) Started() time.Time {
+	return w.started
+}
+
+// Token returns an opaque token for the current position in the journal.
+func (w *Watcher) Token() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.token(w.seq)
+}
+
+func (w *Watcher) token(seq uint64) string {
+	return fmt.Sprintf("%d-%d", w.started.UnixNano(), seq)
+}
+
+// Since returns the changes after the position of token, oldest first, and the
+// token for the current position.
+func (w *Watcher) Since(token string) ([]Change, string, error) {
+	var started int64
+	var seq uint64
+	if _, err := fmt.Sscanf(token, "%d-%d", &started, &seq); err != nil {
+		return nil, "", ErrInvalidToken
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if started != w.started.UnixNano() || seq > w.seq {
+		return nil, "", ErrTokenExpired
+	}
+	// Changes after seq must all still be in the journal
+	if w.hasDrops && (len(w.journal) == 0 || w.journal[0].Seq > seq+1) {
+		return nil, "", ErrTokenExpired
+	}
+	var changes []Change
+	for _, c := range w.journal {
+		if c.Seq > seq {
+			changes = append(changes, c)
+		}
+	}
+	return changes, w.token(w.seq), nil
+}
+
+// After returns the changes made after t, oldest first, and the token for the
+// current position. ok is false when the journal does not reach back to t.
+func (w *Watcher) After(t time.Time) (changes []Change, token string, ok bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if t.Before(w.started) || (w.hasDrops && !t.After(w.dropped)) {
+		return nil, "", false
+	}
+	for _, c := range w.journal {
+		if c.Time.After(t) {
+			changes = append(changes, c)
+		}
+	}
+	return changes, w.token(w.seq), true
+}
+
+// emit records a change and reports it.
+func (w *Watcher) emit(path string, op Op) {
+	w.mu.Lock()
+	w.seq++
+	c := Change{Seq: w.seq, Path: path, Op: op, Time: time.Now()}
+	w.journal = append(w.journal, c)
+	if len(w.journal) > maxJournal {
+		drop := len(w.journal) - maxJournal
+		w.dropped = w.journal[drop-1].Time
+		w.hasDrops = true
+		w.journal = append(w.journal[:0], w.journal[drop:]...)
+	}
+	w.mu.Unlock()
+	if root, ok := w.reloadIgnores(path); ok {
+		w.backend.refresh(root)
+	}
+	if w.onChange != nil {
+		w.onChange(c)
+	}
+}
+
+// reloadIgnores drops the cached ignore rules of the root containing path if
+// path is an ignore file, returning that root.
+func (w *Watcher) reloadIgnores(path string) (string, bool) {
+	if !slices.Contains(ignore.FileNames, filepath.Base(path)) {
+		return "", false
+	}
+	w.rootsMu.Lock()
+	defer w.rootsMu.Unlock()
+	for root := range w.roots {
+		if within(root, path) {
+			w.roots[root] = ignore.NewMatcher(root)
+			return root, true
+		}
+	}
+	return "", false
+}
+
+// ignored reports whether path is excluded by the ignore files of its root.
+func (w *Watcher) ignored(path string, isDir bool) bool {
+	w.rootsMu.Lock()
+	defer w.rootsMu.Unlock()
+	for root, m := range w.roots {
+		if within(root, path) {
+			return m.Ignored(path, isDir)
+		}
+	}
+	return false
+}
+
+// within reports whether path is dir or below it.
+func within(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,167 @@
+//go:build linux
+
+package watch
+
+import (
+	"bytes"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const native = true
+
+// watchMask selects the inotify events that change the content or the set of files.
+const watchMask = unix.IN_CREATE | unix.IN_CLOSE_WRITE | unix.IN_DELETE |
+	unix.IN_MOVED_FROM | unix.IN_MOVED_TO | unix.IN_ONLYDIR
+
+// inotify watches every directory of the roots with one inotify instance.
+type inotify struct {
+	w    *Watcher
+	fd   int
+	file *os.File // non-blocking, so Close interrupts a pending Read
+
+	mu   sync.Mutex
+	dirs map[int]string // watch descriptor -> directory
+	wds  map[string]int
+}
+
+func newBackend(w *Watcher) (backend, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+	b := &inotify{
+		w:    w,
+		fd:   fd,
+		file: os.NewFile(uintptr(fd), "inotify"),
+		dirs: make(map[int]string),
+		wds:  make(map[string]int),
+	}
+	go b.readEvents()
+	return b, nil
+}
+
+func (b *inotify) add(root string) {
+	b.addTree(root, false)
+}
+
+func (b *inotify) remove(root string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for dir, wd := range b.wds {
+		if within(root, dir) {
+			unix.InotifyRmWatch(b.fd, uint32(wd))
+			delete(b.wds, dir)
+			delete(b.dirs, wd)
+		}
+	}
+}
+
+func (b *inotify) refresh(root string) {
+	b.addTree(root, false)
+}
+
+func (b *inotify) close() error {
+	return b.file.Close()
+}
+
+// addTree watches dir and the directories below it. With report set, files found
+// are reported as created, for directories created or moved in with content.
+func (b *inotify) addTree(dir string, report bool) {
+	filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if !d.IsDir() {
+			if report && !b.w.ignored(p, false) {
+				b.w.emit(p, Created)
+			}
+			return nil
+		}
+		if p != dir && b.w.ignored(p, true) {
+			return filepath.SkipDir
+		}
+		wd, err := unix.InotifyAddWatch(b.fd, p, watchMask)
+		if err != nil {
+			// ENOSPC: fs.inotify.max_user_watches is exhausted
+			slog.Warn("cannot watch directory", "path", p, "error", err)
+			return filepath.SkipDir
+		}
+		b.mu.Lock()
+		b.dirs[wd] = p
+		b.wds[p] = wd
+		b.mu.Unlock()
+		return nil
+	})
+}
+
+// forget drops the watches of dir and everything below it.
+func (b *inotify) forget(dir string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for p, wd := range b.wds {
+		if within(dir, p) {
+			unix.InotifyRmWatch(b.fd, uint32(wd))
+			delete(b.wds, p)
+			delete(b.dirs, wd)
+		}
+	}
+}
+
+func (b *inotify) readEvents() {
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := b.file.Read(buf)
+		if err != nil {
+			return
+		}
+		for off := 0; off+unix.SizeofInotifyEvent <= n; {
+			ev := (*unix.InotifyEvent)(unsafe.Pointer(&buf[off]))
+			nameStart := off + unix.SizeofInotifyEvent
+			off = nameStart + int(ev.Len)
+			name := string(bytes.TrimRight(buf[nameStart:min(off, n)], "\x00"))
+			b.handle(int(ev.Wd), ev.Mask, name)
+		}
+	}
+}
+
+func (b *inotify) handle(wd int, mask uint32, name string) {
+	if mask&unix.IN_Q_OVERFLOW != 0 {
+		slog.Warn("inotify event queue overflowed, some changes were not recorded")
+		return
+	}
+	b.mu.Lock()
+	dir, ok := b.dirs[wd]
+	if ok && mask&unix.IN_IGNORED != 0 {
+		delete(b.dirs, wd)
+		delete(b.wds, dir)
+	}
+	b.mu.Unlock()
+	if !ok || name == "" {
+		return
+	}
+	path := filepath.Join(dir, name)
+	isDir := mask&unix.IN_ISDIR != 0
+	if b.w.ignored(path, isDir) {
+		return
+	}
+	switch {
+	case isDir && mask&(unix.IN_CREATE|unix.IN_MOVED_TO) != 0:
+		b.addTree(path, true)
+	case isDir && mask&(unix.IN_DELETE|unix.IN_MOVED_FROM) != 0:
+		b.forget(path)
+		b.w.emit(path, Deleted)
+	case mask&(unix.IN_CREATE|unix.IN_MOVED_TO) != 0:
+		b.w.emit(path, Created)
+	case mask&unix.IN_CLOSE_WRITE != 0:
+		b.w.emit(path, Modified)
+	case mask&(unix.IN_DELETE|unix.IN_MOVED_FROM) != 0:
+		b.w.emit(path, Deleted)
+	}
+}
@@ -0,0 +1,131 @@
+//go:build !linux
+
+package watch
+
+import (
+	"io/fs"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const native = false
+
+// pollInterval is how often the roots are scanned for changes.
+const pollInterval = 2 * time.Second
+
+// fileState is what a scan remembers about a file.
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// poller detects changes by comparing periodic scans of the roots.
+type poller struct {
+	w    *Watcher
+	done chan struct{}
+	once sync.Once
+
+	mu    sync.Mutex
+	roots map[string]map[string]fileState
+}
+
+func newBackend(w *Watcher) (backend, error) {
+	p := &poller{w: w, done: make(chan struct{}), roots: make(map[string]map[string]fileState)}
+	go p.run()
+	return p, nil
+}
+
+func (p *poller) add(root string) {
+	files := p.scan(root)
+	p.mu.Lock()
+	p.roots[root] = files
+	p.mu.Unlock()
+}
+
+func (p *poller) remove(root string) {
+	p.mu.Lock()
+	delete(p.roots, root)
+	p.mu.Unlock()
+}
+
+// refresh does nothing: every poll scans with the current ignore rules.
+func (p *poller) refresh(root string) {}
+
+func (p *poller) close() error {
+	p.once.Do(func() { close(p.done) })
+	return nil
+}
+
+func (p *poller) run() {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+		}
+		p.mu.Lock()
+		roots := make([]string, 0, len(p.roots))
+		for root := range p.roots {
+			roots = append(roots, root)
+		}
+		p.mu.Unlock()
+		for _, root := range roots {
+			p.poll(root)
+		}
+	}
+}
+
+// poll scans root and reports the differences to the previous scan.
+func (p *poller) poll(root string) {
+	files := p.scan(root)
+	p.mu.Lock()
+	prev, ok := p.roots[root]
+	if ok {
+		p.roots[root] = files
+	}
+	p.mu.Unlock()
+	if !ok {
+		return
+	}
+	for path, state := range files {
+		old, existed := prev[path]
+		switch {
+		case !existed:
+			p.w.emit(path, Created)
+		case !old.modTime.Equal(state.modTime) || old.size != state.size:
+			p.w.emit(path, Modified)
+		}
+	}
+	for path := range prev {
+		if _, exists := files[path]; !exists {
+			p.w.emit(path, Deleted)
+		}
+	}
+}
+
+// scan records the state of every file below root.
+func (p *poller) scan(root string) map[string]fileState {
+	files := make(map[string]fileState)
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != root && p.w.ignored(path, true) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if p.w.ignored(path, false) {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			files[path] = fileState{modTime: info.ModTime(), size: info.Size()}
+		}
+		return nil
+	})
+	return files
+}
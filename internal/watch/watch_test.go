@@ -0,0 +1,134 @@
+package watch
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// waitFor waits until a change matching op and path arrives.
+func waitFor(t *testing.T, changes <-chan Change, path string, op Op) {
+	t.Helper()
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case c := <-changes:
+			if c.Path == path && c.Op == op {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for %s %s", op, path)
+		}
+	}
+}
+
+func TestWatcherReportsChanges(t *testing.T) {
+	root := t.TempDir()
+	os.WriteFile(filepath.Join(root, ".gitignore"), []byte("build/\n"), 0644)
+	os.Mkdir(filepath.Join(root, "build"), 0755)
+	existing := filepath.Join(root, "existing.txt")
+	os.WriteFile(existing, []byte("old"), 0644)
+
+	changes := make(chan Change, 100)
+	w, err := New(func(c Change) { changes <- c })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	w.Sync([]string{root})
+	token := w.Token()
+
+	// Ignored paths are not reported
+	os.WriteFile(filepath.Join(root, "build", "out.txt"), []byte("x"), 0644)
+
+	created := filepath.Join(root, "sub", "new.txt")
+	os.Mkdir(filepath.Join(root, "sub"), 0755)
+	os.WriteFile(created, []byte("new"), 0644)
+	waitFor(t, changes, created, Created)
+
+	os.WriteFile(existing, []byte("changed"), 0644)
+	waitFor(t, changes, existing, Modified)
+
+	os.Remove(existing)
+	waitFor(t, changes, existing, Deleted)
+
+	got, next, err := w.Since(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next == token || len(got) == 0 {
+		t.Errorf("expected changes and a new token, got %v %q", got, next)
+	}
+	for _, c := range got {
+		if filepath.Base(filepath.Dir(c.Path)) == "build" {
+			t.Errorf("ignored path reported: %+v", c)
+		}
+	}
+	if rest, _, _ := w.Since(next); len(rest) != 0 {
+		t.Errorf("expected no changes after the latest token, got %v", rest)
+	}
+}
+
+func TestWatcherReloadsIgnoreFiles(t *testing.T) {
+	root := t.TempDir()
+	gitignore := filepath.Join(root, ".gitignore")
+	os.WriteFile(gitignore, []byte("build/\n"), 0644)
+	os.Mkdir(filepath.Join(root, "build"), 0755)
+
+	changes := make(chan Change, 100)
+	w, err := New(func(c Change) { changes <- c })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	w.Sync([]string{root})
+
+	os.WriteFile(gitignore, []byte("*.log\n"), 0644)
+	waitFor(t, changes, gitignore, Modified)
+
+	// build/ is no longer ignored once .gitignore changed
+	out := filepath.Join(root, "build", "out.txt")
+	os.WriteFile(out, []byte("x"), 0644)
+	waitFor(t, changes, out, Created)
+}
+
+func TestJournal(t *testing.T) {
+	w := &Watcher{started: time.Now()}
+	token := w.Token()
+	w.emit("/a", Created)
+	w.emit("/b", Modified)
+
+	changes, next, err := w.Since(token)
+	if err != nil || len(changes) != 2 || changes[0].Path != "/a" || changes[1].Op != Modified {
+		t.Fatalf("unexpected changes %v, err %v", changes, err)
+	}
+	if changes, _, _ := w.Since(next); len(changes) != 0 {
+		t.Errorf("expected no changes, got %v", changes)
+	}
+	if changes, _, ok := w.After(w.started); !ok || len(changes) != 2 {
+		t.Errorf("expected 2 changes after start, got %v (ok=%v)", changes, ok)
+	}
+	if _, _, ok := w.After(w.started.Add(-time.Second)); ok {
+		t.Error("expected times before the start to be unknown")
+	}
+
+	if _, _, err := w.Since("garbage"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected ErrInvalidToken, got %v", err)
+	}
+	if _, _, err := w.Since("1-0"); !errors.Is(err, ErrTokenExpired) {
+		t.Errorf("expected ErrTokenExpired for another run, got %v", err)
+	}
+
+	// Tokens older than the journal expire
+	for i := 0; i < maxJournal; i++ {
+		w.emit("/c", Modified)
+	}
+	if _, _, err := w.Since(token); !errors.Is(err, ErrTokenExpired) {
+		t.Errorf("expected ErrTokenExpired after the journal overflowed, got %v", err)
+	}
+	if _, _, err := w.Since(w.Token()); err != nil {
+		t.Errorf("expected current token to be valid, got %v", err)
+	}
+}